@@ -0,0 +1,172 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"sync"
+
+	"github.com/haysons/gokit/app"
+	"github.com/haysons/gokit/config"
+	"github.com/haysons/gokit/health"
+	"github.com/haysons/gokit/transport"
+)
+
+var (
+	_ transport.Server  = (*Server)(nil)
+	_ transport.Readier = (*Server)(nil)
+)
+
+// DefaultAddr 未配置监听地址时的默认地址，仅监听本机回环地址
+const DefaultAddr = "127.0.0.1:6060"
+
+// Config 管理服务配置项
+type Config struct {
+	// 服务监听的地址，host:port，应与业务端口分离，避免对外暴露。为空时使用 DefaultAddr，
+	// 未指定 host 时仅监听 127.0.0.1，需监听全部网卡时应显式指定 0.0.0.0
+	Addr string `mapstructure:"addr"`
+}
+
+// Option 函数式配置项
+type Option func(*Server)
+
+// WithConfig 整体替换配置
+func WithConfig(cfg Config) Option {
+	return func(s *Server) {
+		s.cfg = cfg
+	}
+}
+
+// WithAddr 配置服务监听地址
+func WithAddr(addr string) Option {
+	return func(s *Server) {
+		s.cfg.Addr = addr
+	}
+}
+
+// WithApp 配置应用，/version 将返回应用名称、版本号及提交号
+func WithApp(a *app.App) Option {
+	return func(s *Server) {
+		s.app = a
+	}
+}
+
+// WithSettings 配置当前生效的配置项，/config 将返回脱敏后的配置项
+func WithSettings(settings config.Settings) Option {
+	return func(s *Server) {
+		s.settings = settings
+	}
+}
+
+// WithHealth 配置健康检查，将注册 /healthz 及 /readyz
+func WithHealth(registry *health.Registry) Option {
+	return func(s *Server) {
+		s.health = registry
+	}
+}
+
+// WithRedactKeys 追加需要脱敏的配置项名称关键字，配置项名称包含关键字（忽略大小写）时，其值将被隐藏
+func WithRedactKeys(keys ...string) Option {
+	return func(s *Server) {
+		s.redactKeys = append(s.redactKeys, keys...)
+	}
+}
+
+// Server 管理服务，提供 pprof、运行时指标、版本信息、当前配置及日志级别等调试接口，需监听独立端口
+type Server struct {
+	cfg        Config
+	app        *app.App
+	settings   config.Settings
+	health     *health.Registry
+	redactKeys []string
+
+	mux        *http.ServeMux
+	httpServer *http.Server
+	ready      chan struct{}
+	readyOnce  sync.Once
+}
+
+// NewServer 创建管理服务
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		redactKeys: defaultRedactKeys,
+		mux:        http.NewServeMux(),
+		ready:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.cfg.Addr = listenAddr(s.cfg.Addr)
+	s.registerRoutes()
+	s.httpServer = &http.Server{Handler: s.mux}
+	return s
+}
+
+func (s *Server) registerRoutes() {
+	s.mux.HandleFunc("/debug/pprof/", pprof.Index)
+	s.mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	s.mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	s.mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	s.mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	s.mux.Handle("/debug/vars", varsHandler())
+	s.mux.HandleFunc("/version", s.handleVersion)
+	s.mux.HandleFunc("/config", s.handleConfig)
+	s.mux.HandleFunc("/loglevel", handleLogLevel)
+	if s.health != nil {
+		s.health.RegisterHTTP(s.mux)
+	}
+}
+
+// listenAddr 补全监听地址，为空时使用 DefaultAddr，未指定 host 时使用回环地址，避免调试接口意外对外暴露
+func listenAddr(addr string) string {
+	if addr == "" {
+		return DefaultAddr
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host != "" {
+		return addr
+	}
+	return net.JoinHostPort("127.0.0.1", port)
+}
+
+// Handle 注册自定义调试接口
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Handler 返回管理服务的 http handler
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// Start 启动管理服务，此方法将阻塞直至服务停止
+func (s *Server) Start(ctx context.Context) error {
+	lis, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	s.readyOnce.Do(func() { close(s.ready) })
+	if err = s.httpServer.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Stop 停止管理服务
+func (s *Server) Stop(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
+
+// Ready 返回的管道将在端口监听完成后关闭
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,144 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haysons/gokit/app"
+	"github.com/haysons/gokit/health"
+	"github.com/haysons/gokit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+)
+
+type staticSettings map[string]any
+
+func (s staticSettings) AllSettings() map[string]any { return s }
+
+func doRequest(t *testing.T, h http.Handler, method, target string) (int, map[string]any) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	var body map[string]any
+	if strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	}
+	return rec.Code, body
+}
+
+func TestListenAddr(t *testing.T) {
+	// 未配置地址或未指定 host 时仅监听回环地址
+	assert.Equal(t, DefaultAddr, NewServer().cfg.Addr)
+	assert.Equal(t, "127.0.0.1:9090", NewServer(WithAddr(":9090")).cfg.Addr)
+	assert.Equal(t, "0.0.0.0:9090", NewServer(WithAddr("0.0.0.0:9090")).cfg.Addr)
+	assert.Equal(t, "[::1]:9090", NewServer(WithAddr("[::1]:9090")).cfg.Addr)
+}
+
+func TestVersion(t *testing.T) {
+	a := app.New(app.WithName("demo"), app.WithVersion("v1.0.0"), app.WithCommit("abc123"))
+	s := NewServer(WithApp(a))
+
+	code, body := doRequest(t, s.Handler(), http.MethodGet, "/version")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "demo", body["name"])
+	assert.Equal(t, "v1.0.0", body["version"])
+	assert.Equal(t, "abc123", body["commit"])
+	assert.NotEmpty(t, body["go_version"])
+}
+
+func TestConfigRedact(t *testing.T) {
+	s := NewServer(WithSettings(staticSettings{
+		"app": map[string]any{"name": "demo"},
+		"mysql": map[string]any{
+			"dsn":      "ENC(abcdef)",
+			"password": "123456",
+			"hosts":    []any{"a", "ENC(xyz)"},
+		},
+		"jwt_token": "raw",
+		"custom":    map[string]any{"license": "raw"},
+	}), WithRedactKeys("license"))
+
+	code, body := doRequest(t, s.Handler(), http.MethodGet, "/config")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]any{"name": "demo"}, body["app"])
+	mysql := body["mysql"].(map[string]any)
+	assert.Equal(t, redacted, mysql["dsn"])
+	assert.Equal(t, redacted, mysql["password"])
+	assert.Equal(t, []any{"a", redacted}, mysql["hosts"])
+	assert.Equal(t, redacted, body["jwt_token"])
+	assert.Equal(t, redacted, body["custom"].(map[string]any)["license"])
+
+	code, _ = doRequest(t, NewServer().Handler(), http.MethodGet, "/config")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestLogLevel(t *testing.T) {
+	origin := log.GetLevel()
+	defer func() { _ = log.SetLevel(origin.String()) }()
+
+	h := NewServer().Handler()
+	code, body := doRequest(t, h, http.MethodPut, "/loglevel?level=warn")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "WARN", body["level"])
+
+	code, body = doRequest(t, h, http.MethodGet, "/loglevel")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "WARN", body["level"])
+
+	code, _ = doRequest(t, h, http.MethodPut, "/loglevel?level=unknown")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = doRequest(t, h, http.MethodDelete, "/loglevel")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+}
+
+func TestDebugRoutes(t *testing.T) {
+	registry := health.NewRegistry()
+	h := NewServer(WithHealth(registry)).Handler()
+
+	code, body := doRequest(t, h, http.MethodGet, "/debug/vars")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, "runtime")
+	assert.Contains(t, body, "memstats")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	code, _ = doRequest(t, h, http.MethodGet, health.ReadinessPath)
+	assert.Equal(t, http.StatusOK, code)
+	registry.Shutdown()
+	code, _ = doRequest(t, h, http.MethodGet, health.ReadinessPath)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+}
+
+func TestModule(t *testing.T) {
+	var s *Server
+	a := app.New(
+		app.WithName("demo"),
+		app.WithModules(health.Module, Module(WithAddr("127.0.0.1:18090"))),
+		app.WithInvokes(fx.Populate(&s)),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	require.NoError(t, a.Start(ctx))
+	defer a.Stop(ctx)
+
+	require.NotNil(t, s)
+	resp, err := http.Get("http://127.0.0.1:18090/version")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var info versionInfo
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&info))
+	assert.Equal(t, "demo", info.Name)
+
+	resp, err = http.Get("http://127.0.0.1:18090/readyz")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,150 @@
+package admin
+
+import (
+	"expvar"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/haysons/gokit/config"
+	"github.com/haysons/gokit/log"
+)
+
+const redacted = "******"
+
+// defaultRedactKeys 默认需要脱敏的配置项名称关键字
+var defaultRedactKeys = []string{"password", "passwd", "secret", "token", "credential", "private_key", "access_key"}
+
+var (
+	startTime   = time.Now()
+	publishOnce sync.Once
+)
+
+// varsHandler 以 expvar 格式输出运行时指标，除 expvar 默认的 cmdline 及 memstats 外，额外发布 runtime 指标
+func varsHandler() http.Handler {
+	publishOnce.Do(func() {
+		expvar.Publish("runtime", expvar.Func(runtimeStats))
+	})
+	return expvar.Handler()
+}
+
+func runtimeStats() any {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return map[string]any{
+		"go_version":      runtime.Version(),
+		"goroutines":      runtime.NumGoroutine(),
+		"num_cpu":         runtime.NumCPU(),
+		"gomaxprocs":      runtime.GOMAXPROCS(0),
+		"uptime_seconds":  int64(time.Since(startTime).Seconds()),
+		"heap_alloc":      m.HeapAlloc,
+		"heap_inuse":      m.HeapInuse,
+		"heap_objects":    m.HeapObjects,
+		"sys":             m.Sys,
+		"num_gc":          m.NumGC,
+		"pause_total_ns":  m.PauseTotalNs,
+		"last_gc_unix_ns": m.LastGC,
+	}
+}
+
+// versionInfo /version 接口的返回值
+type versionInfo struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	GoVersion string `json:"go_version"`
+	StartTime string `json:"start_time"`
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, _ *http.Request) {
+	info := versionInfo{
+		GoVersion: runtime.Version(),
+		StartTime: startTime.Format(time.RFC3339),
+	}
+	if s.app != nil {
+		cfg := s.app.Config()
+		info.Name, info.Version, info.Commit = cfg.Name, cfg.Version, cfg.Commit
+	}
+	// 未指定提交号时，使用编译时嵌入的 vcs 信息
+	if info.Commit == "" {
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			for _, setting := range bi.Settings {
+				if setting.Key == "vcs.revision" {
+					info.Commit = setting.Value
+				}
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (s *Server) handleConfig(w http.ResponseWriter, _ *http.Request) {
+	if s.settings == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "config is not available"})
+		return
+	}
+	writeJSON(w, http.StatusOK, s.redact(s.settings.AllSettings()))
+}
+
+// redact 递归地隐藏敏感配置项及加密配置项的值
+func (s *Server) redact(settings map[string]any) map[string]any {
+	out := make(map[string]any, len(settings))
+	for k, v := range settings {
+		if s.sensitive(k) {
+			out[k] = redacted
+			continue
+		}
+		out[k] = s.redactValue(v)
+	}
+	return out
+}
+
+func (s *Server) redactValue(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		return s.redact(val)
+	case []any:
+		items := make([]any, len(val))
+		for i, item := range val {
+			items[i] = s.redactValue(item)
+		}
+		return items
+	case string:
+		if config.IsSecret(val) {
+			return redacted
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+func (s *Server) sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range s.redactKeys {
+		if strings.Contains(key, strings.ToLower(k)) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleLogLevel GET 获取当前日志级别，PUT/POST 通过 level 参数调整日志级别
+func handleLogLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		if err := log.SetLevel(r.FormValue("level")); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"level": log.GetLevel().String()})
+}
@@ -0,0 +1,39 @@
+package admin
+
+import (
+	"github.com/haysons/gokit/app"
+	"github.com/haysons/gokit/config"
+	"github.com/haysons/gokit/health"
+	"go.uber.org/fx"
+)
+
+// Module 管理服务的 fx 模块，管理服务将作为 transport.Server 加入 "servers" 分组，由 App 管理其生命周期，
+// 依赖图中存在 *app.App、config.Settings 或 *health.Registry 时将自动启用对应接口
+func Module(opts ...Option) fx.Option {
+	return fx.Module("admin",
+		fx.Provide(
+			func(p moduleParams) *Server {
+				options := make([]Option, 0, len(opts)+3)
+				if p.App != nil {
+					options = append(options, WithApp(p.App))
+				}
+				if p.Settings != nil {
+					options = append(options, WithSettings(p.Settings))
+				}
+				if p.Health != nil {
+					options = append(options, WithHealth(p.Health))
+				}
+				return NewServer(append(options, opts...)...)
+			},
+		),
+		fx.Provide(app.AsServer(func(s *Server) *Server { return s })),
+	)
+}
+
+type moduleParams struct {
+	fx.In
+
+	App      *app.App         `optional:"true"`
+	Settings config.Settings  `optional:"true"`
+	Health   *health.Registry `optional:"true"`
+}
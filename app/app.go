@@ -2,9 +2,19 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/haysons/gokit/log"
+	"github.com/haysons/gokit/registry"
+	"github.com/haysons/gokit/transport"
+	"github.com/rs/xid"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxevent"
 )
@@ -14,18 +24,45 @@ type Config struct {
 	Version string `mapstructure:"version"` // app 版本
 	Commit  string `mapstructure:"commit"`  // git 提交号
 
-	fxOptions []fx.Option // uber fx 配置项
+	ID        string            `mapstructure:"id"`        // 实例唯一标识，默认为 hostname-xid
+	Metadata  map[string]string `mapstructure:"metadata"`  // 注册至注册中心的实例元数据
+	Endpoints []string          `mapstructure:"endpoints"` // 注册至注册中心的入口点，默认使用实现了 transport.Endpointer 的服务的入口点
+
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"` // 单个服务停止的超时时间，默认为10s
+	DrainDelay      time.Duration `mapstructure:"drain_delay"`      // 收到停止信号后，标记应用不健康并等待负载均衡摘除流量的时间，默认不等待
+	KillTimeout     time.Duration `mapstructure:"kill_timeout"`     // 优雅关闭的最长时间，超时后将强制退出进程，默认为30s
+
+	fxOptions     []fx.Option        // uber fx 配置项
+	servers       []transport.Server // 由 App 管理生命周期的服务
+	registrar     registry.Registrar // 服务注册
+	stopSignals   []os.Signal        // 触发应用停止的信号
+	reloadSignals []os.Signal        // 触发重新加载的信号
+	reloadHooks   []Hook             // 收到重新加载信号时执行的钩子
+	beforeStop    []Hook             // 停止应用前执行的钩子
+	afterStop     []Hook             // 停止应用后执行的钩子
 }
 
+// Hook 应用生命周期钩子
+type Hook func(ctx context.Context) error
+
 // Option 函数式配置项
 type Option func(*Config)
 
 // WithConfig 整体替换配置
 func WithConfig(cfg Config) Option {
 	return func(c *Config) {
-		fxOpts := c.fxOptions
+		prev := *c
 		*c = cfg
-		c.fxOptions = append(c.fxOptions, fxOpts...)
+		c.fxOptions = append(c.fxOptions, prev.fxOptions...)
+		c.servers = append(c.servers, prev.servers...)
+		c.stopSignals = append(c.stopSignals, prev.stopSignals...)
+		c.reloadSignals = append(c.reloadSignals, prev.reloadSignals...)
+		c.reloadHooks = append(c.reloadHooks, prev.reloadHooks...)
+		c.beforeStop = append(c.beforeStop, prev.beforeStop...)
+		c.afterStop = append(c.afterStop, prev.afterStop...)
+		if c.registrar == nil {
+			c.registrar = prev.registrar
+		}
 	}
 }
 
@@ -50,6 +87,35 @@ func WithCommit(commit string) Option {
 	}
 }
 
+// WithID 配置实例唯一标识
+func WithID(id string) Option {
+	return func(c *Config) {
+		c.ID = id
+	}
+}
+
+// WithMetadata 配置注册至注册中心的实例元数据
+func WithMetadata(md map[string]string) Option {
+	return func(c *Config) {
+		c.Metadata = md
+	}
+}
+
+// WithEndpoints 配置注册至注册中心的入口点，如 grpc://10.0.0.1:9000，默认使用实现了 transport.Endpointer 的服务的入口点
+func WithEndpoints(endpoints ...string) Option {
+	return func(c *Config) {
+		c.Endpoints = endpoints
+	}
+}
+
+// WithRegistrar 配置服务注册，全部服务就绪后注册实例，停止时先注销实例再停止服务，
+// 依赖图中存在 registry.Registrar 时也将自动使用
+func WithRegistrar(r registry.Registrar) Option {
+	return func(c *Config) {
+		c.registrar = r
+	}
+}
+
 // WithProvides 配置服务提供者，用于依赖注入
 func WithProvides(provides ...fx.Option) Option {
 	return func(c *Config) {
@@ -71,10 +137,83 @@ func WithModules(mods ...fx.Option) Option {
 	}
 }
 
+// WithServers 配置由 App 管理生命周期的服务，应用启动时并发启动全部服务，应用停止时逆序停止，
+// 也可通过 AsServer 将服务提供至 fx 的 "servers" 分组中
+func WithServers(srvs ...transport.Server) Option {
+	return func(c *Config) {
+		c.servers = append(c.servers, srvs...)
+	}
+}
+
+// WithShutdownTimeout 配置单个服务停止的超时时间
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.ShutdownTimeout = timeout
+	}
+}
+
+// WithDrainDelay 配置收到停止信号后，停止服务前等待负载均衡摘除流量的时间
+func WithDrainDelay(delay time.Duration) Option {
+	return func(c *Config) {
+		c.DrainDelay = delay
+	}
+}
+
+// WithKillTimeout 配置优雅关闭的最长时间，超时后将强制退出进程
+func WithKillTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.KillTimeout = timeout
+	}
+}
+
+// WithSignals 配置触发应用停止的信号，默认为 SIGINT 及 SIGTERM
+func WithSignals(sigs ...os.Signal) Option {
+	return func(c *Config) {
+		c.stopSignals = sigs
+	}
+}
+
+// WithReloadSignals 配置触发重新加载的信号，默认为 SIGHUP
+func WithReloadSignals(sigs ...os.Signal) Option {
+	return func(c *Config) {
+		c.reloadSignals = sigs
+	}
+}
+
+// WithReload 配置收到重新加载信号时执行的钩子，钩子按配置顺序执行
+func WithReload(hooks ...Hook) Option {
+	return func(c *Config) {
+		c.reloadHooks = append(c.reloadHooks, hooks...)
+	}
+}
+
+// WithBeforeStop 配置停止应用前执行的钩子，钩子在流量摘除完成后、服务停止前按配置顺序执行
+func WithBeforeStop(hooks ...Hook) Option {
+	return func(c *Config) {
+		c.beforeStop = append(c.beforeStop, hooks...)
+	}
+}
+
+// WithAfterStop 配置停止应用后执行的钩子，钩子在全部组件及服务停止后按配置顺序执行
+func WithAfterStop(hooks ...Hook) Option {
+	return func(c *Config) {
+		c.afterStop = append(c.afterStop, hooks...)
+	}
+}
+
 // App 管理整个应用程序的生命周期，基于 uber fx 实现，以此解决依赖注入问题
 type App struct {
-	cfg   *Config
-	fxApp *fx.App
+	cfg      *Config
+	fxApp    *fx.App
+	draining atomic.Bool    // 是否已进入流量摘除阶段
+	servers  *serverManager // 服务生命周期管理
+
+	drainMu    sync.Mutex
+	drainHooks []Hook // 进入流量摘除阶段时执行的钩子
+
+	notify     func(c chan<- os.Signal, sigs ...os.Signal) // 注册信号监听，便于测试时替换
+	stopNotify func(c chan<- os.Signal)                    // 取消信号监听
+	exit       func(code int)                              // 退出进程
 }
 
 // New 创建一个新的 App 实例
@@ -85,23 +224,68 @@ func New(opts ...Option) *App {
 		opt(cfg)
 	}
 
-	return &App{
-		cfg: cfg,
-		fxApp: fx.New(
-			fx.Options(cfg.fxOptions...),
-			// 使用 log 包中配置好的 slog
-			fx.WithLogger(func() fxevent.Logger {
-				logger := &fxevent.SlogLogger{Logger: log.GetDefaultSlog()}
-				logger.UseLogLevel(slog.LevelDebug)
-				return logger
-			}),
-		),
+	if len(cfg.stopSignals) == 0 {
+		cfg.stopSignals = defaultStopSignals
+	}
+	if len(cfg.reloadSignals) == 0 {
+		cfg.reloadSignals = defaultReloadSignals
+	}
+	if cfg.KillTimeout <= 0 {
+		cfg.KillTimeout = defaultKillTimeout
+	}
+	if cfg.ID == "" {
+		hostname, _ := os.Hostname()
+		cfg.ID = fmt.Sprintf("%s-%s", hostname, xid.New().String())
+	}
+
+	a := &App{
+		cfg:        cfg,
+		notify:     signal.Notify,
+		stopNotify: signal.Stop,
+		exit:       os.Exit,
+	}
+	a.fxApp = fx.New(
+		fx.Options(cfg.fxOptions...),
+		// App 自身注入依赖图，组件可据此获取应用信息及健康状态
+		fx.Supply(a),
+		// 服务在其余组件之后启动，并在其余组件之前停止
+		fx.Invoke(func(p serversParams) {
+			a.servers = newServerManager(cfg, p)
+		}),
+		// 使用 log 包中配置好的 slog
+		fx.WithLogger(func() fxevent.Logger {
+			logger := &fxevent.SlogLogger{Logger: log.GetDefaultSlog()}
+			logger.UseLogLevel(slog.LevelDebug)
+			return logger
+		}),
+	)
+	return a
+}
+
+// Config 获取应用配置
+func (a *App) Config() Config {
+	return *a.cfg
+}
+
+// Healthy 应用是否健康，收到停止信号进入流量摘除阶段后将返回 false
+func (a *App) Healthy() bool {
+	return !a.draining.Load()
+}
+
+// Servers 返回由 App 管理生命周期的服务，依赖图构建完成前返回 nil
+func (a *App) Servers() []transport.Server {
+	if a.servers == nil {
+		return nil
 	}
+	return slices.Clone(a.servers.servers)
 }
 
-// Run 启动应用程序
-func (a *App) Run() {
-	a.fxApp.Run()
+// OnDrain 注册进入流量摘除阶段时执行的钩子，钩子在注销实例前按注册顺序执行，
+// 供依赖图中的组件（如健康检查）感知应用即将停止
+func (a *App) OnDrain(hooks ...Hook) {
+	a.drainMu.Lock()
+	defer a.drainMu.Unlock()
+	a.drainHooks = append(a.drainHooks, hooks...)
 }
 
 // Start 启动应用程序但不阻塞
@@ -0,0 +1,272 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/haysons/gokit/log"
+	"github.com/haysons/gokit/registry"
+	"github.com/haysons/gokit/transport"
+	"go.uber.org/fx"
+)
+
+// defaultShutdownTimeout 单个服务停止的默认超时时间
+const defaultShutdownTimeout = 10 * time.Second
+
+// AsServer 将构造函数的返回值标注为 transport.Server 并加入 fx 的 "servers" 分组，由 App 统一管理其生命周期，如：
+//
+//	app.WithProvides(fx.Provide(app.AsServer(NewGRPCServer)))
+func AsServer(constructor any) any {
+	return fx.Annotate(
+		constructor,
+		fx.As(new(transport.Server)),
+		fx.ResultTags(`group:"servers"`),
+	)
+}
+
+type serversParams struct {
+	fx.In
+
+	Lifecycle  fx.Lifecycle
+	Shutdowner fx.Shutdowner
+	Servers    []transport.Server `group:"servers"`
+	Registrar  registry.Registrar `optional:"true"`
+}
+
+// serverManager 管理全部传输层服务的生命周期，应用启动时并发启动全部服务，应用停止时逆序停止全部服务
+type serverManager struct {
+	servers    []transport.Server
+	timeout    time.Duration
+	shutdowner fx.Shutdowner
+	logger     *slog.Logger
+	cfg        *Config
+	registrar  registry.Registrar
+	instance   *registry.ServiceInstance // 已注册的实例，未注册时为 nil
+	regMu      sync.Mutex
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	running  atomic.Bool // 全部服务启动完成
+	stopping atomic.Bool // 开始停止服务
+}
+
+func newServerManager(cfg *Config, p serversParams) *serverManager {
+	servers := make([]transport.Server, 0, len(cfg.servers)+len(p.Servers))
+	for _, srv := range slices.Concat(cfg.servers, p.Servers) {
+		if srv != nil {
+			servers = append(servers, srv)
+		}
+	}
+	timeout := cfg.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	registrar := cfg.registrar
+	if registrar == nil {
+		registrar = p.Registrar
+	}
+	m := &serverManager{
+		servers:    servers,
+		timeout:    timeout,
+		shutdowner: p.Shutdowner,
+		logger:     log.GetDefaultSlog(),
+		cfg:        cfg,
+		registrar:  registrar,
+	}
+	p.Lifecycle.Append(fx.Hook{
+		OnStart: m.start,
+		OnStop:  m.stop,
+	})
+	return m
+}
+
+// start 并发启动全部服务，并等待实现了 transport.Readier 的服务就绪，任一服务启动失败时停止其余服务
+func (m *serverManager) start(ctx context.Context) error {
+	// 服务的运行周期与应用一致，不可使用启动阶段的 ctx
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	exited := make([]chan error, len(m.servers))
+	for i, srv := range m.servers {
+		exited[i] = make(chan error, 1)
+		m.wg.Add(1)
+		go func(srv transport.Server, exited chan<- error) {
+			defer m.wg.Done()
+			err := srv.Start(m.ctx)
+			exited <- err
+			if err != nil && m.running.Load() && !m.stopping.Load() {
+				// 服务运行期间异常退出，关闭整个应用
+				m.logger.Error("server exited unexpectedly", slog.String("server", serverName(srv)), slog.Any("error", err))
+				if sdErr := m.shutdowner.Shutdown(fx.ExitCode(1)); sdErr != nil {
+					m.logger.Error("shutdown app failed", slog.Any("error", sdErr))
+				}
+			}
+		}(srv, exited[i])
+	}
+
+	for i, srv := range m.servers {
+		if err := m.waitReady(ctx, srv, exited[i]); err != nil {
+			m.logger.Error("start server failed", slog.String("server", serverName(srv)), slog.Any("error", err))
+			stopCtx, cancel := context.WithTimeout(context.Background(), m.timeout)
+			defer cancel()
+			return errors.Join(err, m.stop(stopCtx))
+		}
+	}
+	m.running.Store(true)
+
+	// 等待就绪期间已异常退出的服务同样视为启动失败
+	for i, srv := range m.servers {
+		select {
+		case err := <-exited[i]:
+			if err != nil {
+				stopCtx, cancel := context.WithTimeout(context.Background(), m.timeout)
+				defer cancel()
+				return errors.Join(fmt.Errorf("server %s: %w", serverName(srv), err), m.stop(stopCtx))
+			}
+		default:
+		}
+	}
+
+	// 全部服务就绪后再注册实例，避免流量进入未就绪的服务
+	if err := m.register(ctx); err != nil {
+		m.logger.Error("register service instance failed", slog.Any("error", err))
+		stopCtx, cancel := context.WithTimeout(context.Background(), m.timeout)
+		defer cancel()
+		return errors.Join(err, m.stop(stopCtx))
+	}
+	return nil
+}
+
+// register 将应用注册至注册中心，未配置服务注册时不做任何处理
+func (m *serverManager) register(ctx context.Context) error {
+	if m.registrar == nil {
+		return nil
+	}
+	instance, err := m.buildInstance()
+	if err != nil {
+		return err
+	}
+	if err = m.registrar.Register(ctx, instance); err != nil {
+		return fmt.Errorf("register service instance: %w", err)
+	}
+	m.regMu.Lock()
+	m.instance = instance
+	m.regMu.Unlock()
+	m.logger.Info("service instance registered", slog.String("id", instance.ID), slog.Any("endpoints", instance.Endpoints))
+	return nil
+}
+
+// deregister 自注册中心注销应用，可重复调用
+func (m *serverManager) deregister(ctx context.Context) error {
+	m.regMu.Lock()
+	instance := m.instance
+	m.instance = nil
+	m.regMu.Unlock()
+	if instance == nil {
+		return nil
+	}
+	if err := m.registrar.Deregister(ctx, instance); err != nil {
+		m.logger.Error("deregister service instance failed", slog.String("id", instance.ID), slog.Any("error", err))
+		return fmt.Errorf("deregister service instance: %w", err)
+	}
+	m.logger.Info("service instance deregistered", slog.String("id", instance.ID))
+	return nil
+}
+
+// buildInstance 构建服务实例，未配置入口点时使用实现了 transport.Endpointer 的服务的入口点
+func (m *serverManager) buildInstance() (*registry.ServiceInstance, error) {
+	if m.cfg.Name == "" {
+		return nil, errors.New("register service instance: app name is required")
+	}
+	endpoints := slices.Clone(m.cfg.Endpoints)
+	if len(endpoints) == 0 {
+		for _, srv := range m.servers {
+			e, ok := srv.(transport.Endpointer)
+			if !ok {
+				continue
+			}
+			u, err := e.Endpoint()
+			if err != nil {
+				return nil, fmt.Errorf("server %s: get endpoint: %w", serverName(srv), err)
+			}
+			endpoints = append(endpoints, u.String())
+		}
+	}
+	if len(endpoints) == 0 {
+		return nil, errors.New("register service instance: no endpoints")
+	}
+	return &registry.ServiceInstance{
+		ID:        m.cfg.ID,
+		Name:      m.cfg.Name,
+		Version:   m.cfg.Version,
+		Metadata:  m.cfg.Metadata,
+		Endpoints: endpoints,
+	}, nil
+}
+
+// waitReady 等待服务就绪，未实现 transport.Readier 的服务视为立即就绪
+func (m *serverManager) waitReady(ctx context.Context, srv transport.Server, exited <-chan error) error {
+	r, ok := srv.(transport.Readier)
+	if !ok {
+		return nil
+	}
+	select {
+	case <-r.Ready():
+		return nil
+	case err := <-exited:
+		if err == nil {
+			err = errors.New("server exited before ready")
+		}
+		return fmt.Errorf("server %s: %w", serverName(srv), err)
+	case <-ctx.Done():
+		return fmt.Errorf("server %s: wait for ready: %w", serverName(srv), ctx.Err())
+	}
+}
+
+// stop 逆序停止全部服务，每个服务停止的超时时间均为 timeout
+func (m *serverManager) stop(ctx context.Context) error {
+	if !m.stopping.CompareAndSwap(false, true) {
+		return nil
+	}
+	var errs []error
+	// 先注销实例，再停止服务
+	deregCtx, deregCancel := context.WithTimeout(ctx, m.timeout)
+	if err := m.deregister(deregCtx); err != nil {
+		errs = append(errs, err)
+	}
+	deregCancel()
+	for i := len(m.servers) - 1; i >= 0; i-- {
+		srv := m.servers[i]
+		stopCtx, cancel := context.WithTimeout(ctx, m.timeout)
+		if err := srv.Stop(stopCtx); err != nil {
+			m.logger.Error("stop server failed", slog.String("server", serverName(srv)), slog.Any("error", err))
+			errs = append(errs, fmt.Errorf("server %s: %w", serverName(srv), err))
+		}
+		cancel()
+	}
+	if m.cancel != nil {
+		m.cancel()
+	}
+
+	// 等待全部服务的 Start 返回
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("wait for servers exit: %w", ctx.Err()))
+	}
+	return errors.Join(errs...)
+}
+
+func serverName(srv transport.Server) string {
+	return fmt.Sprintf("%T", srv)
+}
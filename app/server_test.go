@@ -0,0 +1,281 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/haysons/gokit/registry"
+	"github.com/haysons/gokit/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+)
+
+// mockServer 模拟阻塞式启动的服务，Start 将阻塞直至 Stop 被调用
+type mockServer struct {
+	name     string
+	startErr error         // Start 直接返回的错误
+	delay    time.Duration // 就绪前的等待时间
+	ready    chan struct{}
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	recorder *stopRecorder
+}
+
+type stopRecorder struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (r *stopRecorder) add(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.names = append(r.names, name)
+}
+
+func (r *stopRecorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.names...)
+}
+
+func newMockServer(name string, recorder *stopRecorder) *mockServer {
+	return &mockServer{
+		name:     name,
+		ready:    make(chan struct{}),
+		stopCh:   make(chan struct{}),
+		recorder: recorder,
+	}
+}
+
+func (s *mockServer) Start(ctx context.Context) error {
+	if s.startErr != nil {
+		return s.startErr
+	}
+	time.Sleep(s.delay)
+	close(s.ready)
+	select {
+	case <-s.stopCh:
+		return nil
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+func (s *mockServer) Stop(ctx context.Context) error {
+	s.stopOnce.Do(func() {
+		s.recorder.add(s.name)
+		close(s.stopCh)
+	})
+	return nil
+}
+
+func (s *mockServer) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// failingServer 模拟运行期间异常退出的服务
+type failingServer struct {
+	*mockServer
+	fail chan error
+}
+
+func (s *failingServer) Start(ctx context.Context) error {
+	close(s.ready)
+	select {
+	case err := <-s.fail:
+		return err
+	case <-s.stopCh:
+		return nil
+	}
+}
+
+func TestServers_StartAndStopInReverseOrder(t *testing.T) {
+	recorder := &stopRecorder{}
+	s1 := newMockServer("s1", recorder)
+	s2 := newMockServer("s2", recorder)
+	s2.delay = 50 * time.Millisecond
+	s3 := newMockServer("s3", recorder)
+
+	app := New(
+		WithServers(s1, s2),
+		WithProvides(fx.Provide(AsServer(func() *mockServer { return s3 }))),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, app.Start(ctx))
+
+	// 启动完成时全部服务均已就绪
+	for _, s := range []*mockServer{s1, s2, s3} {
+		select {
+		case <-s.Ready():
+		default:
+			t.Fatalf("server %s is not ready after start", s.name)
+		}
+	}
+
+	require.NoError(t, app.Stop(ctx))
+	assert.Equal(t, []string{"s3", "s2", "s1"}, recorder.get())
+}
+
+func TestServers_StartFailureStopsOthers(t *testing.T) {
+	recorder := &stopRecorder{}
+	s1 := newMockServer("s1", recorder)
+	s2 := newMockServer("s2", recorder)
+	s2.startErr = errors.New("address already in use")
+	s3 := newMockServer("s3", recorder)
+
+	app := New(WithServers(s1, s2, s3))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := app.Start(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "address already in use")
+	assert.ElementsMatch(t, []string{"s1", "s2", "s3"}, recorder.get())
+}
+
+func TestServers_RuntimeFailureShutdownsApp(t *testing.T) {
+	recorder := &stopRecorder{}
+	srv := &failingServer{mockServer: newMockServer("s1", recorder), fail: make(chan error, 1)}
+
+	app := New(WithServers(srv))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, app.Start(ctx))
+
+	srv.fail <- errors.New("serve failed")
+	select {
+	case sig := <-app.fxApp.Wait():
+		assert.Equal(t, 1, sig.ExitCode)
+	case <-ctx.Done():
+		t.Fatal("app is not shutdown after server failure")
+	}
+	require.NoError(t, app.Stop(ctx))
+}
+
+type blockingStopServer struct {
+	*mockServer
+}
+
+func (s *blockingStopServer) Stop(ctx context.Context) error {
+	<-ctx.Done()
+	return s.mockServer.Stop(ctx)
+}
+
+func TestServers_ShutdownTimeout(t *testing.T) {
+	recorder := &stopRecorder{}
+	srv := &blockingStopServer{mockServer: newMockServer("s1", recorder)}
+
+	app := New(WithServers(srv), WithShutdownTimeout(50*time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, app.Start(ctx))
+
+	start := time.Now()
+	require.NoError(t, app.Stop(ctx))
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, []string{"s1"}, recorder.get())
+}
+
+var _ transport.Readier = (*mockServer)(nil)
+
+// endpointServer 实现了 transport.Endpointer 的服务
+type endpointServer struct {
+	*mockServer
+	endpoint string
+}
+
+func (s *endpointServer) Endpoint() (*url.URL, error) {
+	return url.Parse(s.endpoint)
+}
+
+// fakeRegistrar 记录注册及注销事件
+type fakeRegistrar struct {
+	mu        sync.Mutex
+	events    []string
+	instances []*registry.ServiceInstance
+	err       error
+}
+
+func (r *fakeRegistrar) Register(_ context.Context, si *registry.ServiceInstance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.events = append(r.events, "register")
+	r.instances = append(r.instances, si)
+	return nil
+}
+
+func (r *fakeRegistrar) Deregister(_ context.Context, si *registry.ServiceInstance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, "deregister")
+	return nil
+}
+
+func (r *fakeRegistrar) getEvents() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func TestServers_Registry(t *testing.T) {
+	recorder := &stopRecorder{}
+	srv := &endpointServer{mockServer: newMockServer("s1", recorder), endpoint: "grpc://10.0.0.1:9000"}
+	plain := newMockServer("s2", recorder)
+	registrar := &fakeRegistrar{}
+
+	app := New(
+		WithName("demo"),
+		WithVersion("v1.0.0"),
+		WithID("demo-1"),
+		WithMetadata(map[string]string{"zone": "a"}),
+		WithServers(srv, plain),
+		WithProvides(fx.Provide(func() registry.Registrar { return registrar })),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, app.Start(ctx))
+	require.Equal(t, []string{"register"}, registrar.getEvents())
+	si := registrar.instances[0]
+	assert.Equal(t, "demo-1", si.ID)
+	assert.Equal(t, "demo", si.Name)
+	assert.Equal(t, "v1.0.0", si.Version)
+	assert.Equal(t, map[string]string{"zone": "a"}, si.Metadata)
+	assert.Equal(t, []string{"grpc://10.0.0.1:9000"}, si.Endpoints)
+
+	require.NoError(t, app.Stop(ctx))
+	assert.Equal(t, []string{"register", "deregister"}, registrar.getEvents())
+	assert.Equal(t, []string{"s2", "s1"}, recorder.get())
+}
+
+func TestServers_RegisterFailureStopsServers(t *testing.T) {
+	recorder := &stopRecorder{}
+	registrar := &fakeRegistrar{err: errors.New("etcd unavailable")}
+
+	app := New(
+		WithName("demo"),
+		WithEndpoints("http://10.0.0.1:8000"),
+		WithServers(newMockServer("s1", recorder)),
+		WithRegistrar(registrar),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := app.Start(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "etcd unavailable")
+	assert.Equal(t, []string{"s1"}, recorder.get())
+	assert.Empty(t, registrar.getEvents())
+}
@@ -0,0 +1,174 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/haysons/gokit/log"
+)
+
+// defaultKillTimeout 优雅关闭的默认最长时间
+const defaultKillTimeout = 30 * time.Second
+
+var (
+	defaultStopSignals   = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	defaultReloadSignals = []os.Signal{syscall.SIGHUP}
+)
+
+// Run 启动应用程序并阻塞，直至收到停止信号或应用被 fx.Shutdowner 关闭，随后执行优雅关闭流程，
+// 启动失败或关闭异常时将以非零状态码退出进程
+func (a *App) Run() {
+	code, err := a.run(context.Background())
+	if err != nil {
+		log.GetDefaultSlog().Error("app run failed", slog.Any("error", err))
+		if code == 0 {
+			code = 1
+		}
+	}
+	if code != 0 {
+		a.exit(code)
+	}
+}
+
+// run 启动应用并等待停止信号，返回进程退出码
+func (a *App) run(ctx context.Context) (int, error) {
+	// 启动前即监听信号，避免启动期间收到的停止信号按默认行为直接终止进程而跳过清理
+	sigCh := make(chan os.Signal, 1)
+	a.notify(sigCh, slices.Concat(a.cfg.stopSignals, a.cfg.reloadSignals)...)
+	defer a.stopNotify(sigCh)
+
+	stopped, reload, err := a.start(ctx, sigCh)
+	if err != nil {
+		return 1, err
+	}
+	if stopped {
+		// 启动完成后才执行关闭流程，保证全部已启动的组件均会被停止
+		return 0, a.shutdown(ctx)
+	}
+	if reload {
+		a.reload(ctx)
+	}
+
+	exitCode := 0
+	shutdownCh := a.fxApp.Wait()
+wait:
+	for {
+		select {
+		case sig := <-sigCh:
+			if slices.Contains(a.cfg.reloadSignals, sig) {
+				log.GetDefaultSlog().Info("received reload signal", slog.String("signal", sig.String()))
+				a.reload(ctx)
+				continue
+			}
+			log.GetDefaultSlog().Info("received stop signal", slog.String("signal", sig.String()))
+			break wait
+		case sd := <-shutdownCh:
+			exitCode = sd.ExitCode
+			break wait
+		}
+	}
+	return exitCode, a.shutdown(ctx)
+}
+
+// start 启动应用，启动期间收到的停止信号及重载信号均延迟至启动完成后处理，返回是否收到停止信号及是否需要重载。
+// 收到停止信号时不取消启动：fx 在启动被取消后立即返回，并以已取消的 ctx 在后台回滚，已启动组件的 OnStop 钩子不会执行，
+// 忽略 ctx 的 OnStart 钩子还可能在关闭流程停止组件之后才完成，因此等待启动完成或超时后再执行关闭流程
+func (a *App) start(ctx context.Context, sigCh <-chan os.Signal) (stopped, reload bool, err error) {
+	startCtx, cancel := context.WithTimeout(ctx, a.fxApp.StartTimeout())
+	defer cancel()
+
+	started := make(chan struct{})
+	watched := make(chan struct{})
+	go func() {
+		defer close(watched)
+		for {
+			select {
+			case sig := <-sigCh:
+				if slices.Contains(a.cfg.reloadSignals, sig) {
+					log.GetDefaultSlog().Info("received reload signal during start, deferred", slog.String("signal", sig.String()))
+					reload = true
+					continue
+				}
+				log.GetDefaultSlog().Info("received stop signal during start, stop after started", slog.String("signal", sig.String()))
+				stopped = true
+			case <-started:
+				return
+			}
+		}
+	}()
+	err = a.Start(startCtx)
+	close(started)
+	<-watched
+	return stopped, reload, err
+}
+
+// reload 执行重载钩子
+func (a *App) reload(ctx context.Context) {
+	if err := runHooks(ctx, a.cfg.reloadHooks); err != nil {
+		log.GetDefaultSlog().Error("reload failed", slog.Any("error", err))
+	}
+}
+
+// shutdown 优雅关闭应用：标记应用不健康并执行 OnDrain 钩子、注销实例并等待负载均衡摘除流量，依次执行 BeforeStop 钩子，停止全部组件及服务，
+// 再执行 AfterStop 钩子，整个过程超过 KillTimeout 时强制退出进程
+func (a *App) shutdown(ctx context.Context) error {
+	killTimer := time.AfterFunc(a.cfg.KillTimeout, func() {
+		log.GetDefaultSlog().Error("graceful shutdown timeout, force exit", slog.Duration("kill_timeout", a.cfg.KillTimeout))
+		a.exit(1)
+	})
+	defer killTimer.Stop()
+
+	// 流量摘除阶段，自注册中心注销实例，使调用方尽早停止发送请求
+	a.draining.Store(true)
+	var errs []error
+	a.drainMu.Lock()
+	drainHooks := slices.Clone(a.drainHooks)
+	a.drainMu.Unlock()
+	if err := runHooks(ctx, drainHooks); err != nil {
+		errs = append(errs, fmt.Errorf("drain: %w", err))
+	}
+	if a.servers != nil {
+		if err := a.servers.deregister(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if a.cfg.DrainDelay > 0 {
+		log.GetDefaultSlog().Info("draining", slog.Duration("drain_delay", a.cfg.DrainDelay))
+		select {
+		case <-time.After(a.cfg.DrainDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	if err := runHooks(ctx, a.cfg.beforeStop); err != nil {
+		errs = append(errs, fmt.Errorf("before stop: %w", err))
+	}
+
+	stopCtx, cancel := context.WithTimeout(ctx, a.fxApp.StopTimeout())
+	defer cancel()
+	if err := a.Stop(stopCtx); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := runHooks(ctx, a.cfg.afterStop); err != nil {
+		errs = append(errs, fmt.Errorf("after stop: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// runHooks 按顺序执行全部钩子，某个钩子执行失败不影响后续钩子的执行
+func runHooks(ctx context.Context, hooks []Hook) error {
+	var errs []error
+	for _, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
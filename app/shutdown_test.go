@@ -0,0 +1,270 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+)
+
+// fakeSignals 替换 App 的信号监听，测试时无需发送真实信号
+type fakeSignals struct {
+	mu         sync.Mutex
+	ch         chan<- os.Signal
+	sigs       []os.Signal
+	registered chan struct{}
+}
+
+func installFakeSignals(a *App) *fakeSignals {
+	fs := &fakeSignals{registered: make(chan struct{})}
+	a.notify = func(c chan<- os.Signal, sigs ...os.Signal) {
+		fs.mu.Lock()
+		fs.ch, fs.sigs = c, sigs
+		fs.mu.Unlock()
+		close(fs.registered)
+	}
+	a.stopNotify = func(chan<- os.Signal) {}
+	return fs
+}
+
+func (fs *fakeSignals) send(t *testing.T, sig os.Signal) {
+	select {
+	case <-fs.registered:
+	case <-time.After(time.Second):
+		t.Fatal("signals are not registered")
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.ch <- sig
+}
+
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *eventRecorder) hook(event string) Hook {
+	return func(context.Context) error {
+		r.add(event)
+		return nil
+	}
+}
+
+func (r *eventRecorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *eventRecorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func TestRun_GracefulShutdown(t *testing.T) {
+	recorder := &eventRecorder{}
+	stopRecorder := &stopRecorder{}
+	srv := newMockServer("server", stopRecorder)
+
+	var app *App
+	app = New(
+		WithServers(srv),
+		WithDrainDelay(100*time.Millisecond),
+		WithBeforeStop(
+			func(context.Context) error {
+				// 执行 BeforeStop 时应用已被标记为不健康
+				if !app.Healthy() {
+					recorder.add("before-1")
+				}
+				return nil
+			},
+			recorder.hook("before-2"),
+		),
+		WithAfterStop(
+			func(context.Context) error {
+				recorder.add("after-1:" + stopRecorder.get()[0])
+				return nil
+			},
+			recorder.hook("after-2"),
+		),
+		WithReload(recorder.hook("reload")),
+	)
+	app.OnDrain(func(context.Context) error {
+		if !app.Healthy() {
+			recorder.add("drain")
+		}
+		return nil
+	})
+	signals := installFakeSignals(app)
+	assert.ElementsMatch(t, []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP}, append(app.cfg.stopSignals, app.cfg.reloadSignals...))
+
+	done := make(chan error, 1)
+	go func() {
+		code, err := app.run(context.Background())
+		assert.Equal(t, 0, code)
+		done <- err
+	}()
+
+	signals.send(t, syscall.SIGHUP)
+	assert.Eventually(t, func() bool {
+		return len(recorder.get()) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.True(t, app.Healthy())
+
+	start := time.Now()
+	signals.send(t, syscall.SIGTERM)
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("app is not stopped after signal")
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	assert.False(t, app.Healthy())
+	assert.Equal(t, []string{"reload", "drain", "before-1", "before-2", "after-1:server", "after-2"}, recorder.get())
+}
+
+func TestRun_CustomSignals(t *testing.T) {
+	app := New(WithSignals(syscall.SIGQUIT), WithReloadSignals(syscall.SIGALRM))
+	signals := installFakeSignals(app)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := app.run(context.Background())
+		done <- err
+	}()
+
+	signals.send(t, syscall.SIGQUIT)
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("app is not stopped after signal")
+	}
+	assert.Equal(t, []os.Signal{syscall.SIGQUIT, syscall.SIGALRM}, signals.sigs)
+}
+
+func TestRun_ShutdownByShutdowner(t *testing.T) {
+	var shutdowner fx.Shutdowner
+	app := New(WithInvokes(fx.Populate(&shutdowner)))
+	installFakeSignals(app)
+
+	done := make(chan int, 1)
+	go func() {
+		code, err := app.run(context.Background())
+		assert.NoError(t, err)
+		done <- code
+	}()
+
+	assert.Eventually(t, func() bool { return shutdowner != nil }, time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, shutdowner.Shutdown(fx.ExitCode(3)))
+	select {
+	case code := <-done:
+		assert.Equal(t, 3, code)
+	case <-time.After(time.Second):
+		t.Fatal("app is not stopped after shutdown")
+	}
+}
+
+func TestRun_StopSignalDuringStart(t *testing.T) {
+	recorder := &eventRecorder{}
+	release := make(chan struct{})
+	app := New(WithAfterStop(recorder.hook("after")), WithInvokes(
+		fx.Invoke(func(lc fx.Lifecycle) {
+			lc.Append(fx.Hook{
+				OnStart: recorder.hook("start-1"),
+				OnStop:  recorder.hook("stop-1"),
+			})
+			lc.Append(fx.Hook{
+				// 忽略 ctx 的启动钩子，阻塞直至测试放行
+				OnStart: func(context.Context) error {
+					recorder.add("start-2")
+					<-release
+					return nil
+				},
+				OnStop: recorder.hook("stop-2"),
+			})
+		}),
+	))
+	signals := installFakeSignals(app)
+
+	done := make(chan error, 1)
+	go func() {
+		code, err := app.run(context.Background())
+		assert.Equal(t, 0, code)
+		done <- err
+	}()
+
+	assert.Eventually(t, func() bool { return len(recorder.get()) == 2 }, time.Second, 10*time.Millisecond)
+	signals.send(t, syscall.SIGTERM)
+	// 启动完成前不执行关闭流程
+	select {
+	case <-done:
+		t.Fatal("app stopped before start finished")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("app is not stopped after start finished")
+	}
+	// 全部已启动的组件均被停止
+	assert.Equal(t, []string{"start-1", "start-2", "stop-2", "stop-1", "after"}, recorder.get())
+}
+
+func TestRun_HookErrors(t *testing.T) {
+	recorder := &eventRecorder{}
+	app := New(
+		WithBeforeStop(func(context.Context) error { return errors.New("before failed") }, recorder.hook("before")),
+		WithAfterStop(recorder.hook("after")),
+	)
+	signals := installFakeSignals(app)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := app.run(context.Background())
+		done <- err
+	}()
+	signals.send(t, syscall.SIGINT)
+
+	err := <-done
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "before failed")
+	assert.Equal(t, []string{"before", "after"}, recorder.get())
+}
+
+func TestShutdown_KillTimeout(t *testing.T) {
+	exitCode := make(chan int, 1)
+	app := New(
+		WithKillTimeout(50*time.Millisecond),
+		WithBeforeStop(func(context.Context) error {
+			time.Sleep(200 * time.Millisecond)
+			return nil
+		}),
+	)
+	app.exit = func(code int) { exitCode <- code }
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, app.Start(ctx))
+	require.NoError(t, app.shutdown(ctx))
+
+	select {
+	case code := <-exitCode:
+		assert.Equal(t, 1, code)
+	default:
+		t.Fatal("process is not killed after kill timeout")
+	}
+}
@@ -0,0 +1,165 @@
+// gokit-config 配置文件加密工具，用于生成秘钥、加解密配置值以及对整个配置文件更换秘钥
+//
+//	gokit-config genkey
+//	gokit-config encrypt [-key base64 | -key-file path] <value>
+//	gokit-config decrypt [-key base64 | -key-file path] <ENC(...)>
+//	gokit-config rekey -old-key-file old.key -new-key-file new.key [-w] <config.yaml>
+//
+// 未指定秘钥时，将自环境变量 GOKIT_CONFIG_KEY 或 GOKIT_CONFIG_KEY_FILE 中读取
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/haysons/gokit/config"
+	"github.com/haysons/gokit/util/crypto"
+	"github.com/haysons/gokit/util/encode"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "genkey":
+		err = genKey()
+	case "encrypt":
+		err = encrypt(os.Args[2:])
+	case "decrypt":
+		err = decrypt(os.Args[2:])
+	case "rekey":
+		err = rekey(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gokit-config:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage:
+  gokit-config genkey
+  gokit-config encrypt [-key base64 | -key-file path] <value>
+  gokit-config decrypt [-key base64 | -key-file path] <ENC(...)>
+  gokit-config rekey [-old-key base64 | -old-key-file path] [-new-key base64 | -new-key-file path] [-w] <file>`)
+}
+
+// genKey 生成一个随机的 32 字节 AES 秘钥，以 base64 编码输出
+func genKey() error {
+	key, err := crypto.SecureRandom(32)
+	if err != nil {
+		return err
+	}
+	fmt.Println(encode.Base64Encode(key))
+	return nil
+}
+
+func encrypt(args []string) error {
+	fs := flag.NewFlagSet("encrypt", flag.ExitOnError)
+	keyStr := fs.String("key", "", "base64 encoded secret key")
+	keyFile := fs.String("key-file", "", "file containing base64 encoded secret key")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("encrypt requires exactly one value")
+	}
+
+	key, err := loadKey(*keyStr, *keyFile)
+	if err != nil {
+		return err
+	}
+	secret, err := config.EncryptSecret(fs.Arg(0), key)
+	if err != nil {
+		return err
+	}
+	fmt.Println(secret)
+	return nil
+}
+
+func decrypt(args []string) error {
+	fs := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	keyStr := fs.String("key", "", "base64 encoded secret key")
+	keyFile := fs.String("key-file", "", "file containing base64 encoded secret key")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("decrypt requires exactly one value")
+	}
+
+	key, err := loadKey(*keyStr, *keyFile)
+	if err != nil {
+		return err
+	}
+	plaintext, err := config.DecryptSecret(fs.Arg(0), key)
+	if err != nil {
+		return err
+	}
+	fmt.Println(plaintext)
+	return nil
+}
+
+func rekey(args []string) error {
+	fs := flag.NewFlagSet("rekey", flag.ExitOnError)
+	oldKeyStr := fs.String("old-key", "", "base64 encoded old secret key")
+	oldKeyFile := fs.String("old-key-file", "", "file containing base64 encoded old secret key")
+	newKeyStr := fs.String("new-key", "", "base64 encoded new secret key")
+	newKeyFile := fs.String("new-key-file", "", "file containing base64 encoded new secret key")
+	write := fs.Bool("w", false, "write result to file instead of stdout")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("rekey requires exactly one file")
+	}
+
+	oldKey, err := loadKey(*oldKeyStr, *oldKeyFile)
+	if err != nil {
+		return fmt.Errorf("load old key: %w", err)
+	}
+	if *newKeyStr == "" && *newKeyFile == "" {
+		return fmt.Errorf("new key is required")
+	}
+	newKey, err := loadKey(*newKeyStr, *newKeyFile)
+	if err != nil {
+		return fmt.Errorf("load new key: %w", err)
+	}
+
+	file := fs.Arg(0)
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	out, err := config.RekeySecrets(data, oldKey, newKey)
+	if err != nil {
+		return err
+	}
+	if *write {
+		return os.WriteFile(file, out, info.Mode().Perm())
+	}
+	_, err = os.Stdout.Write(out)
+	return err
+}
+
+// loadKey 优先使用命令行指定的秘钥，其次使用秘钥文件，均未指定时自环境变量中读取
+func loadKey(keyStr, keyFile string) ([]byte, error) {
+	switch {
+	case keyStr != "":
+		return config.ParseSecretKey(keyStr)
+	case keyFile != "":
+		return config.SecretKeyFromFile(keyFile)
+	case os.Getenv(config.SecretKeyEnv) != "":
+		return config.SecretKeyFromEnv(config.SecretKeyEnv)
+	case os.Getenv(config.SecretKeyFileEnv) != "":
+		return config.SecretKeyFromFile(os.Getenv(config.SecretKeyFileEnv))
+	default:
+		return nil, config.ErrSecretKeyMissing
+	}
+}
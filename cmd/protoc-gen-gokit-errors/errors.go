@@ -0,0 +1,293 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+const (
+	errorsPackage = protogen.GoImportPath("github.com/haysons/gokit/errors")
+	codesPackage  = protogen.GoImportPath("google.golang.org/grpc/codes")
+
+	enumDirective  = "@gokit:errors"
+	valueDirective = "@gokit:error"
+)
+
+// annotation 错误枚举及枚举值注释中声明的属性，未声明的属性继承自枚举
+type annotation struct {
+	httpCode int
+	grpcCode codes.Code
+	hint     string
+	hintID   string
+	message  string
+	module   string
+}
+
+// errorEnum 需要生成代码的错误枚举
+type errorEnum struct {
+	enum   *protogen.Enum
+	values []errorValue
+}
+
+type errorValue struct {
+	value *protogen.EnumValue
+	annotation
+}
+
+// generateFile 为文件中的错误枚举生成 {name}_errors.pb.go，不包含错误枚举时不生成文件
+func generateFile(gen *protogen.Plugin, file *protogen.File) error {
+	enums, err := collectEnums(file)
+	if err != nil {
+		return err
+	}
+	if len(enums) == 0 {
+		return nil
+	}
+
+	g := gen.NewGeneratedFile(file.GeneratedFilenamePrefix+"_errors.pb.go", file.GoImportPath)
+	g.P("// Code generated by protoc-gen-gokit-errors. DO NOT EDIT.")
+	g.P("// versions:")
+	g.P("// - protoc-gen-gokit-errors ", version)
+	g.P("// - protoc                  ", protocVersion(gen))
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", file.GoPackageName)
+	g.P()
+
+	g.P("var (")
+	for _, e := range enums {
+		for _, v := range e.values {
+			g.P(definitionName(v.value), " = ", errorsPackage.Ident("Register"), "(", errorsPackage.Ident("Definition"), "{")
+			g.P("Code: int(", v.value.GoIdent, "),")
+			g.P("Message: ", strconv.Quote(v.message), ",")
+			if v.hint != "" {
+				g.P("Hint: ", strconv.Quote(v.hint), ",")
+			}
+			if v.hintID != "" {
+				g.P("HintID: ", strconv.Quote(v.hintID), ",")
+			}
+			g.P("HttpCode: ", v.httpCode, ",")
+			g.P("GrpcCode: ", codesPackage.Ident(v.grpcCode.String()), ",")
+			if v.module != "" {
+				g.P("Module: ", strconv.Quote(v.module), ",")
+			}
+			g.P("})")
+		}
+	}
+	g.P(")")
+
+	for _, e := range enums {
+		for _, v := range e.values {
+			reason := camelCase(string(v.value.Desc.Name()))
+			def := definitionName(v.value)
+			g.P()
+			g.P("// Is", reason, " 判断错误是否为 ", v.value.GoIdent)
+			g.P("func Is", reason, "(err error) bool {")
+			g.P("return ", def, ".Is(err)")
+			g.P("}")
+			g.P()
+			writeLeadingComment(g, "Error"+reason, v.value)
+			g.P("func Error", reason, "(format string, args ...any) error {")
+			g.P("return ", def, ".Newf(format, args...)")
+			g.P("}")
+		}
+	}
+	return nil
+}
+
+// collectEnums 收集文件中（包括嵌套在消息中）的错误枚举
+func collectEnums(file *protogen.File) ([]errorEnum, error) {
+	var enums []errorEnum
+	var walk func(list []*protogen.Enum, messages []*protogen.Message) error
+	walk = func(list []*protogen.Enum, messages []*protogen.Message) error {
+		for _, enum := range list {
+			e, ok, err := parseEnum(file, enum)
+			if err != nil {
+				return err
+			}
+			if ok {
+				enums = append(enums, e)
+			}
+		}
+		for _, m := range messages {
+			if err := walk(m.Enums, m.Messages); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(file.Enums, file.Messages); err != nil {
+		return nil, err
+	}
+	return enums, nil
+}
+
+// parseEnum 解析枚举的注释，枚举未声明 @gokit:errors 指令时返回 false
+func parseEnum(file *protogen.File, enum *protogen.Enum) (errorEnum, bool, error) {
+	args, ok := findDirective(enum.Comments.Leading, enumDirective)
+	if !ok {
+		return errorEnum{}, false, nil
+	}
+	defaults := annotation{
+		httpCode: http.StatusInternalServerError,
+		grpcCode: codes.Unknown,
+		module:   string(file.Desc.Package()),
+	}
+	if err := defaults.parse(args, true); err != nil {
+		return errorEnum{}, false, fmt.Errorf("%s: %w", enum.Desc.FullName(), err)
+	}
+
+	e := errorEnum{enum: enum}
+	for _, v := range enum.Values {
+		// 编号为0的枚举值为 proto3 要求的默认值，不视为错误
+		if v.Desc.Number() == 0 {
+			continue
+		}
+		a := defaults
+		a.message = string(v.Desc.Name())
+		if args, ok := findDirective(v.Comments.Leading, valueDirective); ok {
+			if err := a.parse(args, false); err != nil {
+				return errorEnum{}, false, fmt.Errorf("%s: %w", v.Desc.FullName(), err)
+			}
+		}
+		e.values = append(e.values, errorValue{value: v, annotation: a})
+	}
+	return e, true, nil
+}
+
+// findDirective 查找注释中以 directive 开头的行，返回指令的参数
+func findDirective(comments protogen.Comments, directive string) (string, bool) {
+	for _, line := range strings.Split(string(comments), "\n") {
+		line = strings.TrimSpace(line)
+		rest, ok := strings.CutPrefix(line, directive)
+		if ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			return strings.TrimSpace(rest), true
+		}
+	}
+	return "", false
+}
+
+// parse 解析 key=value 形式的指令参数，value 包含空格时需使用双引号
+func (a *annotation) parse(args string, isEnum bool) error {
+	for args != "" {
+		key, rest, ok := strings.Cut(args, "=")
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return fmt.Errorf("invalid directive argument %q, want key=value", args)
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return fmt.Errorf("invalid quoted value for %s: %s", key, rest)
+			}
+			value, _ = strconv.Unquote(quoted)
+			rest = rest[len(quoted):]
+		} else {
+			end := strings.IndexAny(rest, " \t")
+			if end < 0 {
+				end = len(rest)
+			}
+			value, rest = rest[:end], rest[end:]
+		}
+		if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+			return fmt.Errorf("missing space after %s", key)
+		}
+		args = strings.TrimSpace(rest)
+
+		switch key {
+		case "http":
+			code, err := strconv.Atoi(value)
+			if err != nil || http.StatusText(code) == "" {
+				return fmt.Errorf("invalid http code %q", value)
+			}
+			a.httpCode = code
+		case "grpc":
+			code, ok := parseGrpcCode(value)
+			if !ok {
+				return fmt.Errorf("invalid grpc code %q", value)
+			}
+			a.grpcCode = code
+		case "hint":
+			a.hint = value
+		case "hint_id":
+			a.hintID = value
+		case "msg":
+			if isEnum {
+				return fmt.Errorf("msg is only allowed on enum values")
+			}
+			a.message = value
+		case "module":
+			if !isEnum {
+				return fmt.Errorf("module is only allowed on enums")
+			}
+			a.module = value
+		default:
+			return fmt.Errorf("unknown directive argument %q", key)
+		}
+	}
+	return nil
+}
+
+// parseGrpcCode 解析 grpc 状态码，支持 NOT_FOUND 及 NotFound 两种写法
+func parseGrpcCode(s string) (codes.Code, bool) {
+	name := strings.ToLower(strings.ReplaceAll(s, "_", ""))
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		if strings.ToLower(c.String()) == name {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+// definitionName 枚举值对应的错误码定义的变量名
+func definitionName(v *protogen.EnumValue) string {
+	return "def_" + v.GoIdent.GoName
+}
+
+// camelCase 将 USER_NOT_FOUND 形式的枚举值名称转换为 UserNotFound
+func camelCase(s string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(s, "_") {
+		if part == "" {
+			continue
+		}
+		runes := []rune(strings.ToLower(part))
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
+
+// writeLeadingComment 将枚举值的注释（不包含指令行）作为构造函数的注释
+func writeLeadingComment(g *protogen.GeneratedFile, name string, v *protogen.EnumValue) {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSuffix(string(v.Comments.Leading), "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, valueDirective) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		g.P("// ", name, " 创建 ", v.GoIdent, " 错误")
+		return
+	}
+	g.P("// ", name, " ", lines[0])
+	for _, line := range lines[1:] {
+		g.P("// ", line)
+	}
+}
+
+func protocVersion(gen *protogen.Plugin) string {
+	v := gen.Request.GetCompilerVersion()
+	if v == nil {
+		return "(unknown)"
+	}
+	return fmt.Sprintf("v%d.%d.%d", v.GetMajor(), v.GetMinor(), v.GetPatch())
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/haysons/gokit/errors"
+	"github.com/haysons/gokit/transport/testdata/helloworld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// errorReasonRequest 根据 testdata 中的 error_reason.proto 构造插件请求，运行时的描述符不包含注释，需手动补充
+func errorReasonRequest() *pluginpb.CodeGeneratorRequest {
+	fd := protodesc.ToFileDescriptorProto(helloworld.File_error_reason_proto)
+	fd.SourceCodeInfo = &descriptorpb.SourceCodeInfo{Location: []*descriptorpb.SourceCodeInfo_Location{
+		{Path: []int32{5, 0}, Span: []int32{7, 0, 15, 1}, LeadingComments: proto.String(" @gokit:errors http=400 grpc=INVALID_ARGUMENT module=helloworld\n")},
+		{Path: []int32{5, 0, 2, 1}, Span: []int32{11, 2, 21}, LeadingComments: proto.String(" 名称为空\n")},
+		{Path: []int32{5, 0, 2, 2}, Span: []int32{14, 2, 25}, LeadingComments: proto.String(" 用户不存在\n @gokit:error http=404 grpc=NOT_FOUND hint=\"用户不存在\" hint_id=helloworld.user_not_found\n")},
+	}}
+	return &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{fd.GetName()},
+		Parameter:      proto.String("paths=source_relative"),
+		ProtoFile:      []*descriptorpb.FileDescriptorProto{fd},
+	}
+}
+
+func TestGenerateFile(t *testing.T) {
+	gen, err := protogen.Options{}.New(errorReasonRequest())
+	require.NoError(t, err)
+	for _, f := range gen.Files {
+		require.NoError(t, generateFile(gen, f))
+	}
+	resp := gen.Response()
+	require.Nil(t, resp.Error)
+	require.Len(t, resp.File, 1)
+	assert.Equal(t, "error_reason_errors.pb.go", resp.File[0].GetName())
+
+	// 生成的代码需与 testdata 中提交的代码一致
+	want, err := os.ReadFile("../../transport/testdata/helloworld/error_reason_errors.pb.go")
+	require.NoError(t, err)
+	assert.Equal(t, string(want), resp.File[0].GetContent())
+}
+
+func TestGeneratedErrors(t *testing.T) {
+	err := helloworld.ErrorUserNotFound("user %d not found", 42)
+	assert.True(t, helloworld.IsUserNotFound(err))
+	assert.False(t, helloworld.IsNameEmpty(err))
+	assert.Equal(t, 20002, errors.GetCode(err))
+	assert.Equal(t, http.StatusNotFound, errors.GetHttpCode(err, 0))
+	assert.Equal(t, codes.NotFound, errors.GetGrpcCode(err))
+	assert.Equal(t, "用户不存在", errors.GetHint(err))
+	assert.Contains(t, err.Error(), "user 42 not found")
+	id, _, ok := errors.GetHintID(err)
+	assert.True(t, ok)
+	assert.Equal(t, "helloworld.user_not_found", id)
+
+	// 未单独声明的属性继承自枚举
+	err = helloworld.ErrorNameEmpty("name is empty")
+	assert.Equal(t, http.StatusBadRequest, errors.GetHttpCode(err, 0))
+	assert.Equal(t, codes.InvalidArgument, errors.GetGrpcCode(err))
+	assert.Empty(t, errors.GetHint(err))
+
+	// 错误码已注册，可通过错误码还原错误
+	d, ok := errors.Lookup(int(helloworld.ErrorReason_USER_NOT_FOUND))
+	require.True(t, ok)
+	assert.Equal(t, "helloworld", d.Module)
+	assert.True(t, helloworld.IsUserNotFound(errors.FromCode(20002)))
+}
+
+func TestAnnotationParse(t *testing.T) {
+	a := annotation{httpCode: http.StatusInternalServerError, grpcCode: codes.Unknown}
+	require.NoError(t, a.parse(`grpc=PermissionDenied  hint="请先登录 后再试" hint_id=auth.login msg=forbidden http=403`, false))
+	assert.Equal(t, annotation{
+		httpCode: http.StatusForbidden,
+		grpcCode: codes.PermissionDenied,
+		hint:     "请先登录 后再试",
+		hintID:   "auth.login",
+		message:  "forbidden",
+	}, a)
+
+	for _, args := range []string{
+		"http=999",
+		"grpc=NOPE",
+		"hint",
+		`hint="unterminated`,
+		`hint="a"http=400`,
+		"module=user",
+		"color=red",
+	} {
+		assert.Error(t, a.parse(args, false), args)
+	}
+	assert.Error(t, a.parse("msg=oops", true))
+}
+
+func TestCamelCase(t *testing.T) {
+	assert.Equal(t, "UserNotFound", camelCase("USER_NOT_FOUND"))
+	assert.Equal(t, "Timeout", camelCase("TIMEOUT"))
+	assert.Equal(t, "OauthV2Failed", camelCase("OAUTH__V2_FAILED"))
+}
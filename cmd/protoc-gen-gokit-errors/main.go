@@ -0,0 +1,47 @@
+// protoc-gen-gokit-errors 根据 proto 中的错误枚举生成 gokit 错误构造函数及判断函数
+//
+//	protoc --go_out=. --gokit-errors_out=. --gokit-errors_opt=paths=source_relative error_reason.proto
+//
+// 枚举的注释中包含 @gokit:errors 指令时视为错误枚举，枚举值的编号即为业务状态码，
+// 枚举值可通过 @gokit:error 指令覆盖枚举级别的默认值：
+//
+//	// @gokit:errors http=500 grpc=INTERNAL module=user
+//	enum ErrorReason {
+//	  ERROR_REASON_UNSPECIFIED = 0;
+//	  // 用户不存在
+//	  // @gokit:error http=404 grpc=NOT_FOUND hint="用户不存在" hint_id=user.not_found
+//	  USER_NOT_FOUND = 20001;
+//	}
+//
+// hint 为未经本地化时的提示信息，hint_id 为消息目录中的消息 id，由 localize 中间件根据请求的语言解析。
+// 对于每个枚举值将错误码注册至 errors 包，并基于注册的错误码定义生成 Error<Reason>(format, args...) 及 Is<Reason>(err) 函数
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+const version = "v0.1.0"
+
+func main() {
+	if len(os.Args) == 2 && os.Args[1] == "--version" {
+		fmt.Printf("protoc-gen-gokit-errors %s\n", version)
+		return
+	}
+	protogen.Options{}.Run(func(gen *protogen.Plugin) error {
+		gen.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+		for _, f := range gen.Files {
+			if !f.Generate {
+				continue
+			}
+			if err := generateFile(gen, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -1,21 +1,38 @@
 package config
 
 import (
+	"errors"
 	"log/slog"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/haysons/gokit/log"
 	"github.com/spf13/viper"
 )
 
+// Settings 提供当前生效的全部配置项，可用于展示或调试
+type Settings interface {
+	AllSettings() map[string]any
+}
+
+var _ Settings = (*Config[struct{}])(nil)
+
 type Config[T any] struct {
-	mu     sync.RWMutex
-	viper  *viper.Viper
-	config T
-	logger *slog.Logger
+	mu      sync.RWMutex
+	viperMu sync.RWMutex // viper 并非并发安全，配置文件重新加载时与读取配置项互斥
+	viper   *viper.Viper
+	config  T
+	logger  *slog.Logger
+
+	secretKey []byte // 加密配置项的解密秘钥
+
+	watchMu   sync.Mutex
+	watcher   *fsnotify.Watcher // 配置文件监听器
+	watchDone chan struct{}     // 监听协程退出后关闭
 }
 
 // New 新建 Config 实例, T为配置对应的结构体
@@ -28,27 +45,37 @@ func New[T any]() *Config[T] {
 
 // SetType 设置配置类型，如：json, yaml, toml
 func (c *Config[T]) SetType(t string) {
+	c.viperMu.Lock()
+	defer c.viperMu.Unlock()
 	c.viper.SetConfigType(t)
 }
 
 // SetFile 设置配置文件路径，如：./config.yaml
 func (c *Config[T]) SetFile(file string) {
+	c.viperMu.Lock()
+	defer c.viperMu.Unlock()
 	c.viper.SetConfigFile(file)
 }
 
 // AutomaticEnv 自动加载环境变量值作为配置项，如：环境变量A_B_C作为配置项a.b.c的值
 func (c *Config[T]) AutomaticEnv() {
+	c.viperMu.Lock()
+	defer c.viperMu.Unlock()
 	c.viper.AutomaticEnv()
 	c.viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 }
 
 // SetEnvPrefix 设置环境变量的统一前缀
 func (c *Config[T]) SetEnvPrefix(prefix string) {
+	c.viperMu.Lock()
+	defer c.viperMu.Unlock()
 	c.viper.SetEnvPrefix(prefix)
 }
 
 // SetDefault 设置配置项默认值
 func (c *Config[T]) SetDefault(key string, value any) {
+	c.viperMu.Lock()
+	defer c.viperMu.Unlock()
 	c.viper.SetDefault(key, value)
 }
 
@@ -57,17 +84,32 @@ func (c *Config[T]) SetLogger(logger *slog.Logger) {
 	c.logger = logger
 }
 
+// SetSecretKey 设置加密配置项 ENC(...) 的解密秘钥，未设置时将自环境变量 GOKIT_CONFIG_KEY 或 GOKIT_CONFIG_KEY_FILE 中读取
+func (c *Config[T]) SetSecretKey(key []byte) {
+	c.mu.Lock()
+	c.secretKey = key
+	c.mu.Unlock()
+}
+
 // Load 加载配置项
 func (c *Config[T]) Load() error {
+	c.viperMu.Lock()
+	defer c.viperMu.Unlock()
 	if err := c.viper.ReadInConfig(); err != nil {
 		return err
 	}
 	return c.unmarshalConfig()
 }
 
+// unmarshalConfig 将 viper 中的配置项解析至配置结构体，调用方需持有 viperMu
 func (c *Config[T]) unmarshalConfig() error {
 	var cfg T
-	if err := c.viper.Unmarshal(&cfg); err != nil {
+	decodeHook := viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		c.secretDecodeHook(),
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	))
+	if err := c.viper.Unmarshal(&cfg, decodeHook); err != nil {
 		return err
 	}
 	c.mu.Lock()
@@ -85,43 +127,141 @@ func (c *Config[T]) Get() T {
 	return c.config
 }
 
+// AllSettings 获取当前生效的全部配置项，加密配置项保持 ENC(...) 格式，不会被解密
+func (c *Config[T]) AllSettings() map[string]any {
+	c.viperMu.RLock()
+	defer c.viperMu.RUnlock()
+	return c.viper.AllSettings()
+}
+
 // GetString 依据配置 key 获取特定 string 类型配置项
 func (c *Config[T]) GetString(key string) string {
+	c.viperMu.RLock()
+	defer c.viperMu.RUnlock()
 	return c.viper.GetString(key)
 }
 
 // GetBool 依据配置 key 获取特定 bool 类型配置项
 func (c *Config[T]) GetBool(key string) bool {
+	c.viperMu.RLock()
+	defer c.viperMu.RUnlock()
 	return c.viper.GetBool(key)
 }
 
 // GetInt 依据配置 key 获取特定 int 类型配置项
 func (c *Config[T]) GetInt(key string) int {
+	c.viperMu.RLock()
+	defer c.viperMu.RUnlock()
 	return c.viper.GetInt(key)
 }
 
 // GetFloat64 依据配置 key 获取特定 float64 类型配置项
 func (c *Config[T]) GetFloat64(key string) float64 {
+	c.viperMu.RLock()
+	defer c.viperMu.RUnlock()
 	return c.viper.GetFloat64(key)
 }
 
 // GetDuration 依据配置 key 获取特定 time.Duration 类型配置项
 func (c *Config[T]) GetDuration(key string) time.Duration {
+	c.viperMu.RLock()
+	defer c.viperMu.RUnlock()
 	return c.viper.GetDuration(key)
 }
 
-// Watch 监听配置项变化
+// Watch 监听配置文件变化，配置文件变更后将自动重新加载配置项，启动监听失败时仅记录日志，需获取错误时使用 StartWatch
 func (c *Config[T]) Watch() {
-	c.viper.WatchConfig()
-	c.viper.OnConfigChange(func(e fsnotify.Event) {
-		c.logger.Info("config file changed", slog.String("file name", e.Name))
-		if err := c.unmarshalConfig(); err != nil {
-			c.logger.Error("unmarshal config failed", slog.Any("error", err))
-			return
+	if err := c.StartWatch(); err != nil {
+		c.logger.Error("watch config failed", slog.Any("error", err))
+	}
+}
+
+// StartWatch 监听配置文件变化，配置文件变更后将自动重新加载配置项，可通过 StopWatch 停止监听
+func (c *Config[T]) StartWatch() error {
+	c.watchMu.Lock()
+	defer c.watchMu.Unlock()
+	if c.watcher != nil {
+		return nil
+	}
+
+	c.viperMu.RLock()
+	file := c.viper.ConfigFileUsed()
+	c.viperMu.RUnlock()
+	if file == "" {
+		return errors.New("config file is not set")
+	}
+	file = filepath.Clean(file)
+	realFile, _ := filepath.EvalSymlinks(file)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	// 监听配置文件所在目录，以兼容编辑器先删除后创建、k8s configmap 软链接切换等场景
+	if err = watcher.Add(filepath.Dir(file)); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+	c.watcher = watcher
+	c.watchDone = make(chan struct{})
+	go c.watchLoop(watcher, file, realFile, c.watchDone)
+	return nil
+}
+
+// StopWatch 停止监听配置文件变化
+func (c *Config[T]) StopWatch() error {
+	c.watchMu.Lock()
+	defer c.watchMu.Unlock()
+	if c.watcher == nil {
+		return nil
+	}
+	err := c.watcher.Close()
+	<-c.watchDone
+	c.watcher = nil
+	c.watchDone = nil
+	return err
+}
+
+func (c *Config[T]) watchLoop(watcher *fsnotify.Watcher, file, realFile string, done chan struct{}) {
+	defer close(done)
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			currentFile, _ := filepath.EvalSymlinks(file)
+			fileChanged := filepath.Clean(event.Name) == file && event.Has(fsnotify.Write|fsnotify.Create)
+			linkChanged := currentFile != "" && currentFile != realFile
+			if !fileChanged && !linkChanged {
+				continue
+			}
+			realFile = currentFile
+			c.logger.Info("config file changed", slog.String("file name", event.Name))
+			c.reload()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			c.logger.Error("watch config failed", slog.Any("error", err))
 		}
-	})
+	}
+}
+
+// reload 重新读取配置文件并解析配置项
+func (c *Config[T]) reload() {
+	c.viperMu.Lock()
+	defer c.viperMu.Unlock()
+	if err := c.viper.ReadInConfig(); err != nil {
+		c.logger.Error("read config failed", slog.Any("error", err))
+		return
+	}
+	if err := c.unmarshalConfig(); err != nil {
+		c.logger.Error("unmarshal config failed", slog.Any("error", err))
+	}
 }
 
+// print 打印全部配置项，调用方需持有 viperMu
 func (c *Config[T]) print() {
 	for _, k := range c.viper.AllKeys() {
 		v := c.viper.Get(k)
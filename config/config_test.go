@@ -3,11 +3,13 @@ package config_test
 import (
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/haysons/gokit/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
 )
 
@@ -127,6 +129,7 @@ func TestConfig_Watch(t *testing.T) {
 	err = cfg.Load()
 	assert.NoError(t, err)
 	cfg.Watch()
+	defer cfg.StopWatch()
 
 	// 修改文件以触发 Watch
 	updated := ServerConfig{}
@@ -149,3 +152,52 @@ func TestConfig_Watch(t *testing.T) {
 	assert.Equal(t, "127.0.0.1", newConf.Server.Host)
 	assert.Equal(t, 9090, newConf.Server.Port)
 }
+
+func TestConfig_StartWatchConcurrentRead(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeYaml := func(port int) {
+		cfg := ServerConfig{}
+		cfg.Server.Host = "localhost"
+		cfg.Server.Port = port
+		data, _ := yaml.Marshal(cfg)
+		require.NoError(t, os.WriteFile(file, data, 0644))
+	}
+	writeYaml(8000)
+
+	cfg := config.New[ServerConfig]()
+	cfg.SetType("yaml")
+	cfg.SetFile(file)
+	require.NoError(t, cfg.Load())
+	require.NoError(t, cfg.StartWatch())
+	defer cfg.StopWatch()
+
+	// 配置文件重新加载期间并发读取配置项
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					_ = cfg.GetString("server.host")
+					_ = cfg.AllSettings()
+				}
+			}
+		}()
+	}
+	for port := 8001; port <= 8005; port++ {
+		writeYaml(port)
+		time.Sleep(50 * time.Millisecond)
+	}
+	require.Eventually(t, func() bool { return cfg.GetInt("server.port") == 8005 }, 3*time.Second, 50*time.Millisecond)
+	close(stop)
+	wg.Wait()
+	assert.Equal(t, 8005, cfg.Get().Server.Port)
+
+	// 未设置配置文件时无法监听
+	assert.Error(t, config.New[ServerConfig]().StartWatch())
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"context"
+
+	"github.com/haysons/gokit/log"
+	"go.uber.org/fx"
+)
+
+// LogConfigGetter 配置结构体实现此接口后，Module 将同时向依赖图提供 *log.Config，供 log.Module 使用
+type LogConfigGetter interface {
+	GetLogConfig() *log.Config
+}
+
+// Module 创建配置的 fx 模块，向依赖图提供 *Config[T]、T 及 Settings，file 为配置文件路径，opts 可在加载前对 Config 进行设置。
+// 应用启动时开始监听配置文件变化，应用停止时结束监听
+func Module[T any](file string, opts ...func(*Config[T])) fx.Option {
+	options := []fx.Option{
+		fx.Provide(func() (*Config[T], error) {
+			c := New[T]()
+			c.SetFile(file)
+			for _, opt := range opts {
+				opt(c)
+			}
+			if err := c.Load(); err != nil {
+				return nil, err
+			}
+			return c, nil
+		}),
+		fx.Provide(func(c *Config[T]) T {
+			return c.Get()
+		}),
+		fx.Provide(func(c *Config[T]) Settings {
+			return c
+		}),
+		fx.Invoke(func(lc fx.Lifecycle, c *Config[T]) {
+			lc.Append(fx.Hook{
+				OnStart: func(context.Context) error {
+					return c.StartWatch()
+				},
+				OnStop: func(context.Context) error {
+					return c.StopWatch()
+				},
+			})
+		}),
+	}
+
+	// 配置结构体中包含日志配置时，一并提供给 log.Module
+	if _, ok := any(new(T)).(LogConfigGetter); ok {
+		options = append(options, fx.Provide(func(c *Config[T]) *log.Config {
+			conf := c.Get()
+			return any(&conf).(LogConfigGetter).GetLogConfig()
+		}))
+	}
+	return fx.Module("config", options...)
+}
@@ -0,0 +1,76 @@
+package config_test
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/haysons/gokit/config"
+	"github.com/haysons/gokit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type AppConfig struct {
+	Server struct {
+		Host string `mapstructure:"host"`
+		Port int    `mapstructure:"port"`
+	} `mapstructure:"server"`
+	Log log.Config `mapstructure:"log"`
+}
+
+func (c *AppConfig) GetLogConfig() *log.Config {
+	return &c.Log
+}
+
+func TestModule(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	content := "server:\n  host: 127.0.0.1\n  port: 8080\nlog:\n  level: warn\n  console_fmt: true\n"
+	require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+
+	var (
+		cfg      *config.Config[AppConfig]
+		conf     AppConfig
+		settings config.Settings
+		logger   *slog.Logger
+	)
+	app := fxtest.New(t,
+		config.Module[AppConfig](file, func(c *config.Config[AppConfig]) {
+			c.SetDefault("server.port", 80)
+		}),
+		log.Module,
+		fx.Populate(&cfg, &conf, &settings, &logger),
+	)
+	app.RequireStart()
+
+	assert.Equal(t, "127.0.0.1", conf.Server.Host)
+	assert.Equal(t, 8080, conf.Server.Port)
+	assert.Equal(t, "warn", conf.Log.Level)
+	assert.Equal(t, "127.0.0.1", settings.AllSettings()["server"].(map[string]any)["host"])
+	require.NotNil(t, logger)
+	assert.False(t, logger.Enabled(t.Context(), slog.LevelInfo))
+	assert.True(t, logger.Enabled(t.Context(), slog.LevelWarn))
+
+	// 启动后配置文件变更将自动重新加载
+	content = "server:\n  host: 127.0.0.1\n  port: 9090\nlog:\n  level: warn\n"
+	require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+	assert.Eventually(t, func() bool {
+		return cfg.Get().Server.Port == 9090
+	}, 3*time.Second, 100*time.Millisecond)
+
+	app.RequireStop()
+	log.SetDefaultSlog(&log.Config{Level: "info", ConsoleFmt: true, ConsoleColor: true})
+}
+
+func TestModule_LoadFailed(t *testing.T) {
+	app := fx.New(
+		config.Module[AppConfig](filepath.Join(t.TempDir(), "missing.yaml")),
+		fx.Invoke(func(AppConfig) {}),
+		fx.NopLogger,
+	)
+	assert.Error(t, app.Err())
+}
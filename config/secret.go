@@ -0,0 +1,171 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/haysons/gokit/util/crypto"
+	"github.com/haysons/gokit/util/encode"
+)
+
+const (
+	// SecretKeyEnv 默认读取秘钥的环境变量，值为 base64 编码的 AES 秘钥（16、24 或 32 字节）
+	SecretKeyEnv = "GOKIT_CONFIG_KEY"
+	// SecretKeyFileEnv 默认读取秘钥文件路径的环境变量，文件内容为 base64 编码的 AES 秘钥
+	SecretKeyFileEnv = "GOKIT_CONFIG_KEY_FILE"
+
+	secretPrefix = "ENC("
+	secretSuffix = ")"
+)
+
+var (
+	// ErrSecretKeyMissing 配置中存在加密值，但未配置解密秘钥
+	ErrSecretKeyMissing = errors.New("config: encrypted value found but secret key is not set")
+
+	secretPattern = regexp.MustCompile(`ENC\(([A-Za-z0-9+/=]*)\)`)
+)
+
+// IsSecret 判断配置值是否为加密值，即 ENC(base64...) 格式
+func IsSecret(value string) bool {
+	value = strings.TrimSpace(value)
+	return strings.HasPrefix(value, secretPrefix) && strings.HasSuffix(value, secretSuffix)
+}
+
+// EncryptSecret 使用 AES-GCM 加密配置值，返回 ENC(base64...) 格式的密文，可直接写入配置文件
+func EncryptSecret(plaintext string, key []byte) (string, error) {
+	ciphertext, err := crypto.AESEncrypt([]byte(plaintext), key)
+	if err != nil {
+		return "", fmt.Errorf("encrypt secret failed: %w", err)
+	}
+	return secretPrefix + encode.Base64Encode(ciphertext) + secretSuffix, nil
+}
+
+// DecryptSecret 解密 ENC(base64...) 格式的配置值，若配置值未加密则原样返回
+func DecryptSecret(value string, key []byte) (string, error) {
+	if !IsSecret(value) {
+		return value, nil
+	}
+	if len(key) == 0 {
+		return "", ErrSecretKeyMissing
+	}
+	value = strings.TrimSpace(value)
+	encoded := value[len(secretPrefix) : len(value)-len(secretSuffix)]
+	ciphertext, err := encode.Base64Decode(encoded)
+	if err != nil {
+		return "", fmt.Errorf("decode secret failed: %w", err)
+	}
+	plaintext, err := crypto.AESDecrypt(ciphertext, key)
+	if err != nil {
+		return "", fmt.Errorf("decrypt secret failed: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// RekeySecrets 将配置文件内容中全部 ENC(...) 值使用旧秘钥解密后再以新秘钥加密，文件其余内容保持不变
+func RekeySecrets(data []byte, oldKey, newKey []byte) ([]byte, error) {
+	var rekeyErr error
+	out := secretPattern.ReplaceAllFunc(data, func(match []byte) []byte {
+		if rekeyErr != nil {
+			return match
+		}
+		plaintext, err := DecryptSecret(string(match), oldKey)
+		if err != nil {
+			rekeyErr = err
+			return match
+		}
+		secret, err := EncryptSecret(plaintext, newKey)
+		if err != nil {
+			rekeyErr = err
+			return match
+		}
+		return []byte(secret)
+	})
+	if rekeyErr != nil {
+		return nil, rekeyErr
+	}
+	return out, nil
+}
+
+// ParseSecretKey 解析 base64 编码的 AES 秘钥，秘钥长度需为 16、24 或 32 字节
+func ParseSecretKey(encoded string) ([]byte, error) {
+	key, err := encode.Base64Decode(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("decode secret key failed: %w", err)
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("invalid secret key size %d, must be 16, 24 or 32 bytes", len(key))
+	}
+}
+
+// SecretKeyFromEnv 自环境变量中读取 base64 编码的秘钥
+func SecretKeyFromEnv(name string) ([]byte, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return nil, fmt.Errorf("secret key env %s is not set", name)
+	}
+	return ParseSecretKey(value)
+}
+
+// SecretKeyFromFile 自文件中读取 base64 编码的秘钥
+func SecretKeyFromFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read secret key file failed: %w", err)
+	}
+	return ParseSecretKey(string(data))
+}
+
+// defaultSecretKey 依次尝试自 SecretKeyEnv 及 SecretKeyFileEnv 指向的文件中读取秘钥，均未配置时返回 nil
+func defaultSecretKey() ([]byte, error) {
+	if _, ok := os.LookupEnv(SecretKeyEnv); ok {
+		return SecretKeyFromEnv(SecretKeyEnv)
+	}
+	if path := os.Getenv(SecretKeyFileEnv); path != "" {
+		return SecretKeyFromFile(path)
+	}
+	return nil, nil
+}
+
+// secretDecodeHook 反序列化时透明地解密 ENC(...) 格式的配置值
+func (c *Config[T]) secretDecodeHook() mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, _ reflect.Type, data any) (any, error) {
+		if from.Kind() != reflect.String {
+			return data, nil
+		}
+		value, ok := data.(string)
+		if !ok || !IsSecret(value) {
+			return data, nil
+		}
+		key, err := c.getSecretKey()
+		if err != nil {
+			return nil, err
+		}
+		return DecryptSecret(value, key)
+	}
+}
+
+// getSecretKey 获取解密秘钥，未通过 SetSecretKey 指定时，自默认环境变量中读取
+func (c *Config[T]) getSecretKey() ([]byte, error) {
+	c.mu.RLock()
+	key := c.secretKey
+	c.mu.RUnlock()
+	if len(key) > 0 {
+		return key, nil
+	}
+	key, err := defaultSecretKey()
+	if err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, ErrSecretKeyMissing
+	}
+	return key, nil
+}
@@ -0,0 +1,135 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/haysons/gokit/config"
+	"github.com/haysons/gokit/util/encode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type DatabaseConfig struct {
+	Database struct {
+		User     string   `mapstructure:"user"`
+		Password string   `mapstructure:"password"`
+		Replicas []string `mapstructure:"replicas"`
+	} `mapstructure:"database"`
+}
+
+var (
+	testSecretKey  = []byte("12345678901234567890123456789012")
+	testSecretKey2 = []byte("abcdefghijklmnopqrstuvwxyz123456")
+)
+
+func TestEncryptDecryptSecret(t *testing.T) {
+	secret, err := config.EncryptSecret("p@ssw0rd", testSecretKey)
+	require.NoError(t, err)
+	assert.True(t, config.IsSecret(secret))
+
+	plaintext, err := config.DecryptSecret(secret, testSecretKey)
+	require.NoError(t, err)
+	assert.Equal(t, "p@ssw0rd", plaintext)
+
+	_, err = config.DecryptSecret(secret, testSecretKey2)
+	assert.Error(t, err)
+
+	_, err = config.DecryptSecret(secret, nil)
+	assert.ErrorIs(t, err, config.ErrSecretKeyMissing)
+
+	plaintext, err = config.DecryptSecret("plain", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "plain", plaintext)
+}
+
+func writeSecretConfig(t *testing.T, key []byte) string {
+	password, err := config.EncryptSecret("p@ssw0rd", key)
+	require.NoError(t, err)
+	replica, err := config.EncryptSecret("10.0.0.2", key)
+	require.NoError(t, err)
+
+	content := "database:\n" +
+		"  user: root\n" +
+		"  password: " + password + "\n" +
+		"  replicas:\n" +
+		"    - 10.0.0.1\n" +
+		"    - " + replica + "\n"
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+	return file
+}
+
+func TestConfig_LoadSecret(t *testing.T) {
+	file := writeSecretConfig(t, testSecretKey)
+
+	cfg := config.New[DatabaseConfig]()
+	cfg.SetFile(file)
+	cfg.SetSecretKey(testSecretKey)
+	require.NoError(t, cfg.Load())
+
+	conf := cfg.Get()
+	assert.Equal(t, "root", conf.Database.User)
+	assert.Equal(t, "p@ssw0rd", conf.Database.Password)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, conf.Database.Replicas)
+}
+
+func TestConfig_LoadSecretFromEnv(t *testing.T) {
+	file := writeSecretConfig(t, testSecretKey)
+	t.Setenv(config.SecretKeyEnv, encode.Base64Encode(testSecretKey))
+
+	cfg := config.New[DatabaseConfig]()
+	cfg.SetFile(file)
+	require.NoError(t, cfg.Load())
+	assert.Equal(t, "p@ssw0rd", cfg.Get().Database.Password)
+}
+
+func TestConfig_LoadSecretFromKeyFile(t *testing.T) {
+	file := writeSecretConfig(t, testSecretKey)
+	keyFile := filepath.Join(t.TempDir(), "config.key")
+	require.NoError(t, os.WriteFile(keyFile, []byte(encode.Base64Encode(testSecretKey)+"\n"), 0600))
+	t.Setenv(config.SecretKeyFileEnv, keyFile)
+
+	cfg := config.New[DatabaseConfig]()
+	cfg.SetFile(file)
+	require.NoError(t, cfg.Load())
+	assert.Equal(t, "p@ssw0rd", cfg.Get().Database.Password)
+}
+
+func TestConfig_LoadSecretWithoutKey(t *testing.T) {
+	file := writeSecretConfig(t, testSecretKey)
+
+	cfg := config.New[DatabaseConfig]()
+	cfg.SetFile(file)
+	assert.ErrorIs(t, cfg.Load(), config.ErrSecretKeyMissing)
+}
+
+func TestRekeySecrets(t *testing.T) {
+	file := writeSecretConfig(t, testSecretKey)
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+
+	out, err := config.RekeySecrets(data, testSecretKey, testSecretKey2)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(file, out, 0644))
+
+	cfg := config.New[DatabaseConfig]()
+	cfg.SetFile(file)
+	cfg.SetSecretKey(testSecretKey2)
+	require.NoError(t, cfg.Load())
+	assert.Equal(t, "p@ssw0rd", cfg.Get().Database.Password)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, cfg.Get().Database.Replicas)
+
+	_, err = config.RekeySecrets(out, testSecretKey, testSecretKey2)
+	assert.Error(t, err)
+}
+
+func TestParseSecretKey(t *testing.T) {
+	key, err := config.ParseSecretKey(encode.Base64Encode(testSecretKey))
+	require.NoError(t, err)
+	assert.Equal(t, testSecretKey, key)
+
+	_, err = config.ParseSecretKey(encode.Base64Encode([]byte("short")))
+	assert.Error(t, err)
+}
@@ -0,0 +1,185 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+var (
+	// ErrBarrierHeld 设置已被设置的屏障
+	ErrBarrierHeld = errors.New("barrier is already held")
+	// ErrBarrierEntered 重复进入已进入的双屏障
+	ErrBarrierEntered = errors.New("barrier is already entered")
+	// ErrBarrierNotEntered 离开未进入的双屏障
+	ErrBarrierNotEntered = errors.New("barrier is not entered")
+)
+
+// EtcdBarrier 基于 etcd 的分布式屏障，屏障被设置期间全部等待者阻塞，直至屏障被释放，
+// 可用于协调者完成准备工作（如发布前检查）前暂停全部工作者。
+// 屏障的 key 不绑定租约，持有者异常退出后屏障仍保持设置，需显式释放
+type EtcdBarrier struct {
+	client *clientv3.Client
+	key    string
+}
+
+// NewBarrier 创建分布式屏障实例
+func NewBarrier(client *clientv3.Client, key string) *EtcdBarrier {
+	return &EtcdBarrier{client: client, key: key}
+}
+
+// Hold 设置屏障，屏障已被设置时返回 ErrBarrierHeld
+func (b *EtcdBarrier) Hold(ctx context.Context) error {
+	resp, err := b.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(b.key), "=", 0)).
+		Then(clientv3.OpPut(b.key, "")).
+		Commit()
+	if err != nil {
+		return fmt.Errorf("hold barrier failed: %w", err)
+	}
+	if !resp.Succeeded {
+		return ErrBarrierHeld
+	}
+	return nil
+}
+
+// Release 释放屏障，唤醒全部等待者，屏障未设置时不做任何操作
+func (b *EtcdBarrier) Release(ctx context.Context) error {
+	if _, err := b.client.Delete(ctx, b.key); err != nil {
+		return fmt.Errorf("release barrier failed: %w", err)
+	}
+	return nil
+}
+
+// Wait 阻塞直至屏障被释放或 ctx 结束，屏障未设置时立即返回
+func (b *EtcdBarrier) Wait(ctx context.Context) error {
+	resp, err := b.client.Get(ctx, b.key, clientv3.WithKeysOnly())
+	if err != nil {
+		return fmt.Errorf("get barrier failed: %w", err)
+	}
+	if len(resp.Kvs) == 0 {
+		return nil
+	}
+	return waitDelete(ctx, b.client, b.key, resp.Header.Revision+1)
+}
+
+// EtcdDoubleBarrier 基于 etcd 的双屏障，count 个参与者均进入后才一同开始，均离开后才一同结束，
+// 可用于批处理任务各阶段之间的同步。参与者的 key 绑定会话租约，参与者异常退出后将自动离开。存储布局如下：
+//
+//	{key}/waiters/{lease} 已进入的参与者
+//	{key}/ready           参与者数量达到 count 时写入，唤醒等待中的参与者
+type EtcdDoubleBarrier struct {
+	client  *clientv3.Client
+	key     string
+	count   int
+	session *concurrency.Session
+
+	mu      sync.Mutex // 串行化进入及离开屏障的操作
+	entered string     // 已进入屏障的参与者 key，未进入时为空
+}
+
+// DoubleBarrierOption 双屏障配置项
+type DoubleBarrierOption func(*sessionOptions)
+
+// WithDoubleBarrierTTL 配置会话租约时长，默认为60s，会话存续期间租约将自动续期，参与者异常退出后最长经过 ttl 视为已离开
+func WithDoubleBarrierTTL(ttl time.Duration) DoubleBarrierOption {
+	return func(o *sessionOptions) {
+		o.ttl = ttl
+	}
+}
+
+// NewDoubleBarrier 创建双屏障实例，同一 key 的全部实例需使用相同的 count
+func NewDoubleBarrier(client *clientv3.Client, key string, count int, opts ...DoubleBarrierOption) (*EtcdDoubleBarrier, error) {
+	if count <= 0 {
+		return nil, errors.New("barrier count must be positive")
+	}
+	o := newSessionOptions(opts...)
+	session, err := newSession(context.Background(), client, o.ttl)
+	if err != nil {
+		return nil, err
+	}
+	return &EtcdDoubleBarrier{client: client, key: key, count: count, session: session}, nil
+}
+
+func (b *EtcdDoubleBarrier) waitersPrefix() string {
+	return b.key + "/waiters/"
+}
+
+func (b *EtcdDoubleBarrier) readyKey() string {
+	return b.key + "/ready"
+}
+
+// Enter 进入屏障，阻塞直至 count 个参与者均已进入或 ctx 结束，ctx 结束时将退出屏障
+func (b *EtcdDoubleBarrier) Enter(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.entered != "" {
+		return ErrBarrierEntered
+	}
+
+	key, _, err := putEphemeral(ctx, b.session, b.waitersPrefix())
+	if err != nil {
+		return err
+	}
+	resp, err := b.client.Get(ctx, b.waitersPrefix(), clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		return abandon(ctx, b.client, key, fmt.Errorf("get participants failed: %w", err))
+	}
+	if resp.Count >= int64(b.count) {
+		// 最后进入的参与者写入 ready，唤醒其他参与者
+		if _, err = b.client.Put(ctx, b.readyKey(), ""); err != nil {
+			return abandon(ctx, b.client, key, fmt.Errorf("put ready failed: %w", err))
+		}
+	} else if err = waitPut(ctx, b.client, b.readyKey(), resp.Header.Revision+1); err != nil {
+		return abandon(ctx, b.client, key, err)
+	}
+	b.entered = key
+	return nil
+}
+
+// Leave 离开屏障，阻塞直至全部参与者均已离开或 ctx 结束
+func (b *EtcdDoubleBarrier) Leave(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.entered == "" {
+		return ErrBarrierNotEntered
+	}
+	if err := deleteKey(ctx, b.client, b.entered); err != nil {
+		return err
+	}
+	b.entered = ""
+
+	for {
+		resp, err := b.client.Get(ctx, b.waitersPrefix(), clientv3.WithPrefix(), clientv3.WithCountOnly())
+		if err != nil {
+			return fmt.Errorf("get participants failed: %w", err)
+		}
+		if resp.Count == 0 {
+			// 全部参与者均已离开，清理 ready 以便屏障被再次使用
+			_, err = b.client.Txn(ctx).
+				If(clientv3.Compare(clientv3.CreateRevision(b.waitersPrefix()).WithPrefix(), "=", 0)).
+				Then(clientv3.OpDelete(b.readyKey())).
+				Commit()
+			if err != nil {
+				return fmt.Errorf("delete ready failed: %w", err)
+			}
+			return nil
+		}
+		if err = waitDelete(ctx, b.client, b.waitersPrefix(), resp.Header.Revision+1, clientv3.WithPrefix()); err != nil {
+			return err
+		}
+	}
+}
+
+// Close 关闭双屏障实例，退出屏障并清理资源
+func (b *EtcdDoubleBarrier) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entered = ""
+	return b.session.Close()
+}
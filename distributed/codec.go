@@ -0,0 +1,13 @@
+package distributed
+
+import "github.com/haysons/gokit/distributed/internal/queue"
+
+// Codec 队列元素的编解码器
+type Codec = queue.Codec
+
+var (
+	// JSONCodec json 编解码器
+	JSONCodec = queue.JSONCodec
+	// MsgpackCodec msgpack 编解码器，编码结果更紧凑
+	MsgpackCodec = queue.MsgpackCodec
+)
@@ -2,97 +2,163 @@ package distributed
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
+	"time"
 
+	"github.com/haysons/gokit/distributed/internal/counter"
 	"go.etcd.io/etcd/client/v3"
 )
 
-// Counter 分布式计数器
-type Counter struct {
-	client *clientv3.Client
+// ErrConflict 并发修改冲突，重试次数耗尽后仍未修改成功
+var ErrConflict = errors.New("counter conflict: retries exhausted")
+
+// CounterOption 计数器配置项
+type CounterOption func(*counter.Options)
+
+// WithCounterRetries 配置 CAS 冲突后的最大重试次数，默认为20，耗尽后返回 ErrConflict
+func WithCounterRetries(n int) CounterOption {
+	return func(o *counter.Options) {
+		o.Retries = n
+	}
+}
+
+// WithCounterBackoff 配置首次重试前的退避时间，默认为5ms，此后指数增长并加入随机抖动，最长为500ms
+func WithCounterBackoff(d time.Duration) CounterOption {
+	return func(o *counter.Options) {
+		o.Backoff = d
+	}
+}
+
+// casRequest 一次 CAS 更新
+type casRequest struct {
 	key    string
+	update func(cur int64) int64                                  // 根据当前值计算新值
+	create func(ctx context.Context) ([]clientv3.OpOption, error) // key 不存在时写入的选项，如绑定租约
+	then   []clientv3.Op                                          // 写入成功时在同一事务中执行的操作
 }
 
-// NewCounter 创建分布式计数器实例
-func NewCounter(client *clientv3.Client, key string) *Counter {
-	return &Counter{
-		client: client,
-		key:    key,
+// cas 读取计数值并以 CAS 方式写入新值，冲突时按配置 o 退避重试，超过最大重试次数返回 ErrConflict
+func cas(ctx context.Context, client *clientv3.Client, o counter.Options, req casRequest) (int64, *clientv3.TxnResponse, error) {
+	for attempt := 0; ; attempt++ {
+		cur, modRevision, err := getCounter(ctx, client, req.key)
+		if err != nil {
+			return 0, nil, err
+		}
+		newVal := req.update(cur)
+
+		var (
+			cmp     clientv3.Cmp
+			putOpts []clientv3.OpOption
+		)
+		if modRevision == 0 {
+			// key 不存在，条件：version == 0
+			cmp = clientv3.Compare(clientv3.Version(req.key), "=", 0)
+			if req.create != nil {
+				if putOpts, err = req.create(ctx); err != nil {
+					return 0, nil, err
+				}
+			}
+		} else {
+			// key 存在，条件：modRevision 未变，写入时保留 key 已绑定的租约
+			cmp = clientv3.Compare(clientv3.ModRevision(req.key), "=", modRevision)
+			putOpts = []clientv3.OpOption{clientv3.WithIgnoreLease()}
+		}
+		ops := append([]clientv3.Op{clientv3.OpPut(req.key, strconv.FormatInt(newVal, 10), putOpts...)}, req.then...)
+		txnResp, err := client.Txn(ctx).If(cmp).Then(ops...).Commit()
+		if err != nil {
+			return 0, nil, fmt.Errorf("update counter failed: %w", err)
+		}
+		if txnResp.Succeeded {
+			return newVal, txnResp, nil
+		}
+
+		// 发生并发竞争，退避后重试
+		if attempt >= o.Retries {
+			return 0, nil, ErrConflict
+		}
+		if err = o.Wait(ctx, attempt); err != nil {
+			return 0, nil, err
+		}
 	}
 }
 
-// Get 获取当前计数值
-func (c *Counter) Get(ctx context.Context) (int64, error) {
-	resp, err := c.client.Get(ctx, c.key)
+// getCounter 读取计数值及其修改版本号，不存在时均返回0
+func getCounter(ctx context.Context, client *clientv3.Client, key string) (int64, int64, error) {
+	resp, err := client.Get(ctx, key)
 	if err != nil {
-		return 0, fmt.Errorf("get counter failed: %w", err)
+		return 0, 0, fmt.Errorf("get counter failed: %w", err)
 	}
-
 	if resp.Count == 0 {
-		return 0, nil
+		return 0, 0, nil
 	}
-
 	value, err := strconv.ParseInt(string(resp.Kvs[0].Value), 10, 64)
 	if err != nil {
-		return 0, fmt.Errorf("parse counter value failed: %w", err)
+		return 0, 0, fmt.Errorf("parse counter value failed: %w", err)
 	}
-
-	return value, nil
+	return value, resp.Kvs[0].ModRevision, nil
 }
 
-// Incr 原子递增计数，返回新值
-func (c *Counter) Incr(ctx context.Context) (int64, error) {
-	return c.IncrBy(ctx, 1)
-}
-
-// IncrBy 原子递增指定值，返回新值
-func (c *Counter) IncrBy(ctx context.Context, delta int64) (int64, error) {
-	// 先读取当前值
-	getResp, err := c.client.Get(ctx, c.key)
+// parseCounter 解析计数值，不存在时返回0
+func parseCounter(resp *clientv3.GetResponse) (int64, error) {
+	if len(resp.Kvs) == 0 {
+		return 0, nil
+	}
+	value, err := strconv.ParseInt(string(resp.Kvs[0].Value), 10, 64)
 	if err != nil {
-		return 0, fmt.Errorf("incr counter failed to get current value: %w", err)
+		return 0, fmt.Errorf("parse counter value failed: %w", err)
 	}
+	return value, nil
+}
 
-	var prevVal int64
-	var modRevision int64
-
-	if getResp.Count > 0 {
-		prevVal, err = strconv.ParseInt(string(getResp.Kvs[0].Value), 10, 64)
+// sumCounters 累加各 key 的计数值
+func sumCounters(resp *clientv3.GetResponse) (int64, error) {
+	var sum int64
+	for _, kv := range resp.Kvs {
+		value, err := strconv.ParseInt(string(kv.Value), 10, 64)
 		if err != nil {
-			return 0, fmt.Errorf("incr counter failed to parse current value: %w", err)
+			return 0, fmt.Errorf("parse counter value failed: %w", err)
 		}
-		modRevision = getResp.Kvs[0].ModRevision
-	}
-
-	newVal := prevVal + delta
-	newValStr := strconv.FormatInt(newVal, 10)
-
-	// 使用 CAS 事务保证原子性：若 modRevision 未变则写入新值，否则说明有并发修改
-	var txnResp *clientv3.TxnResponse
-	if modRevision == 0 {
-		// key 不存在，条件：version == 0
-		txnResp, err = c.client.Txn(ctx).
-			If(clientv3.Compare(clientv3.Version(c.key), "=", 0)).
-			Then(clientv3.OpPut(c.key, newValStr)).
-			Commit()
-	} else {
-		// key 存在，条件：modRevision 未变
-		txnResp, err = c.client.Txn(ctx).
-			If(clientv3.Compare(clientv3.ModRevision(c.key), "=", modRevision)).
-			Then(clientv3.OpPut(c.key, newValStr)).
-			Commit()
-	}
-	if err != nil {
-		return 0, fmt.Errorf("incr counter failed: %w", err)
+		sum += value
 	}
+	return sum, nil
+}
+
+// Counter 基于 etcd 的分布式计数器
+type Counter struct {
+	client *clientv3.Client
+	key    string
+	opts   counter.Options
+}
 
-	if !txnResp.Succeeded {
-		// 发生并发竞争，重试
-		return c.IncrBy(ctx, delta)
+// NewCounter 创建分布式计数器实例
+func NewCounter(client *clientv3.Client, key string, opts ...CounterOption) *Counter {
+	return &Counter{
+		client: client,
+		key:    key,
+		opts:   counter.NewOptions(opts...),
 	}
+}
 
-	return newVal, nil
+// Get 获取当前计数值
+func (c *Counter) Get(ctx context.Context) (int64, error) {
+	value, _, err := getCounter(ctx, c.client, c.key)
+	return value, err
+}
+
+// Incr 原子递增计数，返回新值
+func (c *Counter) Incr(ctx context.Context) (int64, error) {
+	return c.IncrBy(ctx, 1)
+}
+
+// IncrBy 原子递增指定值，返回新值，并发冲突且重试次数耗尽时返回 ErrConflict
+func (c *Counter) IncrBy(ctx context.Context, delta int64) (int64, error) {
+	newVal, _, err := cas(ctx, c.client, c.opts, casRequest{
+		key:    c.key,
+		update: func(cur int64) int64 { return cur + delta },
+	})
+	return newVal, err
 }
 
 // Decr 原子递减计数，返回新值
@@ -115,6 +181,50 @@ func (c *Counter) Set(ctx context.Context, value int64) error {
 	return nil
 }
 
+// CompareAndSet 当前计数值等于 old 时设置为 value，返回是否设置成功，计数器不存在时视为0
+func (c *Counter) CompareAndSet(ctx context.Context, old, value int64) (bool, error) {
+	return compareAndSet(ctx, c.client, c.key, old, value, nil)
+}
+
+// compareAndSet 计数值等于 old 时设置为 value，计数器不存在时视为0，此时以 create 返回的选项写入
+func compareAndSet(ctx context.Context, client *clientv3.Client, key string, old, value int64, create func(ctx context.Context) ([]clientv3.OpOption, error)) (bool, error) {
+	newVal := strconv.FormatInt(value, 10)
+	txnResp, err := client.Txn(ctx).
+		If(clientv3.Compare(clientv3.Value(key), "=", strconv.FormatInt(old, 10))).
+		Then(clientv3.OpPut(key, newVal, clientv3.WithIgnoreLease())).
+		Commit()
+	if err != nil {
+		return false, fmt.Errorf("compare and set counter failed: %w", err)
+	}
+	if txnResp.Succeeded || old != 0 {
+		return txnResp.Succeeded, nil
+	}
+	// 计数器不存在时视为0
+	var putOpts []clientv3.OpOption
+	if create != nil {
+		if putOpts, err = create(ctx); err != nil {
+			return false, err
+		}
+	}
+	txnResp, err = client.Txn(ctx).
+		If(clientv3.Compare(clientv3.Version(key), "=", 0)).
+		Then(clientv3.OpPut(key, newVal, putOpts...)).
+		Commit()
+	if err != nil {
+		return false, fmt.Errorf("compare and set counter failed: %w", err)
+	}
+	return txnResp.Succeeded, nil
+}
+
+// GetAndReset 原子地获取当前计数值并重置为0
+func (c *Counter) GetAndReset(ctx context.Context) (int64, error) {
+	resp, err := c.client.Txn(ctx).Then(clientv3.OpGet(c.key), clientv3.OpDelete(c.key)).Commit()
+	if err != nil {
+		return 0, fmt.Errorf("get and reset counter failed: %w", err)
+	}
+	return parseCounter((*clientv3.GetResponse)(resp.Responses[0].GetResponseRange()))
+}
+
 // Reset 重置计数器到 0
 func (c *Counter) Reset(ctx context.Context) error {
 	_, err := c.client.Delete(ctx, c.key)
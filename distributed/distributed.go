@@ -0,0 +1,203 @@
+package distributed
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	_ Locker          = (*Lock)(nil)
+	_ RWLocker        = (*RWMutex)(nil)
+	_ Semaphore       = (*EtcdSemaphore)(nil)
+	_ Barrier         = (*EtcdBarrier)(nil)
+	_ DoubleBarrier   = (*EtcdDoubleBarrier)(nil)
+	_ Elector         = (*Election)(nil)
+	_ SimpleQueue     = (*Queue)(nil)
+	_ ReliableQueue   = (*EtcdReliableQueue)(nil)
+	_ TypedQueue[any] = (*EtcdTypedQueue[any])(nil)
+	_ AtomicCounter   = (*Counter)(nil)
+	_ AtomicCounter   = (*EtcdWindowCounter)(nil)
+	_ AtomicCounter   = (*EtcdShardedCounter)(nil)
+	_ NodeAllocator   = (*EtcdNodeAllocator)(nil)
+	_ PubSub          = (*EtcdPubSub)(nil)
+)
+
+// Locker 分布式锁
+type Locker interface {
+	// Lock 获取锁，超过 timeout 仍未获取时返回错误，非可重入模式下重复获取已持有的锁返回 ErrLockHeld
+	Lock(ctx context.Context, timeout time.Duration) error
+	// TryLock 尝试获取锁，立即返回结果，锁被其他实例持有时返回 false
+	TryLock(ctx context.Context) (bool, error)
+	// Token 当前持有锁的 fencing token，随每次获取锁单调递增，未持有锁时返回0
+	Token() int64
+	// Done 返回的管道在锁不再被持有时关闭，如会话失效导致锁丢失或锁被释放
+	Done() <-chan struct{}
+	// Unlock 释放锁，可重入模式下释放次数与获取次数相同时锁才会被真正释放
+	Unlock(ctx context.Context) error
+	// Close 释放锁及会话，此后不可再使用
+	Close() error
+}
+
+// RWLocker 分布式读写锁，同一时刻允许多个读者或一个写者持有锁，写者优先
+type RWLocker interface {
+	// RLock 获取读锁，阻塞直至获取成功或 ctx 结束
+	RLock(ctx context.Context) error
+	// TryRLock 尝试获取读锁，立即返回结果
+	TryRLock(ctx context.Context) (bool, error)
+	// RUnlock 释放读锁
+	RUnlock(ctx context.Context) error
+	// Lock 获取写锁，阻塞直至获取成功或 ctx 结束
+	Lock(ctx context.Context) error
+	// TryLock 尝试获取写锁，立即返回结果
+	TryLock(ctx context.Context) (bool, error)
+	// Unlock 释放写锁
+	Unlock(ctx context.Context) error
+	// Close 释放锁及会话，此后不可再使用
+	Close() error
+}
+
+// Semaphore 分布式计数信号量，同一时刻最多有指定数量的实例持有许可
+type Semaphore interface {
+	// Acquire 获取许可，阻塞直至获取成功或 ctx 结束
+	Acquire(ctx context.Context) error
+	// TryAcquire 尝试获取许可，立即返回结果，许可已耗尽时返回 false
+	TryAcquire(ctx context.Context) (bool, error)
+	// Release 释放许可
+	Release(ctx context.Context) error
+	// Close 释放许可及会话，此后不可再使用
+	Close() error
+}
+
+// Barrier 分布式屏障，屏障被设置期间全部等待者阻塞，直至屏障被释放
+type Barrier interface {
+	// Hold 设置屏障，屏障已被设置时返回 ErrBarrierHeld
+	Hold(ctx context.Context) error
+	// Release 释放屏障，唤醒全部等待者
+	Release(ctx context.Context) error
+	// Wait 阻塞直至屏障被释放或 ctx 结束，屏障未设置时立即返回
+	Wait(ctx context.Context) error
+}
+
+// DoubleBarrier 分布式双屏障，指定数量的参与者均进入后才一同开始，均离开后才一同结束
+type DoubleBarrier interface {
+	// Enter 进入屏障，阻塞直至全部参与者均已进入或 ctx 结束
+	Enter(ctx context.Context) error
+	// Leave 离开屏障，阻塞直至全部参与者均已离开或 ctx 结束
+	Leave(ctx context.Context) error
+	// Close 退出屏障及会话，此后不可再使用
+	Close() error
+}
+
+// Elector 领导者选举
+type Elector interface {
+	// ID 当前竞选者的 id
+	ID() string
+	// IsLeader 当前竞选者是否为 leader
+	IsLeader() bool
+	// Leader 当前 leader 的 id，leader 未知时为空
+	Leader() string
+	// Candidate 当前竞选者的信息
+	Candidate() CandidateInfo
+	// LeaderInfo 当前 leader 的信息，leader 未知时 ok 为 false
+	LeaderInfo() (info CandidateInfo, ok bool)
+	// OnElected 注册成为 leader 时执行的回调，回调在独立的 goroutine 中执行，ctx 在失去领导权时取消
+	OnElected(fn func(ctx context.Context))
+	// OnRevoked 注册失去领导权时执行的回调
+	OnRevoked(fn func())
+	// Events 返回领导权变化事件的管道
+	Events() <-chan LeaderEvent
+	// RunWhenLeader 成为 leader 时启动任务，失去领导权时取消任务的 ctx
+	RunWhenLeader(fn func(ctx context.Context) error)
+	// Start 发起竞选，并等待出现 leader
+	Start(ctx context.Context) error
+	// Status 选举已就绪且会话正常时返回 nil
+	Status() error
+	// Resign 当前竞选者为 leader 时主动卸任，随后作为普通竞选者重新参与竞选
+	Resign(ctx context.Context) error
+	// Close 退出竞选，当前竞选者为 leader 时立刻结束任期
+	Close() error
+}
+
+// SimpleQueue 分布式队列，元素以 json 编码存储
+type SimpleQueue interface {
+	// Enqueue 入队
+	Enqueue(ctx context.Context, value any) error
+	// Dequeue 出队，队列为空时阻塞直至有元素入队，超过 timeout 时返回错误
+	Dequeue(ctx context.Context, timeout time.Duration) (any, error)
+	// Length 队列长度
+	Length(ctx context.Context) (int64, error)
+	// Clear 清空队列
+	Clear(ctx context.Context) error
+}
+
+// TypedQueue 泛型分布式队列，支持优先级及延迟投递
+type TypedQueue[T any] interface {
+	// Enqueue 入队，可通过 WithPriority 指定优先级，通过 WithDelay 或 WithDeliverAt 延迟投递
+	Enqueue(ctx context.Context, value T, opts ...EnqueueOption) error
+	// Dequeue 出队优先级最高的元素中最早入队的元素，队列为空时阻塞，超过 timeout 时返回错误
+	Dequeue(ctx context.Context, timeout time.Duration) (T, error)
+	// Length 队列长度，包含尚未到期的延迟元素
+	Length(ctx context.Context) (int64, error)
+	// Clear 清空队列
+	Clear(ctx context.Context) error
+}
+
+// ReliableQueue 可靠队列，消息出队后需确认，未确认的消息将在可见性超时后重新投递，超过最大投递次数后进入死信
+type ReliableQueue interface {
+	// Enqueue 入队
+	Enqueue(ctx context.Context, value any) error
+	// Dequeue 租用最早入队的消息，队列为空时阻塞，超过 timeout 时返回错误
+	Dequeue(ctx context.Context, timeout time.Duration) (*Message, error)
+	// Ack 确认消息处理完成并删除消息
+	Ack(ctx context.Context, msg *Message) error
+	// Nack 放弃处理消息，消息立即重新入队，超过最大投递次数时进入死信
+	Nack(ctx context.Context, msg *Message) error
+	// Length 待投递的消息数
+	Length(ctx context.Context) (int64, error)
+	// InFlight 投递中的消息数
+	InFlight(ctx context.Context) (int64, error)
+	// DeadLetters 返回全部死信消息
+	DeadLetters(ctx context.Context) ([]*Message, error)
+	// Clear 清空队列，包括投递中的消息及死信
+	Clear(ctx context.Context) error
+}
+
+// AtomicCounter 分布式计数器
+type AtomicCounter interface {
+	// Get 获取当前计数值，不存在时返回0
+	Get(ctx context.Context) (int64, error)
+	// Incr 原子加1，返回新值
+	Incr(ctx context.Context) (int64, error)
+	// IncrBy 原子增加 delta，返回新值
+	IncrBy(ctx context.Context, delta int64) (int64, error)
+	// Decr 原子减1，返回新值
+	Decr(ctx context.Context) (int64, error)
+	// DecrBy 原子减少 delta，返回新值
+	DecrBy(ctx context.Context, delta int64) (int64, error)
+	// Set 设置计数值
+	Set(ctx context.Context, value int64) error
+	// CompareAndSet 当前计数值等于 old 时设置为 value，返回是否设置成功
+	CompareAndSet(ctx context.Context, old, value int64) (bool, error)
+	// GetAndReset 原子地获取当前计数值并重置为0
+	GetAndReset(ctx context.Context) (int64, error)
+	// Reset 重置计数值为0
+	Reset(ctx context.Context) error
+}
+
+// NodeAllocator 雪花算法节点号分配器，为实例分配集群内唯一的节点号
+type NodeAllocator interface {
+	// Allocate 分配空闲的节点号，已分配时返回当前节点号，全部节点号均已被分配时返回 ErrNoFreeNode
+	Allocate(ctx context.Context) (int64, error)
+	// Node 当前分配的节点号，未分配或租约丢失后重新申请成功前返回-1
+	Node() int64
+	// Close 释放节点号及会话，此后不可再使用
+	Close() error
+}
+
+// PubSub 分布式发布订阅，消息仅短暂保留，适用于本地缓存失效等广播通知
+type PubSub interface {
+	// Publish 向 topic 发布消息，当前的全部订阅者均会收到该消息
+	Publish(ctx context.Context, topic string, payload []byte) error
+	// Subscribe 订阅 topic 此后发布的消息，返回的管道在 ctx 结束后关闭
+	Subscribe(ctx context.Context, topic string) (<-chan *TopicMessage, error)
+}
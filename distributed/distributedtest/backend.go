@@ -0,0 +1,259 @@
+package distributedtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/haysons/gokit/distributed"
+	"github.com/haysons/gokit/distributed/memory"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Backend 待测试的分布式原语实现
+type Backend interface {
+	NewLocker(t *testing.T, key string, opts ...distributed.LockOption) distributed.Locker
+	NewRWLocker(t *testing.T, key string) distributed.RWLocker
+	NewSemaphore(t *testing.T, key string, permits int) distributed.Semaphore
+	NewBarrier(t *testing.T, key string) distributed.Barrier
+	NewDoubleBarrier(t *testing.T, key string, count int) distributed.DoubleBarrier
+	NewElector(t *testing.T, key string, opts ...distributed.ElectionOption) distributed.Elector
+	NewQueue(t *testing.T, prefix string) distributed.SimpleQueue
+	NewTypedQueue(t *testing.T, prefix string, opts ...distributed.TypedQueueOption) distributed.TypedQueue[Item]
+	NewReliableQueue(t *testing.T, prefix string, opts ...distributed.ReliableQueueOption) distributed.ReliableQueue
+	NewCounter(t *testing.T, key string, opts ...distributed.CounterOption) distributed.AtomicCounter
+	NewWindowCounter(t *testing.T, key string, window time.Duration) distributed.AtomicCounter
+	NewShardedCounter(t *testing.T, key string, shards int) distributed.AtomicCounter
+	NewNodeAllocator(t *testing.T, prefix string, opts ...distributed.NodeAllocatorOption) distributed.NodeAllocator
+	NewPubSub(t *testing.T, prefix string) distributed.PubSub
+	// ExpireHolder 模拟 key 上锁的持有者、leader 或最早申请节点号的分配器的会话租约过期
+	ExpireHolder(t *testing.T, key string)
+	// SuspendHolder 模拟 key 上锁的持有者、leader 或最早申请节点号的分配器进程假死，会话停止续期，租约在配置的 TTL 到期后过期
+	SuspendHolder(t *testing.T, key string)
+}
+
+// Item 泛型队列用例使用的元素类型
+type Item struct {
+	Name string
+	Seq  int
+}
+
+// MemoryBackend 内存实现
+type MemoryBackend struct {
+	*memory.Backend
+}
+
+// NewMemoryBackend 创建内存实现的测试后端
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{Backend: memory.NewBackend()}
+}
+
+func (b *MemoryBackend) NewLocker(t *testing.T, key string, opts ...distributed.LockOption) distributed.Locker {
+	l, err := b.NewLock(key, opts...)
+	if err != nil {
+		t.Fatalf("new lock: %v", err)
+	}
+	return l
+}
+
+func (b *MemoryBackend) NewRWLocker(_ *testing.T, key string) distributed.RWLocker {
+	return b.NewRWMutex(key)
+}
+
+func (b *MemoryBackend) NewSemaphore(t *testing.T, key string, permits int) distributed.Semaphore {
+	s, err := b.Backend.NewSemaphore(key, permits)
+	if err != nil {
+		t.Fatalf("new semaphore: %v", err)
+	}
+	return s
+}
+
+func (b *MemoryBackend) NewBarrier(_ *testing.T, key string) distributed.Barrier {
+	return b.Backend.NewBarrier(key)
+}
+
+func (b *MemoryBackend) NewDoubleBarrier(t *testing.T, key string, count int) distributed.DoubleBarrier {
+	d, err := b.Backend.NewDoubleBarrier(key, count)
+	if err != nil {
+		t.Fatalf("new double barrier: %v", err)
+	}
+	return d
+}
+
+func (b *MemoryBackend) NewElector(_ *testing.T, key string, opts ...distributed.ElectionOption) distributed.Elector {
+	return b.NewElection(key, opts...)
+}
+
+func (b *MemoryBackend) NewQueue(_ *testing.T, prefix string) distributed.SimpleQueue {
+	return b.Backend.NewQueue(prefix)
+}
+
+func (b *MemoryBackend) NewTypedQueue(_ *testing.T, prefix string, opts ...distributed.TypedQueueOption) distributed.TypedQueue[Item] {
+	return memory.NewTypedQueue[Item](b.Backend, prefix, opts...)
+}
+
+func (b *MemoryBackend) NewReliableQueue(_ *testing.T, prefix string, opts ...distributed.ReliableQueueOption) distributed.ReliableQueue {
+	return b.Backend.NewReliableQueue(prefix, opts...)
+}
+
+func (b *MemoryBackend) NewCounter(_ *testing.T, key string, opts ...distributed.CounterOption) distributed.AtomicCounter {
+	return b.Backend.NewCounter(key, opts...)
+}
+
+func (b *MemoryBackend) NewWindowCounter(_ *testing.T, key string, window time.Duration) distributed.AtomicCounter {
+	return b.Backend.NewWindowCounter(key, window)
+}
+
+func (b *MemoryBackend) NewShardedCounter(t *testing.T, key string, shards int) distributed.AtomicCounter {
+	c, err := b.Backend.NewShardedCounter(key, shards)
+	if err != nil {
+		t.Fatalf("new sharded counter: %v", err)
+	}
+	return c
+}
+
+func (b *MemoryBackend) NewNodeAllocator(_ *testing.T, prefix string, opts ...distributed.NodeAllocatorOption) distributed.NodeAllocator {
+	return b.Backend.NewNodeAllocator(prefix, opts...)
+}
+
+func (b *MemoryBackend) NewPubSub(_ *testing.T, prefix string) distributed.PubSub {
+	return b.Backend.NewPubSub(prefix)
+}
+
+func (b *MemoryBackend) ExpireHolder(_ *testing.T, key string) {
+	b.Backend.ExpireHolder(key)
+}
+
+func (b *MemoryBackend) SuspendHolder(_ *testing.T, key string) {
+	b.Backend.SuspendHolder(key)
+}
+
+// EtcdBackend 基于 etcd 的实现
+type EtcdBackend struct {
+	Client *clientv3.Client
+}
+
+// NewEtcdBackend 创建基于 etcd 的测试后端
+func NewEtcdBackend(client *clientv3.Client) *EtcdBackend {
+	return &EtcdBackend{Client: client}
+}
+
+func (b *EtcdBackend) NewLocker(t *testing.T, key string, opts ...distributed.LockOption) distributed.Locker {
+	l, err := distributed.NewLock(b.Client, key, opts...)
+	if err != nil {
+		t.Fatalf("new lock: %v", err)
+	}
+	return l
+}
+
+func (b *EtcdBackend) NewRWLocker(t *testing.T, key string) distributed.RWLocker {
+	m, err := distributed.NewRWMutex(b.Client, key)
+	if err != nil {
+		t.Fatalf("new rwmutex: %v", err)
+	}
+	return m
+}
+
+func (b *EtcdBackend) NewSemaphore(t *testing.T, key string, permits int) distributed.Semaphore {
+	s, err := distributed.NewSemaphore(b.Client, key, permits)
+	if err != nil {
+		t.Fatalf("new semaphore: %v", err)
+	}
+	return s
+}
+
+func (b *EtcdBackend) NewBarrier(_ *testing.T, key string) distributed.Barrier {
+	return distributed.NewBarrier(b.Client, key)
+}
+
+func (b *EtcdBackend) NewDoubleBarrier(t *testing.T, key string, count int) distributed.DoubleBarrier {
+	d, err := distributed.NewDoubleBarrier(b.Client, key, count)
+	if err != nil {
+		t.Fatalf("new double barrier: %v", err)
+	}
+	return d
+}
+
+func (b *EtcdBackend) NewElector(_ *testing.T, key string, opts ...distributed.ElectionOption) distributed.Elector {
+	return distributed.NewElection(b.Client, key, opts...)
+}
+
+func (b *EtcdBackend) NewQueue(_ *testing.T, prefix string) distributed.SimpleQueue {
+	return distributed.NewQueue(b.Client, prefix)
+}
+
+func (b *EtcdBackend) NewTypedQueue(_ *testing.T, prefix string, opts ...distributed.TypedQueueOption) distributed.TypedQueue[Item] {
+	return distributed.NewTypedQueue[Item](b.Client, prefix, opts...)
+}
+
+func (b *EtcdBackend) NewReliableQueue(_ *testing.T, prefix string, opts ...distributed.ReliableQueueOption) distributed.ReliableQueue {
+	return distributed.NewReliableQueue(b.Client, prefix, opts...)
+}
+
+func (b *EtcdBackend) NewCounter(_ *testing.T, key string, opts ...distributed.CounterOption) distributed.AtomicCounter {
+	return distributed.NewCounter(b.Client, key, opts...)
+}
+
+func (b *EtcdBackend) NewWindowCounter(_ *testing.T, key string, window time.Duration) distributed.AtomicCounter {
+	return distributed.NewWindowCounter(b.Client, key, window)
+}
+
+func (b *EtcdBackend) NewShardedCounter(t *testing.T, key string, shards int) distributed.AtomicCounter {
+	c, err := distributed.NewShardedCounter(b.Client, key, shards)
+	if err != nil {
+		t.Fatalf("new sharded counter: %v", err)
+	}
+	return c
+}
+
+func (b *EtcdBackend) NewNodeAllocator(_ *testing.T, prefix string, opts ...distributed.NodeAllocatorOption) distributed.NodeAllocator {
+	return distributed.NewNodeAllocator(b.Client, prefix, opts...)
+}
+
+func (b *EtcdBackend) NewPubSub(_ *testing.T, prefix string) distributed.PubSub {
+	return distributed.NewPubSub(b.Client, prefix)
+}
+
+// ExpireHolder 撤销 key 下创建版本最小的 key 绑定的租约，即锁的持有者、leader 或最早申请节点号的分配器的租约
+func (b *EtcdBackend) ExpireHolder(t *testing.T, key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	lease, ok := b.holderLease(t, ctx, key)
+	if !ok {
+		return
+	}
+	if _, err := b.Client.Revoke(ctx, lease); err != nil {
+		t.Fatalf("revoke lease: %v", err)
+	}
+}
+
+// SuspendHolder 持有者与测试共用客户端，无法单独停止其续期，因此在租约授予的时长后撤销租约，模拟租约到期
+func (b *EtcdBackend) SuspendHolder(t *testing.T, key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	lease, ok := b.holderLease(t, ctx, key)
+	if !ok {
+		return
+	}
+	resp, err := b.Client.TimeToLive(ctx, lease)
+	if err != nil {
+		t.Fatalf("get lease ttl: %v", err)
+	}
+	time.AfterFunc(time.Duration(resp.GrantedTTL)*time.Second, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, _ = b.Client.Revoke(ctx, lease)
+	})
+}
+
+// holderLease 返回 key 下创建版本最小的 key 绑定的租约
+func (b *EtcdBackend) holderLease(t *testing.T, ctx context.Context, key string) (clientv3.LeaseID, bool) {
+	resp, err := b.Client.Get(ctx, key+"/", clientv3.WithFirstCreate()...)
+	if err != nil {
+		t.Fatalf("get holder: %v", err)
+	}
+	if len(resp.Kvs) == 0 {
+		return 0, false
+	}
+	return clientv3.LeaseID(resp.Kvs[0].Lease), true
+}
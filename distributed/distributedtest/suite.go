@@ -0,0 +1,1322 @@
+package distributedtest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/haysons/gokit/distributed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Run 运行分布式原语的一致性测试，不同实现在同一组用例下应表现一致
+func Run(t *testing.T, b Backend) {
+	t.Run("Locker", func(t *testing.T) { testLocker(t, b) })
+	t.Run("RWLocker", func(t *testing.T) { testRWLocker(t, b) })
+	t.Run("Semaphore", func(t *testing.T) { testSemaphore(t, b) })
+	t.Run("Barrier", func(t *testing.T) { testBarrier(t, b) })
+	t.Run("DoubleBarrier", func(t *testing.T) { testDoubleBarrier(t, b) })
+	t.Run("Elector", func(t *testing.T) { testElector(t, b) })
+	t.Run("Queue", func(t *testing.T) { testQueue(t, b) })
+	t.Run("TypedQueue", func(t *testing.T) { testTypedQueue(t, b) })
+	t.Run("ReliableQueue", func(t *testing.T) { testReliableQueue(t, b) })
+	t.Run("Counter", func(t *testing.T) { testCounter(t, b) })
+	t.Run("NodeAllocator", func(t *testing.T) { testNodeAllocator(t, b) })
+	t.Run("PubSub", func(t *testing.T) { testPubSub(t, b) })
+}
+
+// key 各用例使用独立的 key，避免相互影响
+func key(t *testing.T) string {
+	return "/distributedtest/" + t.Name()
+}
+
+func testLocker(t *testing.T, b Backend) {
+	ctx := context.Background()
+
+	t.Run("MutualExclusion", func(t *testing.T) {
+		l1, l2 := b.NewLocker(t, key(t)), b.NewLocker(t, key(t))
+		defer l1.Close()
+		defer l2.Close()
+
+		require.NoError(t, l1.Lock(ctx, time.Second))
+		ok, err := l2.TryLock(ctx)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Error(t, l2.Lock(ctx, 200*time.Millisecond))
+
+		require.NoError(t, l1.Unlock(ctx))
+		ok, err = l2.TryLock(ctx)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		require.NoError(t, l2.Unlock(ctx))
+	})
+
+	t.Run("WaitForRelease", func(t *testing.T) {
+		l1, l2 := b.NewLocker(t, key(t)), b.NewLocker(t, key(t))
+		defer l1.Close()
+		defer l2.Close()
+
+		require.NoError(t, l1.Lock(ctx, time.Second))
+		acquired := make(chan error, 1)
+		go func() { acquired <- l2.Lock(ctx, 5*time.Second) }()
+		time.Sleep(100 * time.Millisecond)
+		require.NoError(t, l1.Close())
+		require.NoError(t, <-acquired)
+		require.NoError(t, l2.Unlock(ctx))
+	})
+
+	t.Run("TTLExpiry", func(t *testing.T) {
+		l1, l2 := b.NewLocker(t, key(t)), b.NewLocker(t, key(t))
+		defer l1.Close()
+		defer l2.Close()
+
+		require.NoError(t, l1.Lock(ctx, time.Second))
+		acquired := make(chan error, 1)
+		go func() { acquired <- l2.Lock(ctx, 5*time.Second) }()
+		time.Sleep(100 * time.Millisecond)
+		b.ExpireHolder(t, key(t))
+		require.NoError(t, <-acquired)
+
+		// 会话过期的实例不可再获取锁
+		assert.Error(t, l1.Lock(ctx, 200*time.Millisecond))
+		require.NoError(t, l2.Unlock(ctx))
+	})
+
+	t.Run("LeaseTTL", func(t *testing.T) {
+		const ttl = 2 * time.Second
+		l1, l2 := b.NewLocker(t, key(t), distributed.WithLockTTL(ttl)), b.NewLocker(t, key(t))
+		defer l1.Close()
+		defer l2.Close()
+
+		require.NoError(t, l1.Lock(ctx, time.Second))
+		done := l1.Done()
+		// 持有者停止续期后，租约到期前锁仍被持有
+		start := time.Now()
+		b.SuspendHolder(t, key(t))
+		ok, err := l2.TryLock(ctx)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		require.NoError(t, l2.Lock(ctx, 3*ttl))
+		assert.GreaterOrEqual(t, time.Since(start), ttl/2)
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("done not closed after lease expired")
+		}
+		assert.Error(t, l1.Lock(ctx, 200*time.Millisecond))
+		require.NoError(t, l2.Unlock(ctx))
+	})
+
+	t.Run("SubSecondTTL", func(t *testing.T) {
+		// 不足1s的租约时长向上取整为1s，而非退化为默认的60s
+		l1, l2 := b.NewLocker(t, key(t), distributed.WithLockTTL(500*time.Millisecond)), b.NewLocker(t, key(t))
+		defer l1.Close()
+		defer l2.Close()
+
+		require.NoError(t, l1.Lock(ctx, time.Second))
+		b.SuspendHolder(t, key(t))
+		require.NoError(t, l2.Lock(ctx, 5*time.Second))
+		require.NoError(t, l2.Unlock(ctx))
+	})
+
+	t.Run("UnlockWithoutLock", func(t *testing.T) {
+		l := b.NewLocker(t, key(t))
+		defer l.Close()
+		assert.Error(t, l.Unlock(ctx))
+	})
+
+	t.Run("TryLockNonBlocking", func(t *testing.T) {
+		l1, l2 := b.NewLocker(t, key(t)), b.NewLocker(t, key(t))
+		defer l1.Close()
+		defer l2.Close()
+
+		require.NoError(t, l1.Lock(ctx, time.Second))
+		start := time.Now()
+		ok, err := l2.TryLock(ctx)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+		require.NoError(t, l1.Unlock(ctx))
+	})
+
+	t.Run("FencingToken", func(t *testing.T) {
+		l1, l2 := b.NewLocker(t, key(t)), b.NewLocker(t, key(t))
+		defer l1.Close()
+		defer l2.Close()
+
+		assert.Zero(t, l1.Token())
+		require.NoError(t, l1.Lock(ctx, time.Second))
+		token1 := l1.Token()
+		assert.Positive(t, token1)
+		require.NoError(t, l1.Unlock(ctx))
+		assert.Zero(t, l1.Token())
+
+		require.NoError(t, l2.Lock(ctx, time.Second))
+		token2 := l2.Token()
+		assert.Greater(t, token2, token1)
+		require.NoError(t, l2.Unlock(ctx))
+
+		require.NoError(t, l1.Lock(ctx, time.Second))
+		assert.Greater(t, l1.Token(), token2)
+		require.NoError(t, l1.Unlock(ctx))
+	})
+
+	t.Run("Done", func(t *testing.T) {
+		// 较短的租约使会话续期更频繁，以便尽快感知会话失效
+		l1, l2 := b.NewLocker(t, key(t)), b.NewLocker(t, key(t), distributed.WithLockTTL(3*time.Second))
+		defer l1.Close()
+		defer l2.Close()
+
+		// 未持有锁时管道已关闭
+		select {
+		case <-l1.Done():
+		default:
+			t.Fatal("done should be closed before lock")
+		}
+
+		require.NoError(t, l1.Lock(ctx, time.Second))
+		done := l1.Done()
+		select {
+		case <-done:
+			t.Fatal("done closed while holding lock")
+		default:
+		}
+		require.NoError(t, l1.Unlock(ctx))
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("done not closed after unlock")
+		}
+
+		// 会话失效导致锁丢失时管道关闭
+		require.NoError(t, l2.Lock(ctx, time.Second))
+		done = l2.Done()
+		b.ExpireHolder(t, key(t))
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("done not closed after session expired")
+		}
+	})
+
+	t.Run("NonReentrant", func(t *testing.T) {
+		l := b.NewLocker(t, key(t))
+		defer l.Close()
+
+		require.NoError(t, l.Lock(ctx, time.Second))
+		token := l.Token()
+		// 非可重入模式下重复获取已持有的锁返回错误，且不影响已持有的锁
+		assert.ErrorIs(t, l.Lock(ctx, time.Second), distributed.ErrLockHeld)
+		ok, err := l.TryLock(ctx)
+		assert.ErrorIs(t, err, distributed.ErrLockHeld)
+		assert.False(t, ok)
+		assert.Equal(t, token, l.Token())
+
+		require.NoError(t, l.Unlock(ctx))
+		assert.ErrorIs(t, l.Unlock(ctx), distributed.ErrLockNotHeld)
+		require.NoError(t, l.Lock(ctx, time.Second))
+		require.NoError(t, l.Unlock(ctx))
+	})
+
+	t.Run("Reentrant", func(t *testing.T) {
+		l1 := b.NewLocker(t, key(t), distributed.WithReentrant())
+		l2 := b.NewLocker(t, key(t))
+		defer l1.Close()
+		defer l2.Close()
+
+		require.NoError(t, l1.Lock(ctx, time.Second))
+		token := l1.Token()
+		require.NoError(t, l1.Lock(ctx, time.Second))
+		ok, err := l1.TryLock(ctx)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, token, l1.Token())
+
+		// 释放次数与获取次数相同时锁才会被真正释放
+		require.NoError(t, l1.Unlock(ctx))
+		require.NoError(t, l1.Unlock(ctx))
+		ok, err = l2.TryLock(ctx)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		require.NoError(t, l1.Unlock(ctx))
+		ok, err = l2.TryLock(ctx)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		require.NoError(t, l2.Unlock(ctx))
+		assert.Error(t, l1.Unlock(ctx))
+	})
+}
+
+func testRWLocker(t *testing.T, b Backend) {
+	ctx := context.Background()
+
+	t.Run("SharedReaders", func(t *testing.T) {
+		r1, r2, w := b.NewRWLocker(t, key(t)), b.NewRWLocker(t, key(t)), b.NewRWLocker(t, key(t))
+		defer r1.Close()
+		defer r2.Close()
+		defer w.Close()
+
+		require.NoError(t, r1.RLock(ctx))
+		ok, err := r2.TryRLock(ctx)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = w.TryLock(ctx)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		require.NoError(t, r1.RUnlock(ctx))
+		require.NoError(t, r2.RUnlock(ctx))
+		ok, err = w.TryLock(ctx)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = r1.TryRLock(ctx)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		require.NoError(t, w.Unlock(ctx))
+	})
+
+	t.Run("WriterPreference", func(t *testing.T) {
+		r1, r2, w := b.NewRWLocker(t, key(t)), b.NewRWLocker(t, key(t)), b.NewRWLocker(t, key(t))
+		defer r1.Close()
+		defer r2.Close()
+		defer w.Close()
+
+		require.NoError(t, r1.RLock(ctx))
+		acquired := make(chan error, 1)
+		go func() { acquired <- w.Lock(ctx) }()
+		time.Sleep(200 * time.Millisecond)
+
+		// 写者排队后新到的读者需等待该写者
+		ok, err := r2.TryRLock(ctx)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		require.NoError(t, r1.RUnlock(ctx))
+		select {
+		case err := <-acquired:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("writer not acquired after readers released")
+		}
+		require.NoError(t, w.Unlock(ctx))
+		require.NoError(t, r2.RLock(ctx))
+		require.NoError(t, r2.RUnlock(ctx))
+	})
+
+	t.Run("ContextCancel", func(t *testing.T) {
+		w1, w2 := b.NewRWLocker(t, key(t)), b.NewRWLocker(t, key(t))
+		defer w1.Close()
+		defer w2.Close()
+
+		require.NoError(t, w1.Lock(ctx))
+		cctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		defer cancel()
+		assert.Error(t, w2.Lock(cctx))
+		require.NoError(t, w1.Unlock(ctx))
+
+		// 放弃等待的实例不应阻塞后续获取
+		ok, err := w1.TryLock(ctx)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		require.NoError(t, w1.Unlock(ctx))
+	})
+
+	t.Run("UnlockWithoutLock", func(t *testing.T) {
+		m := b.NewRWLocker(t, key(t))
+		defer m.Close()
+		assert.Error(t, m.Unlock(ctx))
+		require.NoError(t, m.RLock(ctx))
+		assert.Error(t, m.Unlock(ctx))
+		require.NoError(t, m.RUnlock(ctx))
+	})
+}
+
+func testSemaphore(t *testing.T, b Backend) {
+	ctx := context.Background()
+
+	t.Run("Permits", func(t *testing.T) {
+		s1, s2, s3 := b.NewSemaphore(t, key(t), 2), b.NewSemaphore(t, key(t), 2), b.NewSemaphore(t, key(t), 2)
+		defer s1.Close()
+		defer s2.Close()
+		defer s3.Close()
+
+		require.NoError(t, s1.Acquire(ctx))
+		ok, err := s2.TryAcquire(ctx)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = s3.TryAcquire(ctx)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		acquired := make(chan error, 1)
+		go func() { acquired <- s3.Acquire(ctx) }()
+		time.Sleep(100 * time.Millisecond)
+		require.NoError(t, s1.Release(ctx))
+		select {
+		case err := <-acquired:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("permit not acquired after release")
+		}
+		require.NoError(t, s2.Release(ctx))
+		require.NoError(t, s3.Release(ctx))
+		assert.Error(t, s3.Release(ctx))
+	})
+
+	t.Run("Concurrency", func(t *testing.T) {
+		const permits, workers = 2, 6
+		var (
+			wg      sync.WaitGroup
+			mu      sync.Mutex
+			running int
+			peak    int
+		)
+		for range workers {
+			s := b.NewSemaphore(t, key(t), permits)
+			defer s.Close()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, s.Acquire(ctx))
+				mu.Lock()
+				running++
+				peak = max(peak, running)
+				mu.Unlock()
+				time.Sleep(50 * time.Millisecond)
+				mu.Lock()
+				running--
+				mu.Unlock()
+				assert.NoError(t, s.Release(ctx))
+			}()
+		}
+		wg.Wait()
+		assert.LessOrEqual(t, peak, permits)
+		assert.Positive(t, peak)
+	})
+}
+
+func testBarrier(t *testing.T, b Backend) {
+	ctx := context.Background()
+
+	t.Run("WaitUntilRelease", func(t *testing.T) {
+		coordinator := b.NewBarrier(t, key(t))
+		// 屏障未设置时立即返回
+		require.NoError(t, coordinator.Wait(ctx))
+
+		require.NoError(t, coordinator.Hold(ctx))
+		assert.ErrorIs(t, b.NewBarrier(t, key(t)).Hold(ctx), distributed.ErrBarrierHeld)
+
+		const workers = 3
+		released := make(chan error, workers)
+		for range workers {
+			w := b.NewBarrier(t, key(t))
+			go func() { released <- w.Wait(ctx) }()
+		}
+		select {
+		case <-released:
+			t.Fatal("wait returned while barrier held")
+		case <-time.After(200 * time.Millisecond):
+		}
+		require.NoError(t, coordinator.Release(ctx))
+		for range workers {
+			select {
+			case err := <-released:
+				require.NoError(t, err)
+			case <-time.After(5 * time.Second):
+				t.Fatal("waiter not released")
+			}
+		}
+		// 释放后可再次设置
+		require.NoError(t, coordinator.Hold(ctx))
+		require.NoError(t, coordinator.Release(ctx))
+	})
+
+	t.Run("WaitContextCancel", func(t *testing.T) {
+		br := b.NewBarrier(t, key(t))
+		require.NoError(t, br.Hold(ctx))
+		defer br.Release(ctx)
+		waitCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		assert.Error(t, b.NewBarrier(t, key(t)).Wait(waitCtx))
+	})
+}
+
+func testDoubleBarrier(t *testing.T, b Backend) {
+	ctx := context.Background()
+
+	t.Run("EnterLeave", func(t *testing.T) {
+		const participants = 3
+		var (
+			wg             sync.WaitGroup
+			entering, left atomic.Int32
+		)
+		for range 2 {
+			// 屏障可被重复使用
+			for range participants {
+				d := b.NewDoubleBarrier(t, key(t), participants)
+				defer d.Close()
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					entering.Add(1)
+					if !assert.NoError(t, d.Enter(ctx)) {
+						return
+					}
+					// 全部参与者均已开始进入后才能通过
+					assert.EqualValues(t, participants, entering.Load())
+					time.Sleep(50 * time.Millisecond)
+					left.Add(1)
+					if !assert.NoError(t, d.Leave(ctx)) {
+						return
+					}
+					assert.EqualValues(t, participants, left.Load())
+				}()
+			}
+			wg.Wait()
+			entering.Store(0)
+			left.Store(0)
+		}
+	})
+
+	t.Run("EnterContextCancel", func(t *testing.T) {
+		d1 := b.NewDoubleBarrier(t, key(t), 2)
+		defer d1.Close()
+		enterCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		defer cancel()
+		assert.Error(t, d1.Enter(enterCtx))
+		assert.ErrorIs(t, d1.Leave(ctx), distributed.ErrBarrierNotEntered)
+
+		// 超时的参与者已退出，不计入参与者数量
+		d2, d3 := b.NewDoubleBarrier(t, key(t), 2), b.NewDoubleBarrier(t, key(t), 2)
+		defer d2.Close()
+		defer d3.Close()
+		entered := make(chan error, 1)
+		go func() { entered <- d2.Enter(ctx) }()
+		select {
+		case <-entered:
+			t.Fatal("entered before all participants arrived")
+		case <-time.After(200 * time.Millisecond):
+		}
+		require.NoError(t, d3.Enter(ctx))
+		require.NoError(t, <-entered)
+		assert.ErrorIs(t, d3.Enter(ctx), distributed.ErrBarrierEntered)
+	})
+}
+
+func testElector(t *testing.T, b Backend) {
+	ctx := context.Background()
+
+	t.Run("SingleLeader", func(t *testing.T) {
+		e1, e2 := b.NewElector(t, key(t)), b.NewElector(t, key(t))
+		defer e1.Close()
+		defer e2.Close()
+
+		assert.Error(t, e1.Status())
+		require.NoError(t, e1.Start(ctx))
+		require.NoError(t, e2.Start(ctx))
+		assert.NotEqual(t, e1.ID(), e2.ID())
+		assert.Eventually(t, func() bool { return e1.IsLeader() && !e2.IsLeader() }, 5*time.Second, 10*time.Millisecond)
+		assert.NoError(t, e1.Status())
+		assert.NoError(t, e2.Status())
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		e1, e2 := b.NewElector(t, key(t)), b.NewElector(t, key(t))
+		defer e2.Close()
+
+		require.NoError(t, e1.Start(ctx))
+		require.NoError(t, e2.Start(ctx))
+		require.Eventually(t, e1.IsLeader, 5*time.Second, 10*time.Millisecond)
+
+		require.NoError(t, e1.Close())
+		assert.Eventually(t, e2.IsLeader, 5*time.Second, 10*time.Millisecond)
+		assert.False(t, e1.IsLeader())
+		assert.Error(t, e1.Status())
+	})
+
+	t.Run("LeaderLoss", func(t *testing.T) {
+		e1, e2 := b.NewElector(t, key(t)), b.NewElector(t, key(t))
+		defer e1.Close()
+		defer e2.Close()
+
+		require.NoError(t, e1.Start(ctx))
+		require.NoError(t, e2.Start(ctx))
+		require.Eventually(t, e1.IsLeader, 5*time.Second, 10*time.Millisecond)
+
+		b.ExpireHolder(t, key(t))
+		assert.Eventually(t, func() bool { return e2.IsLeader() && !e1.IsLeader() }, 5*time.Second, 10*time.Millisecond)
+		// 失去领导权的竞选者以新会话重新参与竞选
+		assert.Eventually(t, func() bool { return e1.Status() == nil && e1.Leader() == e2.ID() }, 5*time.Second, 10*time.Millisecond)
+		assert.False(t, e1.IsLeader())
+	})
+
+	t.Run("LeaseTTL", func(t *testing.T) {
+		const ttl = 2 * time.Second
+		e1, e2 := b.NewElector(t, key(t), distributed.WithElectionTTL(ttl)), b.NewElector(t, key(t))
+		defer e1.Close()
+		defer e2.Close()
+
+		require.NoError(t, e1.Start(ctx))
+		require.NoError(t, e2.Start(ctx))
+		require.Eventually(t, e1.IsLeader, 5*time.Second, 10*time.Millisecond)
+
+		// leader 停止续期后，租约到期前仍保有领导权，到期后由其他竞选者接任
+		start := time.Now()
+		b.SuspendHolder(t, key(t))
+		require.Eventually(t, e2.IsLeader, 3*ttl, 10*time.Millisecond)
+		assert.GreaterOrEqual(t, time.Since(start), ttl/2)
+	})
+
+	t.Run("CandidateInfo", func(t *testing.T) {
+		info := distributed.CandidateInfo{
+			Name:      "demo",
+			Version:   "v1.0.0",
+			Endpoints: []string{"grpc://10.0.0.1:9000"},
+			Metadata:  map[string]string{"zone": "a"},
+		}
+		e1 := b.NewElector(t, key(t), distributed.WithElectionID("leader-1"), distributed.WithElectionCandidate(info))
+		e2 := b.NewElector(t, key(t))
+		defer e1.Close()
+		defer e2.Close()
+
+		assert.Equal(t, "leader-1", e1.ID())
+		assert.Equal(t, "leader-1", e1.Candidate().ID)
+		assert.NotEmpty(t, e2.ID())
+		assert.Equal(t, e2.ID(), e2.Candidate().ID)
+
+		require.NoError(t, e1.Start(ctx))
+		require.NoError(t, e2.Start(ctx))
+		require.Eventually(t, func() bool { return e2.Leader() == "leader-1" }, 5*time.Second, 10*time.Millisecond)
+		got, ok := e2.LeaderInfo()
+		require.True(t, ok)
+		assert.Equal(t, "leader-1", got.ID)
+		assert.Equal(t, "demo", got.Name)
+		assert.Equal(t, "v1.0.0", got.Version)
+		assert.Equal(t, info.Endpoints, got.Endpoints)
+		assert.Equal(t, info.Metadata, got.Metadata)
+		assert.Equal(t, e1.Candidate().Host, got.Host)
+	})
+
+	t.Run("Callbacks", func(t *testing.T) {
+		e1, e2 := b.NewElector(t, key(t)), b.NewElector(t, key(t))
+		defer e2.Close()
+
+		elected := make(chan context.Context, 1)
+		revoked := make(chan struct{}, 1)
+		e1.OnElected(func(ctx context.Context) { elected <- ctx })
+		e1.OnRevoked(func() { revoked <- struct{}{} })
+
+		require.NoError(t, e1.Start(ctx))
+		require.NoError(t, e2.Start(ctx))
+		var termCtx context.Context
+		select {
+		case termCtx = <-elected:
+		case <-time.After(5 * time.Second):
+			t.Fatal("OnElected is not called")
+		}
+		assert.NoError(t, termCtx.Err())
+		assert.Eventually(t, func() bool { return e2.Leader() == e1.ID() }, 5*time.Second, 10*time.Millisecond)
+
+		require.NoError(t, e1.Close())
+		select {
+		case <-revoked:
+		case <-time.After(5 * time.Second):
+			t.Fatal("OnRevoked is not called")
+		}
+		assert.Error(t, termCtx.Err())
+		assert.Eventually(t, func() bool { return e2.Leader() == e2.ID() }, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Events", func(t *testing.T) {
+		e := b.NewElector(t, key(t))
+		require.NoError(t, e.Start(ctx))
+		select {
+		case ev := <-e.Events():
+			assert.Equal(t, e.ID(), ev.Leader)
+			assert.True(t, ev.IsLeader)
+		case <-time.After(5 * time.Second):
+			t.Fatal("no leader event")
+		}
+		require.NoError(t, e.Close())
+		// 关闭后事件管道关闭
+		for range e.Events() {
+		}
+	})
+
+	t.Run("Resign", func(t *testing.T) {
+		e1, e2 := b.NewElector(t, key(t)), b.NewElector(t, key(t))
+		defer e1.Close()
+		defer e2.Close()
+
+		require.NoError(t, e1.Start(ctx))
+		require.NoError(t, e2.Start(ctx))
+		require.Eventually(t, e1.IsLeader, 5*time.Second, 10*time.Millisecond)
+		// 非 leader 卸任无影响
+		require.NoError(t, e2.Resign(ctx))
+
+		require.NoError(t, e1.Resign(ctx))
+		assert.Eventually(t, func() bool { return e2.IsLeader() && !e1.IsLeader() }, 5*time.Second, 10*time.Millisecond)
+		assert.Eventually(t, func() bool { return e1.Leader() == e2.ID() }, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("RunWhenLeader", func(t *testing.T) {
+		e1, e2 := b.NewElector(t, key(t)), b.NewElector(t, key(t))
+		defer e2.Close()
+
+		var (
+			mu      sync.Mutex
+			running = make(map[string]bool)
+		)
+		job := func(id string) func(ctx context.Context) error {
+			return func(ctx context.Context) error {
+				mu.Lock()
+				running[id] = true
+				mu.Unlock()
+				<-ctx.Done()
+				mu.Lock()
+				running[id] = false
+				mu.Unlock()
+				return nil
+			}
+		}
+		isRunning := func(id string) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return running[id]
+		}
+		e1.RunWhenLeader(job(e1.ID()))
+		e2.RunWhenLeader(job(e2.ID()))
+
+		require.NoError(t, e1.Start(ctx))
+		require.NoError(t, e2.Start(ctx))
+		assert.Eventually(t, func() bool { return isRunning(e1.ID()) && !isRunning(e2.ID()) }, 5*time.Second, 10*time.Millisecond)
+
+		require.NoError(t, e1.Close())
+		assert.Eventually(t, func() bool { return !isRunning(e1.ID()) && isRunning(e2.ID()) }, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+func testQueue(t *testing.T, b Backend) {
+	ctx := context.Background()
+
+	t.Run("FIFO", func(t *testing.T) {
+		q := b.NewQueue(t, key(t))
+		for _, v := range []any{1, "two", map[string]any{"three": 3}} {
+			require.NoError(t, q.Enqueue(ctx, v))
+		}
+		n, err := q.Length(ctx)
+		require.NoError(t, err)
+		assert.EqualValues(t, 3, n)
+
+		// 元素以 json 编码存储，数字解码为 float64
+		for _, want := range []any{float64(1), "two", map[string]any{"three": float64(3)}} {
+			v, err := q.Dequeue(ctx, time.Second)
+			require.NoError(t, err)
+			assert.Equal(t, want, v)
+		}
+	})
+
+	t.Run("DequeueTimeout", func(t *testing.T) {
+		q := b.NewQueue(t, key(t))
+		_, err := q.Dequeue(ctx, 200*time.Millisecond)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("DequeueBlocksUntilEnqueue", func(t *testing.T) {
+		q := b.NewQueue(t, key(t))
+		got := make(chan any, 1)
+		go func() {
+			v, _ := q.Dequeue(ctx, 5*time.Second)
+			got <- v
+		}()
+		time.Sleep(100 * time.Millisecond)
+		require.NoError(t, q.Enqueue(ctx, "late"))
+		assert.Equal(t, "late", <-got)
+	})
+
+	t.Run("ConsumedOnce", func(t *testing.T) {
+		q := b.NewQueue(t, key(t))
+		const total = 20
+		for i := range total {
+			require.NoError(t, q.Enqueue(ctx, i))
+		}
+		var (
+			mu   sync.Mutex
+			seen = make(map[float64]int)
+			wg   sync.WaitGroup
+		)
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					v, err := q.Dequeue(ctx, 300*time.Millisecond)
+					if err != nil {
+						return
+					}
+					mu.Lock()
+					seen[v.(float64)]++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Len(t, seen, total)
+		for v, n := range seen {
+			assert.Equal(t, 1, n, "value %v consumed %d times", v, n)
+		}
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		q := b.NewQueue(t, key(t))
+		require.NoError(t, q.Enqueue(ctx, 1))
+		require.NoError(t, q.Clear(ctx))
+		n, err := q.Length(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+}
+
+func testTypedQueue(t *testing.T, b Backend) {
+	ctx := context.Background()
+
+	for name, codec := range map[string]distributed.Codec{"JSON": distributed.JSONCodec, "Msgpack": distributed.MsgpackCodec} {
+		t.Run("FIFO"+name, func(t *testing.T) {
+			q := b.NewTypedQueue(t, key(t), distributed.WithCodec(codec))
+			const total = 20
+			for i := range total {
+				require.NoError(t, q.Enqueue(ctx, Item{Name: "item", Seq: i}))
+			}
+			n, err := q.Length(ctx)
+			require.NoError(t, err)
+			assert.EqualValues(t, total, n)
+
+			for i := range total {
+				v, err := q.Dequeue(ctx, time.Second)
+				require.NoError(t, err)
+				assert.Equal(t, Item{Name: "item", Seq: i}, v)
+			}
+		})
+	}
+
+	t.Run("Priority", func(t *testing.T) {
+		q := b.NewTypedQueue(t, key(t))
+		require.NoError(t, q.Enqueue(ctx, Item{Name: "low", Seq: 1}))
+		require.NoError(t, q.Enqueue(ctx, Item{Name: "high", Seq: 1}, distributed.WithPriority(2)))
+		require.NoError(t, q.Enqueue(ctx, Item{Name: "low", Seq: 2}))
+		require.NoError(t, q.Enqueue(ctx, Item{Name: "high", Seq: 2}, distributed.WithPriority(100)))
+		require.NoError(t, q.Enqueue(ctx, Item{Name: "mid", Seq: 1}, distributed.WithPriority(1)))
+
+		want := []Item{{"high", 1}, {"high", 2}, {"mid", 1}, {"low", 1}, {"low", 2}}
+		for _, w := range want {
+			v, err := q.Dequeue(ctx, time.Second)
+			require.NoError(t, err)
+			assert.Equal(t, w, v)
+		}
+	})
+
+	t.Run("Delay", func(t *testing.T) {
+		q := b.NewTypedQueue(t, key(t))
+		start := time.Now()
+		require.NoError(t, q.Enqueue(ctx, Item{Name: "delayed"}, distributed.WithDelay(time.Second)))
+		require.NoError(t, q.Enqueue(ctx, Item{Name: "scheduled"}, distributed.WithDeliverAt(start.Add(500*time.Millisecond))))
+		require.NoError(t, q.Enqueue(ctx, Item{Name: "now"}))
+
+		v, err := q.Dequeue(ctx, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "now", v.Name)
+		_, err = q.Dequeue(ctx, 200*time.Millisecond)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		// 等待中的消费者在延迟元素到期后出队
+		v, err = q.Dequeue(ctx, 5*time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "scheduled", v.Name)
+		v, err = q.Dequeue(ctx, 5*time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "delayed", v.Name)
+		assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		q := b.NewTypedQueue(t, key(t))
+		require.NoError(t, q.Enqueue(ctx, Item{Name: "a"}))
+		require.NoError(t, q.Enqueue(ctx, Item{Name: "b"}, distributed.WithDelay(time.Hour)))
+		n, err := q.Length(ctx)
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, n)
+		require.NoError(t, q.Clear(ctx))
+		n, err = q.Length(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+}
+
+func testReliableQueue(t *testing.T, b Backend) {
+	ctx := context.Background()
+
+	t.Run("Ack", func(t *testing.T) {
+		q := b.NewReliableQueue(t, key(t))
+		require.NoError(t, q.Enqueue(ctx, "a"))
+		require.NoError(t, q.Enqueue(ctx, "b"))
+
+		msg, err := q.Dequeue(ctx, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "a", msg.Value)
+		assert.Equal(t, 1, msg.Deliveries)
+		n, err := q.InFlight(ctx)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+		n, err = q.Length(ctx)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, n)
+
+		require.NoError(t, q.Ack(ctx, msg))
+		assert.ErrorIs(t, q.Ack(ctx, msg), distributed.ErrMessageNotInFlight)
+		n, err = q.InFlight(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+
+	t.Run("Nack", func(t *testing.T) {
+		q := b.NewReliableQueue(t, key(t))
+		require.NoError(t, q.Enqueue(ctx, "a"))
+		require.NoError(t, q.Enqueue(ctx, "b"))
+
+		msg, err := q.Dequeue(ctx, time.Second)
+		require.NoError(t, err)
+		require.NoError(t, q.Nack(ctx, msg))
+
+		// 放弃处理的消息重新入队至队尾
+		next, err := q.Dequeue(ctx, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "b", next.Value)
+		redelivered, err := q.Dequeue(ctx, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, msg.ID, redelivered.ID)
+		assert.Equal(t, 2, redelivered.Deliveries)
+		assert.ErrorIs(t, q.Ack(ctx, msg), distributed.ErrMessageNotInFlight)
+		require.NoError(t, q.Ack(ctx, redelivered))
+		require.NoError(t, q.Ack(ctx, next))
+	})
+
+	t.Run("VisibilityTimeout", func(t *testing.T) {
+		q := b.NewReliableQueue(t, key(t), distributed.WithVisibilityTimeout(time.Second))
+		require.NoError(t, q.Enqueue(ctx, "a"))
+		msg, err := q.Dequeue(ctx, time.Second)
+		require.NoError(t, err)
+
+		// 未确认的消息在可见性超时后重新投递，等待中的消费者将被唤醒
+		redelivered, err := q.Dequeue(ctx, 10*time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, msg.ID, redelivered.ID)
+		assert.Equal(t, 2, redelivered.Deliveries)
+		assert.ErrorIs(t, q.Ack(ctx, msg), distributed.ErrMessageNotInFlight)
+		require.NoError(t, q.Ack(ctx, redelivered))
+	})
+
+	t.Run("DeadLetter", func(t *testing.T) {
+		q := b.NewReliableQueue(t, key(t), distributed.WithMaxDeliveries(2))
+		require.NoError(t, q.Enqueue(ctx, "poison"))
+		for range 2 {
+			msg, err := q.Dequeue(ctx, time.Second)
+			require.NoError(t, err)
+			require.NoError(t, q.Nack(ctx, msg))
+		}
+		_, err := q.Dequeue(ctx, 200*time.Millisecond)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		dead, err := q.DeadLetters(ctx)
+		require.NoError(t, err)
+		require.Len(t, dead, 1)
+		assert.Equal(t, "poison", dead[0].Value)
+		assert.Equal(t, 2, dead[0].Deliveries)
+
+		require.NoError(t, q.Clear(ctx))
+		dead, err = q.DeadLetters(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, dead)
+	})
+
+	t.Run("DequeueBlocksUntilEnqueue", func(t *testing.T) {
+		q := b.NewReliableQueue(t, key(t))
+		got := make(chan any, 1)
+		go func() {
+			msg, err := q.Dequeue(ctx, 5*time.Second)
+			if err != nil {
+				got <- err
+				return
+			}
+			got <- msg.Value
+		}()
+		time.Sleep(100 * time.Millisecond)
+		require.NoError(t, q.Enqueue(ctx, "late"))
+		assert.Equal(t, "late", <-got)
+	})
+}
+
+func testCounter(t *testing.T, b Backend) {
+	ctx := context.Background()
+
+	t.Run("Basic", func(t *testing.T) {
+		c := b.NewCounter(t, key(t))
+		v, err := c.Get(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, v)
+
+		v, err = c.Incr(ctx)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, v)
+		v, err = c.IncrBy(ctx, 10)
+		require.NoError(t, err)
+		assert.EqualValues(t, 11, v)
+		v, err = c.DecrBy(ctx, 5)
+		require.NoError(t, err)
+		assert.EqualValues(t, 6, v)
+		v, err = c.Decr(ctx)
+		require.NoError(t, err)
+		assert.EqualValues(t, 5, v)
+
+		require.NoError(t, c.Set(ctx, 100))
+		v, err = c.Get(ctx)
+		require.NoError(t, err)
+		assert.EqualValues(t, 100, v)
+
+		require.NoError(t, c.Reset(ctx))
+		v, err = c.Get(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, v)
+	})
+
+	t.Run("ConcurrentIncr", func(t *testing.T) {
+		// 多个实例并发修改同一计数器，CAS 冲突后重试，最终结果不丢失
+		const workers, times = 8, 20
+		var wg sync.WaitGroup
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c := b.NewCounter(t, key(t))
+				for range times {
+					_, err := c.Incr(ctx)
+					assert.NoError(t, err)
+				}
+			}()
+		}
+		wg.Wait()
+		v, err := b.NewCounter(t, key(t)).Get(ctx)
+		require.NoError(t, err)
+		assert.EqualValues(t, workers*times, v)
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		// 不重试时并发修改可能因冲突失败，失败的修改不生效，成功的修改不丢失
+		const workers, times = 8, 20
+		var (
+			wg        sync.WaitGroup
+			succeeded atomic.Int64
+		)
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c := b.NewCounter(t, key(t), distributed.WithCounterRetries(0))
+				for range times {
+					_, err := c.Incr(ctx)
+					if err == nil {
+						succeeded.Add(1)
+						continue
+					}
+					assert.ErrorIs(t, err, distributed.ErrConflict)
+				}
+			}()
+		}
+		wg.Wait()
+		v, err := b.NewCounter(t, key(t)).Get(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, succeeded.Load(), v)
+		t.Logf("conflicts: %d", workers*times-v)
+	})
+
+	kinds := map[string]func(t *testing.T) distributed.AtomicCounter{
+		"Single":  func(t *testing.T) distributed.AtomicCounter { return b.NewCounter(t, key(t)) },
+		"Window":  func(t *testing.T) distributed.AtomicCounter { return b.NewWindowCounter(t, key(t), time.Hour) },
+		"Sharded": func(t *testing.T) distributed.AtomicCounter { return b.NewShardedCounter(t, key(t), 4) },
+	}
+	for name, newCounter := range kinds {
+		t.Run("CompareAndSet"+name, func(t *testing.T) {
+			c := newCounter(t)
+			// 计数器不存在时视为0
+			ok, err := c.CompareAndSet(ctx, 1, 10)
+			require.NoError(t, err)
+			assert.False(t, ok)
+			ok, err = c.CompareAndSet(ctx, 0, 10)
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			_, err = c.IncrBy(ctx, 5)
+			require.NoError(t, err)
+			ok, err = c.CompareAndSet(ctx, 10, 20)
+			require.NoError(t, err)
+			assert.False(t, ok)
+			ok, err = c.CompareAndSet(ctx, 15, 20)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			v, err := c.Get(ctx)
+			require.NoError(t, err)
+			assert.EqualValues(t, 20, v)
+		})
+
+		t.Run("GetAndReset"+name, func(t *testing.T) {
+			c := newCounter(t)
+			for range 3 {
+				_, err := c.IncrBy(ctx, 2)
+				require.NoError(t, err)
+			}
+			v, err := c.GetAndReset(ctx)
+			require.NoError(t, err)
+			assert.EqualValues(t, 6, v)
+			v, err = c.Get(ctx)
+			require.NoError(t, err)
+			assert.Zero(t, v)
+		})
+	}
+
+	t.Run("ShardedConcurrentIncr", func(t *testing.T) {
+		const workers, times = 8, 20
+		var wg sync.WaitGroup
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c := b.NewShardedCounter(t, key(t), 4)
+				for range times {
+					_, err := c.Incr(ctx)
+					assert.NoError(t, err)
+				}
+			}()
+		}
+		wg.Wait()
+		c := b.NewShardedCounter(t, key(t), 4)
+		v, err := c.Get(ctx)
+		require.NoError(t, err)
+		assert.EqualValues(t, workers*times, v)
+		v, err = c.Incr(ctx)
+		require.NoError(t, err)
+		assert.EqualValues(t, workers*times+1, v)
+		require.NoError(t, c.Set(ctx, 3))
+		v, err = c.Get(ctx)
+		require.NoError(t, err)
+		assert.EqualValues(t, 3, v)
+	})
+
+	t.Run("WindowExpiry", func(t *testing.T) {
+		const window = time.Second
+		c := b.NewWindowCounter(t, key(t), window)
+		// 从窗口开始时计数，避免计数期间窗口切换
+		time.Sleep(time.Until(time.Now().Truncate(window).Add(window)) + 50*time.Millisecond)
+		v, err := c.IncrBy(ctx, 3)
+		require.NoError(t, err)
+		assert.EqualValues(t, 3, v)
+		v, err = c.Get(ctx)
+		require.NoError(t, err)
+		assert.EqualValues(t, 3, v)
+
+		// 窗口切换后计数值从0开始
+		time.Sleep(time.Until(time.Now().Truncate(window).Add(window)) + 50*time.Millisecond)
+		v, err = c.Get(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, v)
+	})
+}
+
+func testNodeAllocator(t *testing.T, b Backend) {
+	ctx := context.Background()
+
+	t.Run("Unique", func(t *testing.T) {
+		nodes := make(map[int64]bool)
+		for range 5 {
+			a := b.NewNodeAllocator(t, key(t))
+			defer a.Close()
+			assert.EqualValues(t, -1, a.Node())
+			node, err := a.Allocate(ctx)
+			require.NoError(t, err)
+			assert.False(t, nodes[node], node)
+			nodes[node] = true
+			assert.Equal(t, node, a.Node())
+
+			// 已分配时返回当前节点号
+			again, err := a.Allocate(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, node, again)
+		}
+	})
+
+	t.Run("NoFreeNode", func(t *testing.T) {
+		a1 := b.NewNodeAllocator(t, key(t), distributed.WithMaxNode(1))
+		a2 := b.NewNodeAllocator(t, key(t), distributed.WithMaxNode(1))
+		a3 := b.NewNodeAllocator(t, key(t), distributed.WithMaxNode(1))
+		defer a2.Close()
+		defer a3.Close()
+
+		n1, err := a1.Allocate(ctx)
+		require.NoError(t, err)
+		n2, err := a2.Allocate(ctx)
+		require.NoError(t, err)
+		assert.NotEqual(t, n1, n2)
+		_, err = a3.Allocate(ctx)
+		assert.ErrorIs(t, err, distributed.ErrNoFreeNode)
+
+		// 关闭后节点号被释放，关闭的分配器不可再使用
+		require.NoError(t, a1.Close())
+		assert.EqualValues(t, -1, a1.Node())
+		_, err = a1.Allocate(ctx)
+		assert.ErrorIs(t, err, distributed.ErrAllocatorClosed)
+		n3, err := a3.Allocate(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, n1, n3)
+	})
+
+	t.Run("Reclaim", func(t *testing.T) {
+		var changed atomic.Int32
+		lost, reclaimed := make(chan int64, 1), make(chan int64, 1)
+		opts := []distributed.NodeAllocatorOption{
+			distributed.WithMaxNode(1),
+			distributed.WithNodeTTL(3 * time.Second),
+			distributed.WithNodeChange(func(int64) { changed.Add(1) }),
+			distributed.WithNodeLost(func(node int64) { lost <- node }),
+			distributed.WithNodeReclaim(func(node int64) { reclaimed <- node }),
+		}
+		a1 := b.NewNodeAllocator(t, key(t), opts...)
+		a2 := b.NewNodeAllocator(t, key(t), opts...)
+		defer a1.Close()
+		defer a2.Close()
+		n1, err := a1.Allocate(ctx)
+		require.NoError(t, err)
+		_, err = a2.Allocate(ctx)
+		require.NoError(t, err)
+
+		// 租约过期后在后台重新申请原节点号，申请成功后不再有空闲的节点号
+		b.ExpireHolder(t, key(t))
+		require.Eventually(t, func() bool {
+			a := b.NewNodeAllocator(t, key(t), distributed.WithMaxNode(1))
+			defer a.Close()
+			_, err := a.Allocate(ctx)
+			return errors.Is(err, distributed.ErrNoFreeNode)
+		}, 15*time.Second, 200*time.Millisecond)
+		assert.Equal(t, n1, a1.Node())
+		assert.Zero(t, changed.Load())
+
+		// 租约丢失与重新申请成功时均通知调用方，以便暂停及恢复 id 生成
+		select {
+		case node := <-lost:
+			assert.Equal(t, n1, node)
+		case <-time.After(time.Second):
+			t.Fatal("lost callback not called")
+		}
+		select {
+		case node := <-reclaimed:
+			assert.Equal(t, n1, node)
+		case <-time.After(15 * time.Second):
+			t.Fatal("reclaim callback not called")
+		}
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		var released atomic.Int32
+		opt := distributed.WithNodeRelease(func() { released.Add(1) })
+
+		// 未分配节点号时关闭不执行回调
+		require.NoError(t, b.NewNodeAllocator(t, key(t), opt).Close())
+		assert.Zero(t, released.Load())
+
+		a := b.NewNodeAllocator(t, key(t), opt)
+		_, err := a.Allocate(ctx)
+		require.NoError(t, err)
+		require.NoError(t, a.Close())
+		require.NoError(t, a.Close())
+		assert.EqualValues(t, 1, released.Load())
+	})
+}
+
+// receive 自订阅管道接收消息，超时返回 nil
+func receive(t *testing.T, ch <-chan *distributed.TopicMessage, timeout time.Duration) *distributed.TopicMessage {
+	t.Helper()
+	select {
+	case msg := <-ch:
+		return msg
+	case <-time.After(timeout):
+		return nil
+	}
+}
+
+func testPubSub(t *testing.T, b Backend) {
+	ctx := context.Background()
+
+	t.Run("Broadcast", func(t *testing.T) {
+		ps := b.NewPubSub(t, key(t))
+		subCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		subs := make([]<-chan *distributed.TopicMessage, 2)
+		for i := range subs {
+			ch, err := b.NewPubSub(t, key(t)).Subscribe(subCtx, "user")
+			require.NoError(t, err)
+			subs[i] = ch
+		}
+		other, err := ps.Subscribe(subCtx, "order")
+		require.NoError(t, err)
+
+		for _, payload := range []string{"1", "2", "3"} {
+			require.NoError(t, ps.Publish(ctx, "user", []byte(payload)))
+		}
+		for _, ch := range subs {
+			var last int64
+			for _, payload := range []string{"1", "2", "3"} {
+				msg := receive(t, ch, 5*time.Second)
+				require.NotNil(t, msg)
+				assert.Equal(t, "user", msg.Topic)
+				assert.Equal(t, payload, string(msg.Payload))
+				assert.Greater(t, msg.Revision, last)
+				last = msg.Revision
+			}
+		}
+		// 其他主题的订阅者不会收到消息
+		assert.Nil(t, receive(t, other, 200*time.Millisecond))
+	})
+
+	t.Run("OnlyNewMessages", func(t *testing.T) {
+		ps := b.NewPubSub(t, key(t))
+		require.NoError(t, ps.Publish(ctx, "user", []byte("old")))
+		subCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		ch, err := ps.Subscribe(subCtx, "user")
+		require.NoError(t, err)
+		require.NoError(t, ps.Publish(ctx, "user", []byte("new")))
+		msg := receive(t, ch, 5*time.Second)
+		require.NotNil(t, msg)
+		assert.Equal(t, "new", string(msg.Payload))
+	})
+
+	t.Run("Unsubscribe", func(t *testing.T) {
+		ps := b.NewPubSub(t, key(t))
+		subCtx, cancel := context.WithCancel(ctx)
+		ch, err := ps.Subscribe(subCtx, "user")
+		require.NoError(t, err)
+		cancel()
+		// ctx 结束后管道关闭
+		require.Eventually(t, func() bool {
+			select {
+			case _, ok := <-ch:
+				return !ok
+			default:
+				return false
+			}
+		}, 5*time.Second, 10*time.Millisecond)
+		require.NoError(t, ps.Publish(ctx, "user", []byte("ignored")))
+	})
+}
@@ -0,0 +1,60 @@
+package distributedtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/haysons/gokit/distributed"
+	"github.com/haysons/gokit/internal/etcdtest"
+	"github.com/haysons/gokit/util/uid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+func TestMemory(t *testing.T) {
+	Run(t, NewMemoryBackend())
+}
+
+func TestEtcd(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping embedded etcd in short mode")
+	}
+	Run(t, NewEtcdBackend(etcdtest.Start(t)))
+}
+
+func TestInitSnowflakeNode(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping embedded etcd in short mode")
+	}
+	defer uid.SetSnowflakeNode(uid.SnowflakeNode())
+	// 分配器使用独立的连接，关闭连接后租约丢失且无法重新申请节点号
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   etcdtest.Start(t).Endpoints(),
+		DialTimeout: 5 * time.Second,
+		Logger:      zap.NewNop(),
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	a, err := distributed.InitSnowflakeNode(context.Background(), client, "/"+t.Name(), distributed.WithMaxNode(0))
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, uid.CurrentSnowflakeNode())
+	assert.NotZero(t, uid.SnowflakeID())
+
+	// 租约丢失后暂停生成 id
+	require.NoError(t, client.Close())
+	require.Eventually(t, func() bool { return uid.SnowflakeID() == 0 }, 10*time.Second, 10*time.Millisecond)
+	assert.EqualValues(t, -1, a.Node())
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = uid.SnowflakeIDContext(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// 重新申请期间关闭分配器，生成器恢复为基于机器 id 计算的节点号
+	require.NoError(t, a.Close())
+	assert.Equal(t, uid.SnowflakeNode(), uid.CurrentSnowflakeNode())
+	assert.NotZero(t, uid.SnowflakeID())
+}
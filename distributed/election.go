@@ -5,38 +5,82 @@ import (
 	"errors"
 	"fmt"
 	"sync"
-	"sync/atomic"
 	"time"
 
-	"github.com/rs/xid"
+	"github.com/haysons/gokit/distributed/internal/election"
 	"go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/client/v3/concurrency"
 )
 
+// ElectionOption 选举配置项
+type ElectionOption func(*election.Options)
+
+// WithElectionTTL 配置会话租约时长，默认为10s
+func WithElectionTTL(ttl time.Duration) ElectionOption {
+	return func(o *election.Options) {
+		o.TTL = ttl
+	}
+}
+
+// WithElectionRetryInterval 配置竞选失败后重试的间隔，默认为5s
+func WithElectionRetryInterval(interval time.Duration) ElectionOption {
+	return func(o *election.Options) {
+		o.RetryInterval = interval
+	}
+}
+
+// WithElectionReadyTimeout 配置 Start 等待出现 leader 的最长时间，默认为10s
+func WithElectionReadyTimeout(timeout time.Duration) ElectionOption {
+	return func(o *election.Options) {
+		o.ReadyTimeout = timeout
+	}
+}
+
+// WithElectionID 配置竞选者 id，需保证全局唯一，默认为 ip-xid
+func WithElectionID(id string) ElectionOption {
+	return func(o *election.Options) {
+		o.Candidate.ID = id
+	}
+}
+
+// WithElectionCandidate 配置竞选者信息，如应用名称、版本及入口点，未指定 id 及 host 时使用默认值
+func WithElectionCandidate(info CandidateInfo) ElectionOption {
+	return func(o *election.Options) {
+		if info.ID == "" {
+			info.ID = o.Candidate.ID
+		}
+		o.Candidate = info
+	}
+}
+
 // Election 是伴随应用程序始终的选举对象，针对于etcd选举对象进行了更多的异常处理，在某次选举出现异常时，会发起新的选举
 type Election struct {
-	id        string // 竞选者id，当前使用ip-uid作为唯一标识
+	id        string // 竞选者id，默认使用ip-xid作为唯一标识
 	elKey     string
-	isLeader  int32
-	electCh   chan error
+	opts      election.Options
+	state     *election.LeaderState
 	readyCh   chan struct{}
 	readyOnce sync.Once
+	resignCh  chan resignReq // 主动卸任请求，由监听竞选的 goroutine 处理
 	ctx       context.Context
 	cancel    context.CancelFunc
 	client    *clientv3.Client
+	mu        sync.RWMutex
 	session   *concurrency.Session
 }
 
 // NewElection 新建选举对象
-func NewElection(client *clientv3.Client, elKey string) *Election {
-	id := fmt.Sprintf("%s-%s", "", xid.New().String())
+func NewElection(client *clientv3.Client, elKey string, opts ...ElectionOption) *Election {
+	o := election.NewOptions(opts...)
+	id := o.Candidate.ID
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Election{
 		id:       id,
 		elKey:    elKey,
-		isLeader: 0,
-		electCh:  make(chan error, 3),
+		opts:     o,
+		state:    election.NewLeaderState(id),
 		readyCh:  make(chan struct{}),
+		resignCh: make(chan resignReq),
 		ctx:      ctx,
 		cancel:   cancel,
 		client:   client,
@@ -50,7 +94,42 @@ func (el *Election) ID() string {
 
 // IsLeader 当前竞选者是否为leader
 func (el *Election) IsLeader() bool {
-	return atomic.LoadInt32(&el.isLeader) == 1
+	return el.state.IsLeader()
+}
+
+// Leader 当前leader的id，leader未知时为空
+func (el *Election) Leader() string {
+	return el.state.Leader()
+}
+
+// Candidate 当前竞选者的信息
+func (el *Election) Candidate() CandidateInfo {
+	return el.opts.Candidate
+}
+
+// LeaderInfo 当前leader的信息，follower可据此将请求转发至leader，leader未知时ok为false
+func (el *Election) LeaderInfo() (info CandidateInfo, ok bool) {
+	return el.state.LeaderInfo()
+}
+
+// OnElected 注册成为leader时执行的回调，回调在独立的goroutine中执行，ctx在失去领导权时取消
+func (el *Election) OnElected(fn func(ctx context.Context)) {
+	el.state.OnElected(fn)
+}
+
+// OnRevoked 注册失去领导权时执行的回调
+func (el *Election) OnRevoked(fn func()) {
+	el.state.OnRevoked(fn)
+}
+
+// Events 返回领导权变化事件的管道，缓冲已满时丢弃最旧的事件，管道在选举关闭后关闭
+func (el *Election) Events() <-chan LeaderEvent {
+	return el.state.Events()
+}
+
+// RunWhenLeader 成为leader时启动任务，失去领导权时取消任务的ctx
+func (el *Election) RunWhenLeader(fn func(ctx context.Context) error) {
+	el.state.RunWhenLeader(fn)
 }
 
 // Start 发起竞选，并等待出现leader，若等待时间过长将会返回错误
@@ -65,58 +144,81 @@ func (el *Election) run(ctx context.Context) {
 		case <-el.ctx.Done():
 			return
 		default:
-			elect, electRes, err := el.elect(ctx)
-			if err != nil {
-				time.Sleep(5 * time.Second)
-				break
-			}
-			if err = el.listen(ctx, elect, electRes); err != nil {
-			}
-			time.Sleep(time.Second)
 		}
+		elect, electRes, cancel, err := el.elect(ctx)
+		if err != nil {
+			el.sleep(el.opts.RetryInterval)
+			continue
+		}
+		_ = el.listen(ctx, elect, electRes)
+		cancel()
+		// 会话失效或主动卸任后，当前竞选者不再是leader，等待片刻后重新竞选
+		if el.state.IsLeader() {
+			el.state.Set(CandidateInfo{})
+		}
+		el.sleep(time.Second)
 	}
 }
 
-// elect 发起竞选
-func (el *Election) elect(ctx context.Context) (*concurrency.Election, chan error, error) {
+// sleep 等待d，选举关闭时立刻返回
+func (el *Election) sleep(d time.Duration) {
+	select {
+	case <-time.After(d):
+	case <-el.ctx.Done():
+	}
+}
+
+// elect 发起竞选，返回的cancel用于结束本次竞选的全部goroutine
+func (el *Election) elect(ctx context.Context) (*concurrency.Election, chan error, context.CancelFunc, error) {
 	// 关闭旧 session（如果有），避免资源泄漏
+	el.mu.Lock()
 	if el.session != nil {
 		el.session.Close()
+		el.session = nil
 	}
+	el.mu.Unlock()
 
-	session, err := concurrency.NewSession(el.client, concurrency.WithTTL(10), concurrency.WithContext(ctx))
+	session, err := newSession(ctx, el.client, el.opts.TTL)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
+	elect := concurrency.NewElection(session, el.elKey)
+	el.mu.Lock()
 	el.session = session
+	el.mu.Unlock()
+	electCtx, cancel := context.WithCancel(ctx)
 	electRes := make(chan error, 1)
-	election := concurrency.NewElection(session, el.elKey)
 	go func() {
-		electRes <- election.Campaign(ctx, el.id)
+		electRes <- elect.Campaign(electCtx, encodeCandidateInfo(el.opts.Candidate))
 	}()
-	return election, electRes, nil
+	return elect, electRes, cancel, nil
 }
 
-// listen 监听本次竞选的全部事件，直到监听出现异常才会停止阻塞，返回错误
+// listen 监听本次竞选的全部事件，直到监听出现异常、会话失效或主动卸任才会停止阻塞，返回错误
 func (el *Election) listen(ctx context.Context, elect *concurrency.Election, electRes chan error) error {
 	ticker := time.NewTicker(20 * time.Second)
 	defer ticker.Stop()
-	leaderChan := elect.Observe(ctx)
+	observeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	leaderChan := elect.Observe(observeCtx)
+	el.mu.RLock()
+	sessionDone := el.session.Done()
+	el.mu.RUnlock()
+	campaigned := false
 	for {
 		select {
 		case err := <-electRes:
 			// 本次选举发生问题，返回错误，发起新的选举
 			if err != nil {
 				return err
-			} else {
 			}
+			campaigned = true
 		case resp, ok := <-leaderChan:
 			if !ok {
 				return errors.New("elect observe chan closed")
 			}
 			if len(resp.Kvs) > 0 {
-				curLeader := string(resp.Kvs[0].Value)
-				el.setLeader(ctx, curLeader)
+				el.state.Set(parseCandidateInfo(resp.Kvs[0].Value))
 				el.readyOnce.Do(func() {
 					// 首次监听到leader发生变化，则初始化完成
 					close(el.readyCh)
@@ -133,25 +235,63 @@ func (el *Election) listen(ctx context.Context, elect *concurrency.Election, ele
 				continue
 			}
 			if resp != nil && len(resp.Kvs) > 0 {
-				el.setLeader(ctx, string(resp.Kvs[0].Value))
+				el.state.Set(parseCandidateInfo(resp.Kvs[0].Value))
 			}
+		case <-sessionDone:
+			return errors.New("election session expired")
+		case req := <-el.resignCh:
+			if !campaigned {
+				if !el.state.IsLeader() {
+					req.done <- nil
+					continue
+				}
+				// 已成为 leader 时竞选很快便会返回，concurrency.Election 非并发安全，需等待竞选返回后再卸任
+				if err := <-electRes; err != nil {
+					req.done <- nil
+					return err
+				}
+			}
+			err := elect.Resign(req.ctx)
+			req.done <- err
+			if err != nil {
+				continue
+			}
+			return errors.New("election resigned")
 		case <-el.ctx.Done():
 			return el.ctx.Err()
 		}
 	}
 }
 
-func (el *Election) setLeader(_ context.Context, curLeader string) {
-	if el.id == curLeader {
-		atomic.CompareAndSwapInt32(&el.isLeader, 0, 1)
-	} else {
-		atomic.CompareAndSwapInt32(&el.isLeader, 1, 0)
+// Resign 当前竞选者为leader时主动卸任，随后作为普通竞选者重新参与竞选，
+// 若不存在其他竞选者，当前竞选者将再次成为leader
+func (el *Election) Resign(ctx context.Context) error {
+	if !el.state.IsLeader() {
+		return nil
+	}
+	req := resignReq{ctx: ctx, done: make(chan error, 1)}
+	select {
+	case el.resignCh <- req:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-el.ctx.Done():
+		return nil
+	}
+	if err := <-req.done; err != nil {
+		return fmt.Errorf("resign failed: %w", err)
 	}
+	el.state.Set(CandidateInfo{})
+	return nil
+}
+
+type resignReq struct {
+	ctx  context.Context
+	done chan error
 }
 
 // waitForReady 实际等待
 func (el *Election) waitForReady() error {
-	ctx, cancel := context.WithTimeout(el.ctx, 10*time.Second)
+	ctx, cancel := context.WithTimeout(el.ctx, el.opts.ReadyTimeout)
 	defer cancel()
 	select {
 	case <-el.readyCh:
@@ -164,9 +304,38 @@ func (el *Election) waitForReady() error {
 	}
 }
 
-// Close 关闭竞选，清理资源，让leader立刻结束任期，需在程序退出时调用，否则间隔5s才能重新选出leader
+// Status 返回选举状态，选举已就绪且会话正常时返回 nil，可用于健康检查
+func (el *Election) Status() error {
+	select {
+	case <-el.ctx.Done():
+		return errors.New("election closed")
+	default:
+	}
+	select {
+	case <-el.readyCh:
+	default:
+		return errors.New("election not ready")
+	}
+
+	el.mu.RLock()
+	defer el.mu.RUnlock()
+	if el.session == nil {
+		return errors.New("election session not established")
+	}
+	select {
+	case <-el.session.Done():
+		return errors.New("election session expired")
+	default:
+	}
+	return nil
+}
+
+// Close 关闭竞选，清理资源，让leader立刻结束任期，需在程序退出时调用，否则需等待会话租约过期才能重新选出leader
 func (el *Election) Close() error {
 	el.cancel()
+	el.state.Close()
+	el.mu.Lock()
+	defer el.mu.Unlock()
 	if el.session != nil {
 		return el.session.Close()
 	}
@@ -0,0 +1,112 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+// defaultSessionTTL 会话的默认租约时长
+const defaultSessionTTL = 60 * time.Second
+
+// ErrLockHeld 重复获取已持有的锁或许可
+var ErrLockHeld = errors.New("lock is already held")
+
+// sessionOptions 读写锁、信号量等仅需配置会话的原语的配置
+type sessionOptions struct {
+	ttl time.Duration // 会话租约时长，持有者异常退出后最长经过 ttl 其持有的 key 将被删除
+}
+
+// newSessionOptions 根据配置项生成会话配置
+func newSessionOptions[F ~func(*sessionOptions)](opts ...F) sessionOptions {
+	o := sessionOptions{ttl: defaultSessionTTL}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// newSession 创建租约时长为 ttl 的会话，会话存续期间租约自动续期，ctx 结束后停止续期
+func newSession(ctx context.Context, client *clientv3.Client, ttl time.Duration) (*concurrency.Session, error) {
+	session, err := concurrency.NewSession(client, concurrency.WithTTL(sessionTTL(ttl)), concurrency.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("create session failed: %w", err)
+	}
+	return session, nil
+}
+
+// sessionTTL 将租约时长向上取整为秒且至少为1s，截断为0时 concurrency 将改用默认的60s
+func sessionTTL(ttl time.Duration) int {
+	return max(int(math.Ceil(ttl.Seconds())), 1)
+}
+
+// putEphemeral 在 prefix 下写入绑定会话租约的 key，返回 key 及其创建版本号，
+// 等待者按创建版本号排队，会话失效后 key 随租约一并删除
+func putEphemeral(ctx context.Context, session *concurrency.Session, prefix string) (string, int64, error) {
+	key := fmt.Sprintf("%s%x", prefix, session.Lease())
+	client := session.Client()
+	resp, err := client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+		Then(clientv3.OpPut(key, "", clientv3.WithLease(session.Lease()))).
+		Else(clientv3.OpGet(key)).
+		Commit()
+	if err != nil {
+		return "", 0, fmt.Errorf("put key failed: %w", err)
+	}
+	if resp.Succeeded {
+		return key, resp.Header.Revision, nil
+	}
+	return key, resp.Responses[0].GetResponseRange().Kvs[0].CreateRevision, nil
+}
+
+// deleteKey 删除 key，ctx 取消时仍会执行，避免遗留的 key 阻塞其他等待者
+func deleteKey(ctx context.Context, client *clientv3.Client, key string) error {
+	if _, err := client.Delete(context.WithoutCancel(ctx), key); err != nil {
+		return fmt.Errorf("delete key failed: %w", err)
+	}
+	return nil
+}
+
+// waitDelete 自版本号 rev 起监听 key，直至 key 被删除
+func waitDelete(ctx context.Context, client *clientv3.Client, key string, rev int64, opts ...clientv3.OpOption) error {
+	return waitEvent(ctx, client, key, rev, func(ev *clientv3.Event) bool { return ev.Type == mvccpb.DELETE },
+		append(opts, clientv3.WithFilterPut())...)
+}
+
+// waitPut 自版本号 rev 起监听前缀 prefix，直至其下有 key 写入
+func waitPut(ctx context.Context, client *clientv3.Client, prefix string, rev int64) error {
+	return waitEvent(ctx, client, prefix, rev, func(ev *clientv3.Event) bool { return ev.Type == mvccpb.PUT },
+		clientv3.WithPrefix(), clientv3.WithFilterDelete())
+}
+
+// waitEvent 自版本号 rev 起监听 key，直至出现满足 match 的事件
+func waitEvent(ctx context.Context, client *clientv3.Client, key string, rev int64, match func(ev *clientv3.Event) bool, opts ...clientv3.OpOption) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	wch := client.Watch(ctx, key, append(opts, clientv3.WithRev(rev))...)
+	for resp := range wch {
+		if err := resp.Err(); err != nil {
+			return err
+		}
+		for _, ev := range resp.Events {
+			if match(ev) {
+				return nil
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return errors.New("watch channel closed")
+}
+
+// abandon 等待失败时删除排队的 key，并返回等待的错误
+func abandon(ctx context.Context, client *clientv3.Client, key string, err error) error {
+	return errors.Join(err, deleteKey(ctx, client, key))
+}
@@ -0,0 +1,36 @@
+// Package allocator 提供 distributed 包中各节点号分配器实现共用的分配器配置
+package allocator
+
+import (
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+)
+
+const defaultTTL = 10 * time.Second
+
+// maxNode 雪花算法节点号的最大值
+var maxNode = int64(1)<<snowflake.NodeBits - 1
+
+// Options 节点号分配器配置
+type Options struct {
+	TTL       time.Duration    // 会话租约时长，实例异常退出后最长经过 TTL 节点号将被释放
+	MaxNode   int64            // 最大节点号，可分配的节点号为 [0, MaxNode]
+	OnChange  func(node int64) // 租约丢失后重新分配到不同的节点号时执行的回调
+	OnLost    func(node int64) // 租约丢失时执行的回调
+	OnReclaim func(node int64) // 租约丢失后重新申请节点号成功时执行的回调
+	OnRelease func()           // 关闭分配器释放已分配的节点号时执行的回调
+}
+
+// NewOptions 根据配置项生成节点号分配器配置
+func NewOptions[F ~func(*Options)](opts ...F) Options {
+	o := Options{
+		TTL:     defaultTTL,
+		MaxNode: maxNode,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.MaxNode = min(max(o.MaxNode, 0), maxNode)
+	return o
+}
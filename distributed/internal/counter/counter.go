@@ -0,0 +1,46 @@
+// Package counter 提供 distributed 包中各计数器实现共用的计数器配置
+package counter
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	defaultRetries = 20
+	defaultBackoff = 5 * time.Millisecond
+	maxBackoff     = 500 * time.Millisecond
+)
+
+// Options 计数器配置
+type Options struct {
+	Retries int           // CAS 冲突后的最大重试次数
+	Backoff time.Duration // 首次重试前的退避时间，此后指数增长
+}
+
+// NewOptions 根据配置项生成计数器配置
+func NewOptions[F ~func(*Options)](opts ...F) Options {
+	o := Options{
+		Retries: defaultRetries,
+		Backoff: defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Wait 第 attempt 次重试前的退避，ctx 结束时返回错误
+func (o Options) Wait(ctx context.Context, attempt int) error {
+	d := min(o.Backoff<<min(attempt, 16), maxBackoff)
+	if d > 0 {
+		d = d/2 + rand.N(d/2+1)
+	}
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,177 @@
+// Package election 提供 distributed 包中 Elector 的各实现共用的竞选配置及 leader 状态
+package election
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/haysons/gokit/log"
+)
+
+// eventBufferSize 领导权事件管道的缓冲大小，缓冲已满时丢弃最旧的事件
+const eventBufferSize = 16
+
+// CandidateInfo 竞选者信息，作为竞选值发布，follower 可据此获取 leader 的地址并转发请求
+type CandidateInfo struct {
+	ID        string            `json:"id"`                  // 竞选者 id，默认为 ip-xid
+	Host      string            `json:"host,omitempty"`      // 竞选者所在主机的 ip，默认为本机私有 ip
+	Name      string            `json:"name,omitempty"`      // 应用名称
+	Version   string            `json:"version,omitempty"`   // 应用版本
+	Endpoints []string          `json:"endpoints,omitempty"` // 应用入口点，如 grpc://10.0.0.1:9000
+	Metadata  map[string]string `json:"metadata,omitempty"`  // 其他元数据
+}
+
+// LeaderEvent 领导权变化事件
+type LeaderEvent struct {
+	Leader   string        // 当前 leader 的 id，leader 未知时为空
+	Info     CandidateInfo // 当前 leader 的信息
+	IsLeader bool          // 当前竞选者是否为 leader
+}
+
+// LeaderState 维护竞选者观察到的 leader，并在领导权变化时分发回调及事件
+type LeaderState struct {
+	id       string
+	isLeader atomic.Bool
+
+	mu      sync.Mutex
+	leader  CandidateInfo
+	ctx     context.Context    // 任期内有效的 ctx，失去领导权时取消
+	cancel  context.CancelFunc // 取消任期 ctx
+	elected []func(ctx context.Context)
+	revoked []func()
+	events  chan LeaderEvent
+	closed  bool
+}
+
+// NewLeaderState 创建 id 对应竞选者的 leader 状态
+func NewLeaderState(id string) *LeaderState {
+	return &LeaderState{
+		id:     id,
+		events: make(chan LeaderEvent, eventBufferSize),
+	}
+}
+
+// IsLeader 当前竞选者是否为 leader
+func (s *LeaderState) IsLeader() bool {
+	return s.isLeader.Load()
+}
+
+// Leader 当前 leader 的 id，leader 未知时为空
+func (s *LeaderState) Leader() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.leader.ID
+}
+
+// LeaderInfo 当前 leader 的信息，leader 未知时 ok 为 false
+func (s *LeaderState) LeaderInfo() (info CandidateInfo, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.leader, s.leader.ID != ""
+}
+
+// Set 更新当前 leader，leader 未知时传入零值，当前竞选者成为 leader 时执行 OnElected 回调，
+// 失去领导权时取消任期 ctx 并执行 OnRevoked 回调
+func (s *LeaderState) Set(leader CandidateInfo) {
+	s.mu.Lock()
+	if s.closed || leader.ID == s.leader.ID {
+		s.mu.Unlock()
+		return
+	}
+	s.leader = leader
+	was, now := s.isLeader.Load(), leader.ID != "" && leader.ID == s.id
+	s.isLeader.Store(now)
+	s.publish(LeaderEvent{Leader: leader.ID, Info: leader, IsLeader: now})
+
+	var (
+		elected []func(ctx context.Context)
+		revoked []func()
+		ctx     context.Context
+	)
+	switch {
+	case now && !was:
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+		ctx, elected = s.ctx, append(elected, s.elected...)
+	case was && !now:
+		s.cancel()
+		s.ctx, s.cancel = nil, nil
+		revoked = append(revoked, s.revoked...)
+	}
+	s.mu.Unlock()
+
+	for _, fn := range elected {
+		go fn(ctx)
+	}
+	for _, fn := range revoked {
+		fn()
+	}
+}
+
+// publish 发送领导权事件，缓冲已满时丢弃最旧的事件，需持有 mu
+func (s *LeaderState) publish(ev LeaderEvent) {
+	for {
+		select {
+		case s.events <- ev:
+			return
+		default:
+		}
+		select {
+		case <-s.events:
+		default:
+		}
+	}
+}
+
+// OnElected 注册成为 leader 时执行的回调，回调在独立的 goroutine 中执行，ctx 在失去领导权时取消，
+// 注册时已是 leader 将立即执行
+func (s *LeaderState) OnElected(fn func(ctx context.Context)) {
+	s.mu.Lock()
+	s.elected = append(s.elected, fn)
+	ctx := s.ctx
+	s.mu.Unlock()
+	if ctx != nil {
+		go fn(ctx)
+	}
+}
+
+// OnRevoked 注册失去领导权时执行的回调
+func (s *LeaderState) OnRevoked(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked = append(s.revoked, fn)
+}
+
+// Events 返回领导权变化事件的管道，管道在竞选关闭后关闭
+func (s *LeaderState) Events() <-chan LeaderEvent {
+	return s.events
+}
+
+// RunWhenLeader 成为 leader 时启动任务，失去领导权时取消任务的 ctx，任务需在 ctx 取消后尽快返回，
+// 重新成为 leader 时将在上一次任务返回后再次启动；任务在任期内自行返回后，本任期内不会重新启动
+func (s *LeaderState) RunWhenLeader(fn func(ctx context.Context) error) {
+	var mu sync.Mutex
+	s.OnElected(func(ctx context.Context) {
+		// 保证同一时刻仅有一个任务在运行
+		mu.Lock()
+		defer mu.Unlock()
+		if ctx.Err() != nil {
+			return
+		}
+		if err := fn(ctx); err != nil && ctx.Err() == nil {
+			log.GetDefaultSlog().Error("leader task failed", slog.String("id", s.id), slog.Any("error", err))
+		}
+	})
+}
+
+// Close 失去领导权并关闭事件管道，此后不再分发回调及事件
+func (s *LeaderState) Close() {
+	s.Set(CandidateInfo{})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.events)
+	}
+}
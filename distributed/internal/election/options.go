@@ -0,0 +1,42 @@
+package election
+
+import (
+	"fmt"
+	"time"
+
+	utilnet "github.com/haysons/gokit/util/net"
+	"github.com/rs/xid"
+)
+
+const (
+	defaultTTL           = 10 * time.Second
+	defaultRetryInterval = 5 * time.Second
+	defaultReadyTimeout  = 10 * time.Second
+)
+
+// Options 选举配置
+type Options struct {
+	TTL           time.Duration // 会话租约时长，leader 异常退出后最长经过 ttl 将重新选举
+	RetryInterval time.Duration // 竞选失败后重试的间隔
+	ReadyTimeout  time.Duration // Start 等待出现 leader 的最长时间
+	Candidate     CandidateInfo // 竞选者信息
+}
+
+// NewOptions 根据配置项生成选举配置，未指定时竞选者的 host 为本机私有 ip，id 为 host-xid
+func NewOptions[F ~func(*Options)](opts ...F) Options {
+	o := Options{
+		TTL:           defaultTTL,
+		RetryInterval: defaultRetryInterval,
+		ReadyTimeout:  defaultReadyTimeout,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.Candidate.Host == "" {
+		o.Candidate.Host = utilnet.GetLocalIP()
+	}
+	if o.Candidate.ID == "" {
+		o.Candidate.ID = fmt.Sprintf("%s-%s", o.Candidate.Host, xid.New().String())
+	}
+	return o
+}
@@ -0,0 +1,85 @@
+// Package lock 提供 distributed 包中 Locker 的各实现共用的锁配置及持有状态
+package lock
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultTTL 锁会话的默认租约时长
+const defaultTTL = 60 * time.Second
+
+// Options 锁配置
+type Options struct {
+	TTL       time.Duration // 会话租约时长，持有者异常退出后最长经过 TTL 锁将被释放
+	Reentrant bool          // 是否可重入
+}
+
+// NewOptions 根据配置项生成锁配置
+func NewOptions[F ~func(*Options)](opts ...F) Options {
+	o := Options{TTL: defaultTTL}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Hold 一次持有锁的状态
+type Hold struct {
+	count int           // 重入次数
+	token int64         // fencing token
+	done  chan struct{} // 锁不再被持有时关闭
+	once  sync.Once
+}
+
+// NewHold 创建持有锁的状态
+func NewHold(token int64) *Hold {
+	return &Hold{count: 1, token: token, done: make(chan struct{})}
+}
+
+// Token 本次持有锁的 fencing token
+func (h *Hold) Token() int64 {
+	return h.token
+}
+
+// Done 返回的管道在锁不再被持有时关闭
+func (h *Hold) Done() <-chan struct{} {
+	return h.done
+}
+
+// Lost 锁是否已不再被持有
+func (h *Hold) Lost() bool {
+	select {
+	case <-h.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Reenter 重入次数加1
+func (h *Hold) Reenter() {
+	h.count++
+}
+
+// Exit 重入次数减1，返回锁是否需被真正释放
+func (h *Hold) Exit() bool {
+	h.count--
+	return h.count <= 0
+}
+
+// Release 标记锁不再被持有
+func (h *Hold) Release() {
+	h.once.Do(func() { close(h.done) })
+}
+
+var closedCh = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+// ClosedCh 返回已关闭的管道，未持有锁时 Done 返回该管道
+func ClosedCh() <-chan struct{} {
+	return closedCh
+}
@@ -0,0 +1,28 @@
+// Package pubsub 提供 distributed 包中各发布订阅实现共用的发布订阅配置
+package pubsub
+
+import "time"
+
+const (
+	defaultMessageTTL      = 10 * time.Second
+	defaultSubscribeBuffer = 64
+)
+
+// Options 发布订阅配置
+type Options struct {
+	TTL    time.Duration // 消息的保留时长，订阅者断线重连后可收到保留期间错过的消息
+	Buffer int           // 订阅管道的缓冲大小
+}
+
+// NewOptions 根据配置项生成发布订阅配置
+func NewOptions[F ~func(*Options)](opts ...F) Options {
+	o := Options{
+		TTL:    defaultMessageTTL,
+		Buffer: defaultSubscribeBuffer,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.Buffer = max(o.Buffer, 0)
+	return o
+}
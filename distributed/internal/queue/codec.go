@@ -0,0 +1,32 @@
+package queue
+
+import (
+	"encoding/json"
+
+	"github.com/haysons/gokit/util/encode"
+)
+
+// Codec 队列元素的编解码器
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	// JSONCodec json 编解码器
+	JSONCodec Codec = jsonCodec{}
+	// MsgpackCodec msgpack 编解码器，编码结果更紧凑
+	MsgpackCodec Codec = msgpackCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) { return json.Marshal(v) }
+
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) { return encode.MsgpackMarshal(v) }
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error { return encode.MsgpackUnmarshal(data, v) }
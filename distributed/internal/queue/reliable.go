@@ -0,0 +1,32 @@
+// Package queue 提供 distributed 包中各队列实现共用的队列配置及编解码器
+package queue
+
+import "time"
+
+const (
+	defaultVisibilityTimeout = 30 * time.Second
+	defaultMaxDeliveries     = 5
+)
+
+// ReliableOptions 可靠队列配置
+type ReliableOptions struct {
+	VisibilityTimeout time.Duration // 可见性超时，消息出队后超过该时间未确认将重新投递
+	MaxDeliveries     int           // 最大投递次数，超过后进入死信，为0时不限制
+}
+
+// NewReliableOptions 根据配置项生成可靠队列配置
+func NewReliableOptions[F ~func(*ReliableOptions)](opts ...F) ReliableOptions {
+	o := ReliableOptions{
+		VisibilityTimeout: defaultVisibilityTimeout,
+		MaxDeliveries:     defaultMaxDeliveries,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Exhausted 消息已投递 deliveries 次后是否应进入死信
+func (o ReliableOptions) Exhausted(deliveries int) bool {
+	return o.MaxDeliveries > 0 && deliveries >= o.MaxDeliveries
+}
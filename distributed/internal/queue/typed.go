@@ -0,0 +1,48 @@
+package queue
+
+import "time"
+
+const (
+	defaultPriorityLevels = 3
+	maxPriorityLevels     = 100
+)
+
+// TypedOptions 泛型队列配置
+type TypedOptions struct {
+	Codec          Codec // 元素编解码器
+	PriorityLevels int   // 优先级数量，优先级取值范围为 [0, PriorityLevels)，值越大越先出队
+}
+
+// NewTypedOptions 根据配置项生成泛型队列配置
+func NewTypedOptions[F ~func(*TypedOptions)](opts ...F) TypedOptions {
+	o := TypedOptions{
+		Codec:          JSONCodec,
+		PriorityLevels: defaultPriorityLevels,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.PriorityLevels = min(max(o.PriorityLevels, 1), maxPriorityLevels)
+	return o
+}
+
+// EnqueueOptions 入队配置
+type EnqueueOptions struct {
+	Priority  int       // 优先级
+	VisibleAt time.Time // 元素可见的时间，此前不会出队，为零值时立即可见
+}
+
+// NewEnqueueOptions 根据配置项生成入队配置，优先级将被限制在 [0, levels) 内
+func NewEnqueueOptions[F ~func(*EnqueueOptions)](levels int, opts ...F) EnqueueOptions {
+	var o EnqueueOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.Priority = min(max(o.Priority, 0), levels-1)
+	return o
+}
+
+// Delayed 元素在当前时刻是否尚不可见
+func (o EnqueueOptions) Delayed() bool {
+	return !o.VisibleAt.IsZero() && o.VisibleAt.After(time.Now())
+}
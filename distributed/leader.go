@@ -0,0 +1,31 @@
+package distributed
+
+import (
+	"encoding/json"
+
+	"github.com/haysons/gokit/distributed/internal/election"
+)
+
+// CandidateInfo 竞选者信息，作为竞选值发布，follower 可据此获取 leader 的地址并转发请求
+type CandidateInfo = election.CandidateInfo
+
+// LeaderEvent 领导权变化事件
+type LeaderEvent = election.LeaderEvent
+
+// encodeCandidateInfo 编码为竞选值
+func encodeCandidateInfo(c CandidateInfo) string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return c.ID
+	}
+	return string(data)
+}
+
+// parseCandidateInfo 解析竞选值，兼容直接以 id 作为竞选值的旧版本竞选者
+func parseCandidateInfo(value []byte) CandidateInfo {
+	var info CandidateInfo
+	if err := json.Unmarshal(value, &info); err != nil || info.ID == "" {
+		return CandidateInfo{ID: string(value)}
+	}
+	return info
+}
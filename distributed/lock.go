@@ -3,66 +3,192 @@ package distributed
 import (
 	"context"
 	"errors"
-	"fmt"
+	"sync"
 	"time"
 
+	"github.com/haysons/gokit/distributed/internal/lock"
 	"go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/client/v3/concurrency"
 )
 
+// ErrLockNotHeld 释放未持有的锁
+var ErrLockNotHeld = errors.New("lock is not held")
+
+// LockOption 锁配置项
+type LockOption func(*lock.Options)
+
+// WithLockTTL 配置会话租约时长，默认为60s，会话存续期间租约将自动续期
+func WithLockTTL(ttl time.Duration) LockOption {
+	return func(o *lock.Options) {
+		o.TTL = ttl
+	}
+}
+
+// WithReentrant 开启可重入模式，同一锁实例可重复获取锁，释放相同次数后锁才会被真正释放，
+// 非可重入模式下重复获取已持有的锁将返回 ErrLockHeld
+func WithReentrant() LockOption {
+	return func(o *lock.Options) {
+		o.Reentrant = true
+	}
+}
+
 // Lock 分布式锁
 type Lock struct {
-	client  *clientv3.Client
+	lockKey string
+	opts    lock.Options
 	session *concurrency.Session
 	mutex   *concurrency.Mutex
-	lockKey string
+
+	opMu sync.Mutex // 串行化获取及释放锁的操作
+	mu   sync.Mutex
+	hold *lock.Hold // 当前持有锁的状态，未持有时为 nil
 }
 
-// NewLock 创建分布式锁实例
-func NewLock(client *clientv3.Client, lockKey string) (*Lock, error) {
-	session, err := concurrency.NewSession(client, concurrency.WithTTL(60))
+// NewLock 创建分布式锁实例，会话失效后锁实例不可再使用，需重新创建
+func NewLock(client *clientv3.Client, lockKey string, opts ...LockOption) (*Lock, error) {
+	o := lock.NewOptions(opts...)
+	session, err := newSession(context.Background(), client, o.TTL)
 	if err != nil {
-		return nil, fmt.Errorf("create session failed: %w", err)
+		return nil, err
 	}
-
 	return &Lock{
-		client:  client,
+		lockKey: lockKey,
+		opts:    o,
 		session: session,
 		mutex:   concurrency.NewMutex(session, lockKey),
-		lockKey: lockKey,
 	}, nil
 }
 
-// Lock 尝试获取分布式锁，支持超时
+// Lock 获取分布式锁，超过 timeout 仍未获取时返回错误，获取成功后可通过 Token 获取 fencing token
 func (l *Lock) Lock(ctx context.Context, timeout time.Duration) error {
+	l.opMu.Lock()
+	defer l.opMu.Unlock()
+	if held, err := l.reenter(); held || err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
-
-	return l.mutex.Lock(ctx)
+	if err := l.mutex.Lock(ctx); err != nil {
+		return err
+	}
+	return l.acquired(ctx)
 }
 
-// TryLock 尝试获取分布式锁，立即返回结果
+// TryLock 尝试获取分布式锁，立即返回结果，锁被其他实例持有时返回 false
 func (l *Lock) TryLock(ctx context.Context) (bool, error) {
-	ctx, cancel := context.WithTimeout(ctx, time.Second)
-	defer cancel()
+	l.opMu.Lock()
+	defer l.opMu.Unlock()
+	if held, err := l.reenter(); held || err != nil {
+		return held, err
+	}
 
-	err := l.mutex.Lock(ctx)
-	if errors.Is(err, context.DeadlineExceeded) {
+	err := l.mutex.TryLock(ctx)
+	if errors.Is(err, concurrency.ErrLocked) {
 		return false, nil
 	}
 	if err != nil {
 		return false, err
 	}
+	if err = l.acquired(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
 
+// reenter 已持有锁时，可重入模式下增加重入次数，非可重入模式下返回 ErrLockHeld，返回是否已持有锁，需持有 opMu
+func (l *Lock) reenter() (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.hold == nil || l.hold.Lost() {
+		return false, nil
+	}
+	if !l.opts.Reentrant {
+		return false, ErrLockHeld
+	}
+	l.hold.Reenter()
 	return true, nil
 }
 
-// Unlock 释放分布式锁
+// acquired 记录持有锁的状态，并在会话失效时标记锁已丢失，需持有 opMu
+func (l *Lock) acquired(ctx context.Context) error {
+	// 锁的 key 在获取锁时创建，其创建版本号随每次获取锁全局单调递增，可作为 fencing token
+	resp, err := l.session.Client().Get(ctx, l.mutex.Key())
+	if err == nil && len(resp.Kvs) == 0 {
+		err = ErrLockNotHeld
+	}
+	if err != nil {
+		// 无法确定 fencing token 时释放锁，避免持有锁却无法安全写入
+		_ = l.mutex.Unlock(context.WithoutCancel(ctx))
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	hold := lock.NewHold(resp.Kvs[0].CreateRevision)
+	l.hold = hold
+	sessionDone := l.session.Done()
+	go func() {
+		select {
+		case <-sessionDone:
+			hold.Release()
+		case <-hold.Done():
+		}
+	}()
+	return nil
+}
+
+// Token 返回当前持有锁的 fencing token，其随每次获取锁单调递增，未持有锁时返回0。
+// 写入受锁保护的资源时携带 token，资源方拒绝小于已见 token 的写入，可避免锁过期后旧持有者的写入覆盖新持有者
+func (l *Lock) Token() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.hold == nil {
+		return 0
+	}
+	return l.hold.Token()
+}
+
+// Done 返回的管道在锁不再被持有时关闭，如会话失效导致锁丢失或锁被释放，未持有锁时返回已关闭的管道
+func (l *Lock) Done() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.hold == nil {
+		return lock.ClosedCh()
+	}
+	return l.hold.Done()
+}
+
+// Unlock 释放分布式锁，可重入模式下释放次数与获取次数相同时锁才会被真正释放
 func (l *Lock) Unlock(ctx context.Context) error {
+	l.opMu.Lock()
+	defer l.opMu.Unlock()
+	l.mu.Lock()
+	hold := l.hold
+	if hold == nil {
+		l.mu.Unlock()
+		return ErrLockNotHeld
+	}
+	if !hold.Exit() {
+		l.mu.Unlock()
+		return nil
+	}
+	l.hold = nil
+	l.mu.Unlock()
+
+	hold.Release()
 	return l.mutex.Unlock(ctx)
 }
 
-// Close 关闭锁实例，清理资源
+// Close 关闭锁实例，释放锁并清理资源
 func (l *Lock) Close() error {
+	l.opMu.Lock()
+	defer l.opMu.Unlock()
+	l.mu.Lock()
+	if l.hold != nil {
+		l.hold.Release()
+		l.hold = nil
+	}
+	l.mu.Unlock()
 	return l.session.Close()
 }
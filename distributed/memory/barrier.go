@@ -0,0 +1,176 @@
+package memory
+
+import (
+	"context"
+	"errors"
+
+	"github.com/haysons/gokit/distributed"
+)
+
+var (
+	_ distributed.Barrier       = (*Barrier)(nil)
+	_ distributed.DoubleBarrier = (*DoubleBarrier)(nil)
+)
+
+// barrierEntry 屏障的共享状态
+type barrierEntry struct {
+	held     bool          // 屏障是否被设置
+	released chan struct{} // 屏障释放时关闭并替换
+	ready    chan struct{} // 双屏障参与者数量达到要求时关闭并替换
+}
+
+// barrier 返回 key 对应的屏障状态，需持有 Backend.mu
+func (b *Backend) barrier(key string) *barrierEntry {
+	e, ok := b.barriers[key]
+	if !ok {
+		e = &barrierEntry{released: make(chan struct{}), ready: make(chan struct{})}
+		b.barriers[key] = e
+	}
+	return e
+}
+
+// Barrier 内存分布式屏障
+type Barrier struct {
+	b   *Backend
+	key string
+}
+
+// NewBarrier 创建内存分布式屏障
+func (b *Backend) NewBarrier(key string) *Barrier {
+	return &Barrier{b: b, key: key}
+}
+
+// Hold 设置屏障，屏障已被设置时返回 ErrBarrierHeld
+func (br *Barrier) Hold(_ context.Context) error {
+	br.b.mu.Lock()
+	defer br.b.mu.Unlock()
+	e := br.b.barrier(br.key)
+	if e.held {
+		return distributed.ErrBarrierHeld
+	}
+	e.held = true
+	return nil
+}
+
+// Release 释放屏障，唤醒全部等待者
+func (br *Barrier) Release(_ context.Context) error {
+	br.b.mu.Lock()
+	defer br.b.mu.Unlock()
+	e := br.b.barrier(br.key)
+	if e.held {
+		e.held = false
+		close(e.released)
+		e.released = make(chan struct{})
+	}
+	return nil
+}
+
+// Wait 阻塞直至屏障被释放或 ctx 结束，屏障未设置时立即返回
+func (br *Barrier) Wait(ctx context.Context) error {
+	br.b.mu.Lock()
+	e := br.b.barrier(br.key)
+	held, released := e.held, e.released
+	br.b.mu.Unlock()
+	if !held {
+		return nil
+	}
+	select {
+	case <-released:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// DoubleBarrier 内存分布式双屏障
+type DoubleBarrier struct {
+	b       *Backend
+	key     string
+	count   int
+	sess    *session
+	entered *ticket // 已进入屏障的凭证，未进入时为 nil
+}
+
+// NewDoubleBarrier 创建内存分布式双屏障，同一 key 的全部实例需使用相同的 count
+func (b *Backend) NewDoubleBarrier(key string, count int) (*DoubleBarrier, error) {
+	if count <= 0 {
+		return nil, errors.New("memory: barrier count must be positive")
+	}
+	return &DoubleBarrier{b: b, key: key, count: count, sess: &session{}}, nil
+}
+
+// Enter 进入屏障，阻塞直至 count 个参与者均已进入或 ctx 结束，ctx 结束时将退出屏障
+func (d *DoubleBarrier) Enter(ctx context.Context) error {
+	d.b.mu.Lock()
+	if d.sess.err != nil {
+		d.b.mu.Unlock()
+		return d.sess.err
+	}
+	if d.entered != nil {
+		d.b.mu.Unlock()
+		return distributed.ErrBarrierEntered
+	}
+	q := d.b.ticketQueue(d.key + "/waiters/")
+	e := d.b.barrier(d.key)
+	t := &ticket{}
+	q.push(t)
+	if len(q.tickets) >= d.count {
+		// 最后进入的参与者唤醒其他参与者
+		close(e.ready)
+		e.ready = make(chan struct{})
+		d.entered = t
+		d.b.mu.Unlock()
+		return nil
+	}
+	ready := e.ready
+	d.b.mu.Unlock()
+
+	select {
+	case <-ready:
+		d.b.mu.Lock()
+		d.entered = t
+		d.b.mu.Unlock()
+		return nil
+	case <-ctx.Done():
+		d.b.mu.Lock()
+		q.remove(t)
+		d.b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Leave 离开屏障，阻塞直至全部参与者均已离开或 ctx 结束
+func (d *DoubleBarrier) Leave(ctx context.Context) error {
+	d.b.mu.Lock()
+	if d.entered == nil {
+		d.b.mu.Unlock()
+		return distributed.ErrBarrierNotEntered
+	}
+	q := d.b.ticketQueue(d.key + "/waiters/")
+	q.remove(d.entered)
+	d.entered = nil
+	for len(q.tickets) > 0 {
+		changed := q.changed
+		d.b.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		d.b.mu.Lock()
+	}
+	d.b.mu.Unlock()
+	return nil
+}
+
+// Close 退出屏障并关闭会话
+func (d *DoubleBarrier) Close() error {
+	d.b.mu.Lock()
+	defer d.b.mu.Unlock()
+	if d.entered != nil {
+		d.b.ticketQueue(d.key + "/waiters/").remove(d.entered)
+		d.entered = nil
+	}
+	d.sess.close()
+	return nil
+}
@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"runtime"
+
+	"github.com/haysons/gokit/distributed"
+	"github.com/haysons/gokit/distributed/internal/counter"
+)
+
+var _ distributed.AtomicCounter = (*Counter)(nil)
+
+// Counter 内存分布式计数器，与 etcd 实现一致以 CAS 方式更新，并发修改冲突时退避重试，重试次数耗尽后返回 distributed.ErrConflict
+type Counter struct {
+	b    *Backend
+	key  string
+	opts counter.Options
+}
+
+// counterValue 计数值及其修改版本号，对应 etcd 中 key 的 value 及 mod revision
+type counterValue struct {
+	value int64
+	rev   int64
+}
+
+// NewCounter 创建内存分布式计数器
+func (b *Backend) NewCounter(key string, opts ...distributed.CounterOption) *Counter {
+	return &Counter{b: b, key: key, opts: counter.NewOptions(opts...)}
+}
+
+// NewShardedCounter 创建内存分片计数器，内存实现不存在写入热点，语义与单个 key 的计数器一致
+func (b *Backend) NewShardedCounter(key string, shards int) (*Counter, error) {
+	if shards <= 0 {
+		return nil, errors.New("memory: counter shards must be positive")
+	}
+	return &Counter{b: b, key: key + "/", opts: counter.NewOptions[distributed.CounterOption]()}, nil
+}
+
+// Get 获取当前计数值，不存在时返回0
+func (c *Counter) Get(ctx context.Context) (int64, error) {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+	return c.b.counters[c.key].value, nil
+}
+
+// Incr 原子加1，返回新值
+func (c *Counter) Incr(ctx context.Context) (int64, error) {
+	return c.IncrBy(ctx, 1)
+}
+
+// IncrBy 原子增加 delta，返回新值，并发冲突且重试次数耗尽时返回 distributed.ErrConflict
+func (c *Counter) IncrBy(ctx context.Context, delta int64) (int64, error) {
+	for attempt := 0; ; attempt++ {
+		c.b.mu.Lock()
+		cur := c.b.counters[c.key]
+		c.b.mu.Unlock()
+		// 与 etcd 实现一致，读取与写入之间存在间隙，其间的并发修改将导致冲突
+		runtime.Gosched()
+		if newVal, ok := c.swap(cur, cur.value+delta); ok {
+			return newVal, nil
+		}
+
+		if attempt >= c.opts.Retries {
+			return 0, distributed.ErrConflict
+		}
+		if err := c.opts.Wait(ctx, attempt); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// swap 计数值的修改版本号未变时写入新值
+func (c *Counter) swap(cur counterValue, value int64) (int64, bool) {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+	if c.b.counters[c.key].rev != cur.rev {
+		return 0, false
+	}
+	c.b.setCounter(c.key, value)
+	return value, true
+}
+
+// setCounter 写入计数值并更新修改版本号，需持有 Backend.mu
+func (b *Backend) setCounter(key string, value int64) {
+	b.rev++
+	b.counters[key] = counterValue{value: value, rev: b.rev}
+}
+
+// Decr 原子减1，返回新值
+func (c *Counter) Decr(ctx context.Context) (int64, error) {
+	return c.DecrBy(ctx, 1)
+}
+
+// DecrBy 原子减少 delta，返回新值
+func (c *Counter) DecrBy(ctx context.Context, delta int64) (int64, error) {
+	return c.IncrBy(ctx, -delta)
+}
+
+// Set 设置计数值
+func (c *Counter) Set(ctx context.Context, value int64) error {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+	c.b.setCounter(c.key, value)
+	return nil
+}
+
+// CompareAndSet 当前计数值等于 old 时设置为 value，返回是否设置成功，计数器不存在时视为0
+func (c *Counter) CompareAndSet(ctx context.Context, old, value int64) (bool, error) {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+	if c.b.counters[c.key].value != old {
+		return false, nil
+	}
+	c.b.setCounter(c.key, value)
+	return true, nil
+}
+
+// GetAndReset 原子地获取当前计数值并重置为0
+func (c *Counter) GetAndReset(ctx context.Context) (int64, error) {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+	value := c.b.counters[c.key].value
+	delete(c.b.counters, c.key)
+	return value, nil
+}
+
+// Reset 重置计数值为0
+func (c *Counter) Reset(ctx context.Context) error {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+	delete(c.b.counters, c.key)
+	return nil
+}
@@ -0,0 +1,203 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/haysons/gokit/distributed"
+	"github.com/haysons/gokit/distributed/internal/election"
+)
+
+var _ distributed.Elector = (*Election)(nil)
+
+// Election 内存领导者选举，先发起竞选者先成为 leader，leader 退出或会话过期后由下一个竞选者接任
+type Election struct {
+	b       *Backend
+	id      string
+	info    distributed.CandidateInfo
+	key     string
+	sess    *session
+	state   *election.LeaderState
+	started bool
+}
+
+type electionEntry struct {
+	candidates []*Election // 按竞选顺序排列，首个为 leader
+}
+
+// remove 移除竞选者，需持有 Backend.mu
+func (e *electionEntry) remove(el *Election) {
+	e.candidates = slices.DeleteFunc(e.candidates, func(c *Election) bool { return c == el })
+}
+
+// leader 当前 leader 的信息，需持有 Backend.mu
+func (e *electionEntry) leader() distributed.CandidateInfo {
+	if len(e.candidates) == 0 {
+		return distributed.CandidateInfo{}
+	}
+	return e.candidates[0].info
+}
+
+// NewElection 创建内存领导者选举，配置项中仅竞选者信息及会话租约时长生效
+func (b *Backend) NewElection(elKey string, opts ...distributed.ElectionOption) *Election {
+	o := election.NewOptions(opts...)
+	return &Election{
+		b:     b,
+		id:    o.Candidate.ID,
+		info:  o.Candidate,
+		key:   elKey,
+		sess:  newSession(o.TTL),
+		state: election.NewLeaderState(o.Candidate.ID),
+	}
+}
+
+func (b *Backend) electionEntry(key string) *electionEntry {
+	e, ok := b.elections[key]
+	if !ok {
+		e = &electionEntry{}
+		b.elections[key] = e
+	}
+	return e
+}
+
+// notifyLeader 通知全部竞选者当前的 leader，需在释放 Backend.mu 后调用，避免回调中再次加锁导致死锁
+func notifyLeader(candidates []*Election, leader distributed.CandidateInfo) {
+	for _, c := range candidates {
+		c.state.Set(leader)
+	}
+}
+
+// snapshot 获取竞选者列表及当前 leader，需持有 Backend.mu
+func (e *electionEntry) snapshot() ([]*Election, distributed.CandidateInfo) {
+	return slices.Clone(e.candidates), e.leader()
+}
+
+// ID 当前竞选者的 id
+func (el *Election) ID() string {
+	return el.id
+}
+
+// IsLeader 当前竞选者是否为 leader
+func (el *Election) IsLeader() bool {
+	return el.state.IsLeader()
+}
+
+// Leader 当前 leader 的 id，leader 未知时为空
+func (el *Election) Leader() string {
+	return el.state.Leader()
+}
+
+// Candidate 当前竞选者的信息
+func (el *Election) Candidate() distributed.CandidateInfo {
+	return el.info
+}
+
+// LeaderInfo 当前 leader 的信息，leader 未知时 ok 为 false
+func (el *Election) LeaderInfo() (info distributed.CandidateInfo, ok bool) {
+	return el.state.LeaderInfo()
+}
+
+// OnElected 注册成为 leader 时执行的回调，回调在独立的 goroutine 中执行，ctx 在失去领导权时取消
+func (el *Election) OnElected(fn func(ctx context.Context)) {
+	el.state.OnElected(fn)
+}
+
+// OnRevoked 注册失去领导权时执行的回调
+func (el *Election) OnRevoked(fn func()) {
+	el.state.OnRevoked(fn)
+}
+
+// Events 返回领导权变化事件的管道
+func (el *Election) Events() <-chan distributed.LeaderEvent {
+	return el.state.Events()
+}
+
+// RunWhenLeader 成为 leader 时启动任务，失去领导权时取消任务的 ctx
+func (el *Election) RunWhenLeader(fn func(ctx context.Context) error) {
+	el.state.RunWhenLeader(fn)
+}
+
+// Start 发起竞选，内存实现中 leader 立即确定
+func (el *Election) Start(ctx context.Context) error {
+	el.b.mu.Lock()
+	if el.sess.err != nil {
+		el.b.mu.Unlock()
+		return el.sess.err
+	}
+	if el.started {
+		el.b.mu.Unlock()
+		return nil
+	}
+	e := el.b.electionEntry(el.key)
+	e.candidates = append(e.candidates, el)
+	el.started = true
+	candidates, leader := e.snapshot()
+	el.b.mu.Unlock()
+
+	notifyLeader(candidates, leader)
+	return nil
+}
+
+// Resign 当前竞选者为 leader 时主动卸任，并排至竞选队列末尾
+func (el *Election) Resign(ctx context.Context) error {
+	el.b.mu.Lock()
+	e := el.b.electionEntry(el.key)
+	if e.leader().ID != el.id {
+		el.b.mu.Unlock()
+		return nil
+	}
+	e.remove(el)
+	e.candidates = append(e.candidates, el)
+	candidates, leader := e.snapshot()
+	el.b.mu.Unlock()
+
+	notifyLeader(candidates, leader)
+	return nil
+}
+
+// expire 模拟 leader 会话过期：失去领导权，并以新会话重新参与竞选
+func (el *Election) expire() {
+	el.b.mu.Lock()
+	e := el.b.electionEntry(el.key)
+	if el.sess.err != nil || len(e.candidates) == 0 || e.candidates[0] != el {
+		el.b.mu.Unlock()
+		return
+	}
+	e.remove(el)
+	e.candidates = append(e.candidates, el)
+	el.sess.renew()
+	candidates, leader := e.snapshot()
+	el.b.mu.Unlock()
+
+	notifyLeader(candidates, leader)
+}
+
+// Status 返回选举状态，选举已就绪且会话正常时返回 nil
+func (el *Election) Status() error {
+	el.b.mu.Lock()
+	defer el.b.mu.Unlock()
+	switch {
+	case errors.Is(el.sess.err, ErrSessionClosed):
+		return errors.New("election closed")
+	case !el.started:
+		return errors.New("election not ready")
+	case el.sess.err != nil:
+		return errors.New("election session expired")
+	}
+	return nil
+}
+
+// Close 退出竞选，当前竞选者为 leader 时立刻结束任期
+func (el *Election) Close() error {
+	el.b.mu.Lock()
+	e := el.b.electionEntry(el.key)
+	e.remove(el)
+	el.sess.close()
+	candidates, leader := e.snapshot()
+	el.b.mu.Unlock()
+
+	el.state.Close()
+	notifyLeader(candidates, leader)
+	return nil
+}
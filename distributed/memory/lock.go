@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/haysons/gokit/distributed"
+	"github.com/haysons/gokit/distributed/internal/lock"
+)
+
+var _ distributed.Locker = (*Lock)(nil)
+
+// Lock 内存分布式锁
+type Lock struct {
+	b    *Backend
+	key  string
+	opts lock.Options
+	sess *session
+	hold *lock.Hold // 当前持有锁的状态，锁因会话过期被释放后仍保留至下次操作
+}
+
+type lockEntry struct {
+	owner   *Lock
+	changed chan struct{} // 锁释放时关闭并替换
+}
+
+// release 释放锁并唤醒等待者，需持有 Backend.mu
+func (e *lockEntry) release() {
+	e.owner = nil
+	close(e.changed)
+	e.changed = make(chan struct{})
+}
+
+// NewLock 创建内存分布式锁，会话过期后锁实例不可再使用
+func (b *Backend) NewLock(lockKey string, opts ...distributed.LockOption) (*Lock, error) {
+	o := lock.NewOptions(opts...)
+	return &Lock{b: b, key: lockKey, opts: o, sess: newSession(o.TTL)}, nil
+}
+
+func (b *Backend) lockEntry(key string) *lockEntry {
+	e, ok := b.locks[key]
+	if !ok {
+		e = &lockEntry{changed: make(chan struct{})}
+		b.locks[key] = e
+	}
+	return e
+}
+
+// Lock 获取锁，超过 timeout 仍未获取时返回错误
+func (l *Lock) Lock(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		acquired, changed, err := l.tryAcquire()
+		if err != nil || acquired {
+			return err
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// TryLock 尝试获取锁，立即返回结果，锁被其他实例持有时返回 false
+func (l *Lock) TryLock(ctx context.Context) (bool, error) {
+	acquired, _, err := l.tryAcquire()
+	return acquired, err
+}
+
+func (l *Lock) tryAcquire() (bool, <-chan struct{}, error) {
+	l.b.mu.Lock()
+	defer l.b.mu.Unlock()
+	if l.hold != nil && !l.hold.Lost() {
+		if !l.opts.Reentrant {
+			return false, nil, distributed.ErrLockHeld
+		}
+		l.hold.Reenter()
+		return true, nil, nil
+	}
+	if l.sess.err != nil {
+		return false, nil, l.sess.err
+	}
+	e := l.b.lockEntry(l.key)
+	if e.owner == nil {
+		e.owner = l
+		l.b.rev++
+		l.hold = lock.NewHold(l.b.rev)
+		return true, nil, nil
+	}
+	return false, e.changed, nil
+}
+
+// Token 当前持有锁的 fencing token，随每次获取锁单调递增，未持有锁时返回0
+func (l *Lock) Token() int64 {
+	l.b.mu.Lock()
+	defer l.b.mu.Unlock()
+	if l.hold == nil {
+		return 0
+	}
+	return l.hold.Token()
+}
+
+// Done 返回的管道在锁不再被持有时关闭，未持有锁时返回已关闭的管道
+func (l *Lock) Done() <-chan struct{} {
+	l.b.mu.Lock()
+	defer l.b.mu.Unlock()
+	if l.hold == nil {
+		return lock.ClosedCh()
+	}
+	return l.hold.Done()
+}
+
+// Unlock 释放锁，可重入模式下释放次数与获取次数相同时锁才会被真正释放
+func (l *Lock) Unlock(ctx context.Context) error {
+	l.b.mu.Lock()
+	defer l.b.mu.Unlock()
+	if l.hold == nil {
+		return distributed.ErrLockNotHeld
+	}
+	if !l.hold.Exit() {
+		return nil
+	}
+	l.releaseLocked()
+	return nil
+}
+
+// releaseLocked 释放锁并标记不再持有，需持有 Backend.mu
+func (l *Lock) releaseLocked() {
+	if l.hold != nil {
+		l.hold.Release()
+		l.hold = nil
+	}
+	if e := l.b.lockEntry(l.key); e.owner == l {
+		e.release()
+	}
+}
+
+// expire 会话租约过期，锁被释放，Done 管道关闭且锁实例此后不可再使用
+func (l *Lock) expire() {
+	l.b.mu.Lock()
+	defer l.b.mu.Unlock()
+	if l.sess.err != nil {
+		return
+	}
+	l.sess.expire()
+	if l.hold != nil {
+		l.hold.Release()
+	}
+	if e := l.b.lockEntry(l.key); e.owner == l {
+		e.release()
+	}
+}
+
+// Close 释放锁及会话
+func (l *Lock) Close() error {
+	l.b.mu.Lock()
+	defer l.b.mu.Unlock()
+	l.releaseLocked()
+	l.sess.close()
+	return nil
+}
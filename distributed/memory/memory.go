@@ -0,0 +1,152 @@
+package memory
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrSessionExpired 会话已过期（对应 etcd 租约过期），原语不可再使用
+	ErrSessionExpired = errors.New("memory: session expired")
+	// ErrSessionClosed 会话已关闭
+	ErrSessionClosed = errors.New("memory: session closed")
+)
+
+// Backend 分布式原语的内存后端，所创建原语的语义与基于 etcd 的实现一致，主要用于单元测试，
+// 同一 Backend 创建的原语之间共享状态，相当于连接至同一 etcd 集群
+type Backend struct {
+	mu             sync.Mutex
+	locks          map[string]*lockEntry
+	tickets        map[string]*ticketQueue
+	barriers       map[string]*barrierEntry
+	elections      map[string]*electionEntry
+	queues         map[string]*queueEntry
+	reliableQueues map[string]*reliableEntry
+	typedQueues    map[string]*typedEntry
+	counters       map[string]counterValue
+	windows        map[string]*windowEntry
+	nodes          map[string]*nodeEntry
+	topics         map[string]map[*subscriber]struct{}
+	rev            int64 // 全局修订号，对应 etcd 的 revision，用于生成 fencing token
+}
+
+// NewBackend 创建内存后端
+func NewBackend() *Backend {
+	return &Backend{
+		locks:          make(map[string]*lockEntry),
+		tickets:        make(map[string]*ticketQueue),
+		barriers:       make(map[string]*barrierEntry),
+		elections:      make(map[string]*electionEntry),
+		queues:         make(map[string]*queueEntry),
+		reliableQueues: make(map[string]*reliableEntry),
+		typedQueues:    make(map[string]*typedEntry),
+		counters:       make(map[string]counterValue),
+		windows:        make(map[string]*windowEntry),
+		nodes:          make(map[string]*nodeEntry),
+		topics:         make(map[string]map[*subscriber]struct{}),
+	}
+}
+
+// ExpireHolder 模拟 key 上锁的持有者或 leader 的会话租约立即过期，
+// 锁将被释放，持有者的 Done 管道关闭且此后不可再使用；leader 将被撤销，随后与 etcd 实现一致以新会话重新参与竞选；
+// 最早申请节点号的分配器将以新会话重新申请节点号
+func (b *Backend) ExpireHolder(key string) {
+	b.mu.Lock()
+	lock, leader, node := b.holders(key)
+	b.mu.Unlock()
+
+	if lock != nil {
+		lock.expire()
+	}
+	if leader != nil {
+		leader.expire()
+	}
+	if node != nil {
+		node.expire()
+	}
+}
+
+// SuspendHolder 模拟 key 上锁的持有者、leader 或最早申请节点号的分配器的进程假死（如长时间 GC 停顿、网络分区），
+// 其会话停止续期，租约在各自配置的 TTL 到期后过期，过期后的表现与 ExpireHolder 一致
+func (b *Backend) SuspendHolder(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	lock, leader, node := b.holders(key)
+	if lock != nil {
+		lock.sess.suspend(lock.expire)
+	}
+	if leader != nil {
+		leader.sess.suspend(leader.expire)
+	}
+	if node != nil {
+		node.sess.suspend(node.expire)
+	}
+}
+
+// holders 返回 key 上锁的持有者、leader 及最早申请节点号的分配器，不存在时为 nil，需持有 Backend.mu
+func (b *Backend) holders(key string) (*Lock, *Election, *NodeAllocator) {
+	var (
+		lock   *Lock
+		leader *Election
+		node   *NodeAllocator
+	)
+	if e, ok := b.locks[key]; ok {
+		lock = e.owner
+	}
+	if e, ok := b.elections[key]; ok && len(e.candidates) > 0 {
+		leader = e.candidates[0]
+	}
+	if e, ok := b.nodes[key]; ok {
+		node = e.earliest()
+	}
+	return lock, leader, node
+}
+
+// session 模拟 etcd 会话，会话存续期间租约自动续期，停止续期后租约在 ttl 到期时过期，
+// 过期或关闭后绑定的原语不可再使用，字段均由 Backend.mu 保护
+type session struct {
+	ttl   time.Duration
+	err   error
+	timer *time.Timer // 停止续期后租约到期的定时器，续期期间为 nil
+}
+
+func newSession(ttl time.Duration) *session {
+	return &session{ttl: ttl}
+}
+
+// suspend 停止续期，租约在 ttl 到期后执行 onExpire，onExpire 执行时未持有 Backend.mu，
+// 会话已失效、已停止续期或未配置租约时长时不做处理
+func (s *session) suspend(onExpire func()) {
+	if s.err != nil || s.timer != nil || s.ttl <= 0 {
+		return
+	}
+	s.timer = time.AfterFunc(s.ttl, onExpire)
+}
+
+// renew 以新会话替换已过期的会话，租约恢复续期
+func (s *session) renew() {
+	s.stop()
+	s.err = nil
+}
+
+func (s *session) stop() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+}
+
+func (s *session) expire() {
+	s.stop()
+	if s.err == nil {
+		s.err = ErrSessionExpired
+	}
+}
+
+func (s *session) close() {
+	s.stop()
+	if s.err == nil {
+		s.err = ErrSessionClosed
+	}
+}
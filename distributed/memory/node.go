@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/haysons/gokit/distributed"
+	"github.com/haysons/gokit/distributed/internal/allocator"
+)
+
+var _ distributed.NodeAllocator = (*NodeAllocator)(nil)
+
+// nodeEntry 节点号的分配情况
+type nodeEntry struct {
+	holders map[int64]*NodeAllocator // 节点号 -> 持有者
+}
+
+// nodeEntry 返回 prefix 对应的节点号分配情况，需持有 Backend.mu
+func (b *Backend) nodeEntry(prefix string) *nodeEntry {
+	e, ok := b.nodes[prefix]
+	if !ok {
+		e = &nodeEntry{holders: make(map[int64]*NodeAllocator)}
+		b.nodes[prefix] = e
+	}
+	return e
+}
+
+// earliest 返回最早申请节点号的持有者，不存在时返回 nil
+func (e *nodeEntry) earliest() *NodeAllocator {
+	var first *NodeAllocator
+	for _, a := range e.holders {
+		if first == nil || a.rev < first.rev {
+			first = a
+		}
+	}
+	return first
+}
+
+// NodeAllocator 内存雪花算法节点号分配器，自0起依次分配空闲的节点号
+type NodeAllocator struct {
+	b      *Backend
+	prefix string
+	opts   allocator.Options
+	closed bool
+	sess   *session
+	node   int64 // 当前分配的节点号，未分配时为-1
+	rev    int64 // 申请节点号时的修订号，对应 etcd 中节点号 key 的创建版本
+}
+
+// NewNodeAllocator 创建内存节点号分配器
+func (b *Backend) NewNodeAllocator(prefix string, opts ...distributed.NodeAllocatorOption) *NodeAllocator {
+	o := allocator.NewOptions(opts...)
+	return &NodeAllocator{
+		b:      b,
+		prefix: prefix,
+		opts:   o,
+		sess:   newSession(o.TTL),
+		node:   -1,
+	}
+}
+
+// Allocate 分配空闲的节点号，已分配时返回当前节点号，全部节点号均已被分配时返回 ErrNoFreeNode
+func (a *NodeAllocator) Allocate(_ context.Context) (int64, error) {
+	a.b.mu.Lock()
+	defer a.b.mu.Unlock()
+	if a.closed {
+		return -1, distributed.ErrAllocatorClosed
+	}
+	if a.node >= 0 {
+		return a.node, nil
+	}
+	e := a.b.nodeEntry(a.prefix)
+	for node := int64(0); node <= a.opts.MaxNode; node++ {
+		if _, ok := e.holders[node]; ok {
+			continue
+		}
+		a.b.rev++
+		a.node, a.rev = node, a.b.rev
+		e.holders[node] = a
+		return node, nil
+	}
+	return -1, distributed.ErrNoFreeNode
+}
+
+// Node 当前分配的节点号，未分配时返回-1
+func (a *NodeAllocator) Node() int64 {
+	a.b.mu.Lock()
+	defer a.b.mu.Unlock()
+	return a.node
+}
+
+// Close 关闭分配器并释放节点号
+func (a *NodeAllocator) Close() error {
+	a.b.mu.Lock()
+	allocated := a.node >= 0
+	if allocated {
+		delete(a.b.nodeEntry(a.prefix).holders, a.node)
+	}
+	a.closed = true
+	a.node = -1
+	a.sess.close()
+	a.b.mu.Unlock()
+
+	if allocated && a.opts.OnRelease != nil {
+		a.opts.OnRelease()
+	}
+	return nil
+}
+
+// expire 模拟持有者的会话租约过期，与 etcd 实现一致以新会话重新申请节点号，
+// 原节点号刚被释放，因此总能重新申请到原节点号，回调在释放锁后执行
+func (a *NodeAllocator) expire() {
+	a.b.mu.Lock()
+	if a.closed || a.node < 0 {
+		a.b.mu.Unlock()
+		return
+	}
+	node := a.node
+	a.b.rev++
+	a.rev = a.b.rev
+	a.sess.renew()
+	a.b.mu.Unlock()
+
+	if a.opts.OnLost != nil {
+		a.opts.OnLost(node)
+	}
+	if a.opts.OnReclaim != nil {
+		a.opts.OnReclaim(node)
+	}
+}
@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/haysons/gokit/distributed"
+	"github.com/haysons/gokit/distributed/internal/pubsub"
+)
+
+var _ distributed.PubSub = (*PubSub)(nil)
+
+// subscriber 订阅者，发布的消息先追加至 pending，再由订阅者自身的协程投递至管道，避免发布方被阻塞
+type subscriber struct {
+	pending []*distributed.TopicMessage
+	notify  chan struct{} // 有新消息时写入
+}
+
+// PubSub 内存分布式发布订阅
+type PubSub struct {
+	b      *Backend
+	prefix string
+	opts   pubsub.Options
+}
+
+// NewPubSub 创建内存分布式发布订阅
+func (b *Backend) NewPubSub(prefix string, opts ...distributed.PubSubOption) *PubSub {
+	return &PubSub{b: b, prefix: prefix, opts: pubsub.NewOptions(opts...)}
+}
+
+func (p *PubSub) topicKey(topic string) string {
+	return p.prefix + "/" + topic
+}
+
+// Publish 向 topic 发布消息，当前的全部订阅者均会收到该消息
+func (p *PubSub) Publish(_ context.Context, topic string, payload []byte) error {
+	p.b.mu.Lock()
+	defer p.b.mu.Unlock()
+	p.b.rev++
+	for s := range p.b.topics[p.topicKey(topic)] {
+		s.pending = append(s.pending, &distributed.TopicMessage{
+			Topic:    topic,
+			Payload:  append([]byte(nil), payload...),
+			Revision: p.b.rev,
+		})
+		select {
+		case s.notify <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Subscribe 订阅 topic 此后发布的消息，返回的管道在 ctx 结束后关闭
+func (p *PubSub) Subscribe(ctx context.Context, topic string) (<-chan *distributed.TopicMessage, error) {
+	key := p.topicKey(topic)
+	s := &subscriber{notify: make(chan struct{}, 1)}
+	p.b.mu.Lock()
+	if p.b.topics[key] == nil {
+		p.b.topics[key] = make(map[*subscriber]struct{})
+	}
+	p.b.topics[key][s] = struct{}{}
+	p.b.mu.Unlock()
+
+	ch := make(chan *distributed.TopicMessage, p.opts.Buffer)
+	go func() {
+		defer close(ch)
+		defer func() {
+			p.b.mu.Lock()
+			delete(p.b.topics[key], s)
+			p.b.mu.Unlock()
+		}()
+		for {
+			select {
+			case <-s.notify:
+			case <-ctx.Done():
+				return
+			}
+			p.b.mu.Lock()
+			pending := s.pending
+			s.pending = nil
+			p.b.mu.Unlock()
+			for _, msg := range pending {
+				select {
+				case ch <- msg:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
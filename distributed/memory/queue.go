@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/haysons/gokit/distributed"
+)
+
+var _ distributed.SimpleQueue = (*Queue)(nil)
+
+// Queue 内存分布式队列，元素以 json 编码存储，出队时解码为 any，与 etcd 实现一致
+type Queue struct {
+	b         *Backend
+	keyPrefix string
+}
+
+type queueEntry struct {
+	items   [][]byte
+	changed chan struct{} // 元素入队时关闭并替换
+}
+
+// NewQueue 创建内存分布式队列
+func (b *Backend) NewQueue(keyPrefix string) *Queue {
+	return &Queue{b: b, keyPrefix: keyPrefix}
+}
+
+func (q *Queue) entry() *queueEntry {
+	e, ok := q.b.queues[q.keyPrefix]
+	if !ok {
+		e = &queueEntry{changed: make(chan struct{})}
+		q.b.queues[q.keyPrefix] = e
+	}
+	return e
+}
+
+// Enqueue 入队
+func (q *Queue) Enqueue(ctx context.Context, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal value failed: %w", err)
+	}
+	q.b.mu.Lock()
+	defer q.b.mu.Unlock()
+	e := q.entry()
+	e.items = append(e.items, data)
+	close(e.changed)
+	e.changed = make(chan struct{})
+	return nil
+}
+
+// Dequeue 出队，队列为空时阻塞直至有元素入队，超过 timeout 时返回错误
+func (q *Queue) Dequeue(ctx context.Context, timeout time.Duration) (any, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		q.b.mu.Lock()
+		e := q.entry()
+		if len(e.items) > 0 {
+			data := e.items[0]
+			e.items = e.items[1:]
+			q.b.mu.Unlock()
+			var value any
+			if err := json.Unmarshal(data, &value); err != nil {
+				return nil, fmt.Errorf("unmarshal value failed: %w", err)
+			}
+			return value, nil
+		}
+		changed := e.changed
+		q.b.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Length 队列长度
+func (q *Queue) Length(ctx context.Context) (int64, error) {
+	q.b.mu.Lock()
+	defer q.b.mu.Unlock()
+	return int64(len(q.entry().items)), nil
+}
+
+// Clear 清空队列
+func (q *Queue) Clear(ctx context.Context) error {
+	q.b.mu.Lock()
+	defer q.b.mu.Unlock()
+	q.entry().items = nil
+	return nil
+}
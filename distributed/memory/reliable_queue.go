@@ -0,0 +1,196 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/haysons/gokit/distributed"
+	"github.com/haysons/gokit/distributed/internal/queue"
+	"github.com/haysons/gokit/util/uid"
+)
+
+var _ distributed.ReliableQueue = (*ReliableQueue)(nil)
+
+// ReliableQueue 内存可靠队列，语义与 etcd 实现一致
+type ReliableQueue struct {
+	b      *Backend
+	prefix string
+	opts   queue.ReliableOptions
+}
+
+type reliableEntry struct {
+	ready    []*reliableMessage
+	inflight map[string]*reliableMessage
+	dead     []*reliableMessage
+	changed  chan struct{} // 消息重新可投递时关闭并替换
+}
+
+type reliableMessage struct {
+	id         string
+	data       []byte
+	deliveries int
+	receipt    int64
+	timer      *time.Timer // 可见性超时定时器
+}
+
+// NewReliableQueue 创建内存可靠队列
+func (b *Backend) NewReliableQueue(prefix string, opts ...distributed.ReliableQueueOption) *ReliableQueue {
+	return &ReliableQueue{b: b, prefix: prefix, opts: queue.NewReliableOptions(opts...)}
+}
+
+func (q *ReliableQueue) entry() *reliableEntry {
+	e, ok := q.b.reliableQueues[q.prefix]
+	if !ok {
+		e = &reliableEntry{inflight: make(map[string]*reliableMessage), changed: make(chan struct{})}
+		q.b.reliableQueues[q.prefix] = e
+	}
+	return e
+}
+
+// push 消息入队至队尾并唤醒等待者，需持有 Backend.mu
+func (e *reliableEntry) push(m *reliableMessage) {
+	e.ready = append(e.ready, m)
+	close(e.changed)
+	e.changed = make(chan struct{})
+}
+
+// Enqueue 入队
+func (q *ReliableQueue) Enqueue(ctx context.Context, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal value failed: %w", err)
+	}
+	q.b.mu.Lock()
+	defer q.b.mu.Unlock()
+	q.entry().push(&reliableMessage{id: uid.XID(), data: data})
+	return nil
+}
+
+// Dequeue 租用最早入队的消息，队列为空时阻塞直至有消息入队或投递中的消息超时，超过 timeout 时返回错误
+func (q *ReliableQueue) Dequeue(ctx context.Context, timeout time.Duration) (*distributed.Message, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		q.b.mu.Lock()
+		e := q.entry()
+		if len(e.ready) > 0 {
+			m := e.ready[0]
+			e.ready = e.ready[1:]
+			m.deliveries++
+			q.b.rev++
+			m.receipt = q.b.rev
+			e.inflight[m.id] = m
+			receipt := m.receipt
+			m.timer = time.AfterFunc(q.opts.VisibilityTimeout, func() { q.expire(m.id, receipt) })
+			msg := &distributed.Message{ID: m.id, Deliveries: m.deliveries, Receipt: m.receipt}
+			q.b.mu.Unlock()
+			if err := json.Unmarshal(m.data, &msg.Value); err != nil {
+				return nil, fmt.Errorf("unmarshal value failed: %w", err)
+			}
+			return msg, nil
+		}
+		changed := e.changed
+		q.b.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// expire 可见性超时后重新投递消息
+func (q *ReliableQueue) expire(id string, receipt int64) {
+	q.b.mu.Lock()
+	defer q.b.mu.Unlock()
+	_ = q.release(id, receipt)
+}
+
+// release 将投递中的消息重新入队至队尾，超过最大投递次数时移入死信，需持有 Backend.mu
+func (q *ReliableQueue) release(id string, receipt int64) error {
+	m, err := q.take(id, receipt)
+	if err != nil {
+		return err
+	}
+	e := q.entry()
+	if q.opts.Exhausted(m.deliveries) {
+		e.dead = append(e.dead, m)
+		return nil
+	}
+	e.push(m)
+	return nil
+}
+
+// take 移除投递中的消息，需持有 Backend.mu
+func (q *ReliableQueue) take(id string, receipt int64) (*reliableMessage, error) {
+	e := q.entry()
+	m, ok := e.inflight[id]
+	if !ok || m.receipt != receipt {
+		return nil, distributed.ErrMessageNotInFlight
+	}
+	m.timer.Stop()
+	delete(e.inflight, id)
+	return m, nil
+}
+
+// Ack 确认消息处理完成并删除消息
+func (q *ReliableQueue) Ack(ctx context.Context, msg *distributed.Message) error {
+	q.b.mu.Lock()
+	defer q.b.mu.Unlock()
+	_, err := q.take(msg.ID, msg.Receipt)
+	return err
+}
+
+// Nack 放弃处理消息，消息立即重新入队至队尾，超过最大投递次数时进入死信
+func (q *ReliableQueue) Nack(ctx context.Context, msg *distributed.Message) error {
+	q.b.mu.Lock()
+	defer q.b.mu.Unlock()
+	return q.release(msg.ID, msg.Receipt)
+}
+
+// Length 待投递的消息数
+func (q *ReliableQueue) Length(ctx context.Context) (int64, error) {
+	q.b.mu.Lock()
+	defer q.b.mu.Unlock()
+	return int64(len(q.entry().ready)), nil
+}
+
+// InFlight 投递中的消息数
+func (q *ReliableQueue) InFlight(ctx context.Context) (int64, error) {
+	q.b.mu.Lock()
+	defer q.b.mu.Unlock()
+	return int64(len(q.entry().inflight)), nil
+}
+
+// DeadLetters 返回全部死信消息
+func (q *ReliableQueue) DeadLetters(ctx context.Context) ([]*distributed.Message, error) {
+	q.b.mu.Lock()
+	dead := append([]*reliableMessage(nil), q.entry().dead...)
+	q.b.mu.Unlock()
+
+	msgs := make([]*distributed.Message, 0, len(dead))
+	for _, m := range dead {
+		msg := &distributed.Message{ID: m.id, Deliveries: m.deliveries}
+		if err := json.Unmarshal(m.data, &msg.Value); err != nil {
+			return nil, fmt.Errorf("unmarshal value failed: %w", err)
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// Clear 清空队列，包括投递中的消息及死信
+func (q *ReliableQueue) Clear(ctx context.Context) error {
+	q.b.mu.Lock()
+	defer q.b.mu.Unlock()
+	e := q.entry()
+	for _, m := range e.inflight {
+		m.timer.Stop()
+	}
+	e.ready, e.dead = nil, nil
+	e.inflight = make(map[string]*reliableMessage)
+	return nil
+}
@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/haysons/gokit/distributed"
+)
+
+var _ distributed.RWLocker = (*RWMutex)(nil)
+
+// RWMutex 内存分布式读写锁，读者与写者按到达顺序排队，写者优先
+type RWMutex struct {
+	b    *Backend
+	key  string
+	sess *session
+	held *ticket // 当前持有锁的凭证，未持有时为 nil
+}
+
+// NewRWMutex 创建内存分布式读写锁
+func (b *Backend) NewRWMutex(key string) *RWMutex {
+	return &RWMutex{b: b, key: key, sess: &session{}}
+}
+
+// RLock 获取读锁，阻塞直至获取成功或 ctx 结束
+func (m *RWMutex) RLock(ctx context.Context) error {
+	_, err := m.acquire(ctx, false, true)
+	return err
+}
+
+// TryRLock 尝试获取读锁，立即返回结果
+func (m *RWMutex) TryRLock(ctx context.Context) (bool, error) {
+	return m.acquire(ctx, false, false)
+}
+
+// RUnlock 释放读锁
+func (m *RWMutex) RUnlock(ctx context.Context) error {
+	return m.release(false)
+}
+
+// Lock 获取写锁，阻塞直至获取成功或 ctx 结束
+func (m *RWMutex) Lock(ctx context.Context) error {
+	_, err := m.acquire(ctx, true, true)
+	return err
+}
+
+// TryLock 尝试获取写锁，立即返回结果
+func (m *RWMutex) TryLock(ctx context.Context) (bool, error) {
+	return m.acquire(ctx, true, false)
+}
+
+// Unlock 释放写锁
+func (m *RWMutex) Unlock(ctx context.Context) error {
+	return m.release(true)
+}
+
+func (m *RWMutex) acquire(ctx context.Context, write, wait bool) (bool, error) {
+	m.b.mu.Lock()
+	if m.sess.err != nil {
+		m.b.mu.Unlock()
+		return false, m.sess.err
+	}
+	if m.held != nil {
+		m.b.mu.Unlock()
+		return false, distributed.ErrLockHeld
+	}
+	q := m.b.ticketQueue(m.key)
+	t := &ticket{write: write}
+	q.push(t)
+	for {
+		if rwAcquired(q, t) {
+			m.held = t
+			m.b.mu.Unlock()
+			return true, nil
+		}
+		if !wait {
+			q.remove(t)
+			m.b.mu.Unlock()
+			return false, nil
+		}
+		changed := q.changed
+		m.b.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			m.b.mu.Lock()
+			q.remove(t)
+			m.b.mu.Unlock()
+			return false, ctx.Err()
+		}
+		m.b.mu.Lock()
+	}
+}
+
+// rwAcquired 读者前方不存在写者，或写者位于队首时获取锁
+func rwAcquired(q *ticketQueue, t *ticket) bool {
+	for _, v := range q.tickets {
+		if v == t {
+			return true
+		}
+		if t.write || v.write {
+			return false
+		}
+	}
+	return false
+}
+
+func (m *RWMutex) release(write bool) error {
+	m.b.mu.Lock()
+	defer m.b.mu.Unlock()
+	if m.held == nil || m.held.write != write {
+		return distributed.ErrLockNotHeld
+	}
+	m.b.ticketQueue(m.key).remove(m.held)
+	m.held = nil
+	return nil
+}
+
+// Close 释放锁及会话
+func (m *RWMutex) Close() error {
+	m.b.mu.Lock()
+	defer m.b.mu.Unlock()
+	if m.held != nil {
+		m.b.ticketQueue(m.key).remove(m.held)
+		m.held = nil
+	}
+	m.sess.close()
+	return nil
+}
@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"errors"
+
+	"github.com/haysons/gokit/distributed"
+)
+
+var _ distributed.Semaphore = (*Semaphore)(nil)
+
+// Semaphore 内存分布式计数信号量，排在前 permits 位的实例持有许可
+type Semaphore struct {
+	b       *Backend
+	key     string
+	permits int
+	sess    *session
+	held    *ticket // 当前持有许可的凭证，未持有时为 nil
+}
+
+// NewSemaphore 创建内存分布式信号量，同一 key 的全部实例需使用相同的 permits
+func (b *Backend) NewSemaphore(key string, permits int) (*Semaphore, error) {
+	if permits <= 0 {
+		return nil, errors.New("memory: semaphore permits must be positive")
+	}
+	return &Semaphore{b: b, key: key, permits: permits, sess: &session{}}, nil
+}
+
+// Acquire 获取许可，阻塞直至获取成功或 ctx 结束
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	_, err := s.acquire(ctx, true)
+	return err
+}
+
+// TryAcquire 尝试获取许可，立即返回结果，许可已耗尽时返回 false
+func (s *Semaphore) TryAcquire(ctx context.Context) (bool, error) {
+	return s.acquire(ctx, false)
+}
+
+func (s *Semaphore) acquire(ctx context.Context, wait bool) (bool, error) {
+	s.b.mu.Lock()
+	if s.sess.err != nil {
+		s.b.mu.Unlock()
+		return false, s.sess.err
+	}
+	if s.held != nil {
+		s.b.mu.Unlock()
+		return false, distributed.ErrLockHeld
+	}
+	q := s.b.ticketQueue(s.key)
+	t := &ticket{}
+	q.push(t)
+	for {
+		if q.index(t) < s.permits {
+			s.held = t
+			s.b.mu.Unlock()
+			return true, nil
+		}
+		if !wait {
+			q.remove(t)
+			s.b.mu.Unlock()
+			return false, nil
+		}
+		changed := q.changed
+		s.b.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			s.b.mu.Lock()
+			q.remove(t)
+			s.b.mu.Unlock()
+			return false, ctx.Err()
+		}
+		s.b.mu.Lock()
+	}
+}
+
+// Release 释放许可
+func (s *Semaphore) Release(ctx context.Context) error {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	if s.held == nil {
+		return distributed.ErrLockNotHeld
+	}
+	s.b.ticketQueue(s.key).remove(s.held)
+	s.held = nil
+	return nil
+}
+
+// Close 释放许可及会话
+func (s *Semaphore) Close() error {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	if s.held != nil {
+		s.b.ticketQueue(s.key).remove(s.held)
+		s.held = nil
+	}
+	s.sess.close()
+	return nil
+}
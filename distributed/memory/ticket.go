@@ -0,0 +1,52 @@
+package memory
+
+// ticket 排队凭证，对应 etcd 实现中按版本号排队的 key
+type ticket struct {
+	write bool // 是否为写者
+}
+
+// ticketQueue 按到达顺序排队的凭证
+type ticketQueue struct {
+	tickets []*ticket
+	changed chan struct{} // 凭证移除时关闭并替换
+}
+
+func newTicketQueue() *ticketQueue {
+	return &ticketQueue{changed: make(chan struct{})}
+}
+
+// ticketQueue 返回 key 对应的排队队列，需持有 Backend.mu
+func (b *Backend) ticketQueue(key string) *ticketQueue {
+	q, ok := b.tickets[key]
+	if !ok {
+		q = newTicketQueue()
+		b.tickets[key] = q
+	}
+	return q
+}
+
+// push 凭证入队
+func (q *ticketQueue) push(t *ticket) {
+	q.tickets = append(q.tickets, t)
+}
+
+// index 凭证在队列中的位置，不存在时返回-1
+func (q *ticketQueue) index(t *ticket) int {
+	for i, v := range q.tickets {
+		if v == t {
+			return i
+		}
+	}
+	return -1
+}
+
+// remove 移除凭证并唤醒等待者
+func (q *ticketQueue) remove(t *ticket) {
+	i := q.index(t)
+	if i < 0 {
+		return
+	}
+	q.tickets = append(q.tickets[:i], q.tickets[i+1:]...)
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
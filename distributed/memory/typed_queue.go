@@ -0,0 +1,156 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/haysons/gokit/distributed"
+	"github.com/haysons/gokit/distributed/internal/queue"
+)
+
+var _ distributed.TypedQueue[any] = (*TypedQueue[any])(nil)
+
+// TypedQueue 内存泛型队列，语义与 etcd 实现一致，元素以编解码器编码后存储
+type TypedQueue[T any] struct {
+	b      *Backend
+	prefix string
+	opts   queue.TypedOptions
+}
+
+type typedEntry struct {
+	ready   [][][]byte // 各优先级可出队的元素
+	delayed []*delayedItem
+	changed chan struct{} // 元素入队时关闭并替换
+}
+
+type delayedItem struct {
+	data      []byte
+	priority  int
+	visibleAt time.Time
+}
+
+// NewTypedQueue 创建内存泛型队列，同一 prefix 的全部实例需使用相同的配置
+func NewTypedQueue[T any](b *Backend, prefix string, opts ...distributed.TypedQueueOption) *TypedQueue[T] {
+	return &TypedQueue[T]{b: b, prefix: prefix, opts: queue.NewTypedOptions(opts...)}
+}
+
+func (q *TypedQueue[T]) entry() *typedEntry {
+	e, ok := q.b.typedQueues[q.prefix]
+	if !ok {
+		e = &typedEntry{ready: make([][][]byte, q.opts.PriorityLevels), changed: make(chan struct{})}
+		q.b.typedQueues[q.prefix] = e
+	}
+	return e
+}
+
+// Enqueue 入队，可通过 WithPriority 指定优先级，通过 WithDelay 或 WithDeliverAt 延迟投递
+func (q *TypedQueue[T]) Enqueue(ctx context.Context, value T, opts ...distributed.EnqueueOption) error {
+	o := queue.NewEnqueueOptions(q.opts.PriorityLevels, opts...)
+	data, err := q.opts.Codec.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal value failed: %w", err)
+	}
+	q.b.mu.Lock()
+	defer q.b.mu.Unlock()
+	e := q.entry()
+	if o.Delayed() {
+		e.delayed = append(e.delayed, &delayedItem{data: data, priority: o.Priority, visibleAt: o.VisibleAt})
+	} else {
+		e.ready[o.Priority] = append(e.ready[o.Priority], data)
+	}
+	close(e.changed)
+	e.changed = make(chan struct{})
+	return nil
+}
+
+// promote 将已到期的延迟元素移至 ready，返回最早的未到期元素的可见时间，需持有 Backend.mu
+func (e *typedEntry) promote() time.Time {
+	var (
+		next    time.Time
+		now     = time.Now()
+		delayed = e.delayed[:0]
+	)
+	for _, item := range e.delayed {
+		if !item.visibleAt.After(now) {
+			e.ready[item.priority] = append(e.ready[item.priority], item.data)
+			continue
+		}
+		if next.IsZero() || item.visibleAt.Before(next) {
+			next = item.visibleAt
+		}
+		delayed = append(delayed, item)
+	}
+	e.delayed = delayed
+	return next
+}
+
+// Dequeue 出队优先级最高的元素中最早入队的元素，队列为空时阻塞直至有元素可见，超过 timeout 时返回错误
+func (q *TypedQueue[T]) Dequeue(ctx context.Context, timeout time.Duration) (T, error) {
+	var zero T
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		q.b.mu.Lock()
+		e := q.entry()
+		next := e.promote()
+		for p := len(e.ready) - 1; p >= 0; p-- {
+			if len(e.ready[p]) == 0 {
+				continue
+			}
+			data := e.ready[p][0]
+			e.ready[p] = e.ready[p][1:]
+			q.b.mu.Unlock()
+			var value T
+			if err := q.opts.Codec.Unmarshal(data, &value); err != nil {
+				return zero, fmt.Errorf("unmarshal value failed: %w", err)
+			}
+			return value, nil
+		}
+		changed := e.changed
+		q.b.mu.Unlock()
+
+		if err := waitUntil(ctx, changed, next); err != nil {
+			return zero, err
+		}
+	}
+}
+
+// waitUntil 等待 changed 关闭，next 非零值时最多等待至 next
+func waitUntil(ctx context.Context, changed <-chan struct{}, next time.Time) error {
+	var timeout <-chan time.Time
+	if !next.IsZero() {
+		timer := time.NewTimer(time.Until(next))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-changed:
+	case <-timeout:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+// Length 队列长度，包含尚未到期的延迟元素
+func (q *TypedQueue[T]) Length(ctx context.Context) (int64, error) {
+	q.b.mu.Lock()
+	defer q.b.mu.Unlock()
+	e := q.entry()
+	n := len(e.delayed)
+	for _, items := range e.ready {
+		n += len(items)
+	}
+	return int64(n), nil
+}
+
+// Clear 清空队列
+func (q *TypedQueue[T]) Clear(ctx context.Context) error {
+	q.b.mu.Lock()
+	defer q.b.mu.Unlock()
+	e := q.entry()
+	e.ready = make([][][]byte, q.opts.PriorityLevels)
+	e.delayed = nil
+	return nil
+}
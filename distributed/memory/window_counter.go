@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/haysons/gokit/distributed"
+)
+
+var _ distributed.AtomicCounter = (*WindowCounter)(nil)
+
+// WindowCounter 内存时间窗口计数器，窗口按 UTC 时间对齐，窗口切换后计数值从0开始
+type WindowCounter struct {
+	b      *Backend
+	key    string
+	window time.Duration
+}
+
+type windowEntry struct {
+	start time.Time // 计数值所属窗口的开始时间
+	value int64
+}
+
+// NewWindowCounter 创建内存时间窗口计数器
+func (b *Backend) NewWindowCounter(key string, window time.Duration) *WindowCounter {
+	return &WindowCounter{b: b, key: key, window: window}
+}
+
+// ResetAt 当前窗口结束的时间，此后计数值从0开始
+func (c *WindowCounter) ResetAt() time.Time {
+	return time.Now().Truncate(c.window).Add(c.window)
+}
+
+// entry 当前窗口的计数，窗口已切换时重置，需持有 Backend.mu
+func (c *WindowCounter) entry() *windowEntry {
+	start := time.Now().Truncate(c.window)
+	e, ok := c.b.windows[c.key]
+	if !ok || !e.start.Equal(start) {
+		e = &windowEntry{start: start}
+		c.b.windows[c.key] = e
+	}
+	return e
+}
+
+// Get 获取当前窗口的计数值
+func (c *WindowCounter) Get(ctx context.Context) (int64, error) {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+	return c.entry().value, nil
+}
+
+// Incr 当前窗口的计数原子加1，返回新值
+func (c *WindowCounter) Incr(ctx context.Context) (int64, error) {
+	return c.IncrBy(ctx, 1)
+}
+
+// IncrBy 当前窗口的计数原子增加 delta，返回新值
+func (c *WindowCounter) IncrBy(ctx context.Context, delta int64) (int64, error) {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+	e := c.entry()
+	e.value += delta
+	return e.value, nil
+}
+
+// Decr 当前窗口的计数原子减1，返回新值
+func (c *WindowCounter) Decr(ctx context.Context) (int64, error) {
+	return c.DecrBy(ctx, 1)
+}
+
+// DecrBy 当前窗口的计数原子减少 delta，返回新值
+func (c *WindowCounter) DecrBy(ctx context.Context, delta int64) (int64, error) {
+	return c.IncrBy(ctx, -delta)
+}
+
+// Set 设置当前窗口的计数值
+func (c *WindowCounter) Set(ctx context.Context, value int64) error {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+	c.entry().value = value
+	return nil
+}
+
+// CompareAndSet 当前窗口的计数值等于 old 时设置为 value，返回是否设置成功
+func (c *WindowCounter) CompareAndSet(ctx context.Context, old, value int64) (bool, error) {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+	e := c.entry()
+	if e.value != old {
+		return false, nil
+	}
+	e.value = value
+	return true, nil
+}
+
+// GetAndReset 原子地获取当前窗口的计数值并重置为0
+func (c *WindowCounter) GetAndReset(ctx context.Context) (int64, error) {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+	e := c.entry()
+	value := e.value
+	e.value = 0
+	return value, nil
+}
+
+// Reset 重置当前窗口的计数值为0
+func (c *WindowCounter) Reset(ctx context.Context) error {
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+	c.entry().value = 0
+	return nil
+}
@@ -0,0 +1,355 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/haysons/gokit/distributed/internal/allocator"
+	"github.com/haysons/gokit/distributed/internal/election"
+	"github.com/haysons/gokit/log"
+	"github.com/haysons/gokit/util/uid"
+	"go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+// minNodeReclaimBackoff 重新申请节点号失败后的最短退避时间
+const minNodeReclaimBackoff = 100 * time.Millisecond
+
+var (
+	// ErrNoFreeNode 全部节点号均已被分配
+	ErrNoFreeNode = errors.New("no free node")
+	// ErrAllocatorClosed 分配器已关闭
+	ErrAllocatorClosed = errors.New("node allocator is closed")
+)
+
+// NodeAllocatorOption 节点号分配器配置项
+type NodeAllocatorOption func(*allocator.Options)
+
+// WithNodeTTL 配置会话租约时长，默认为10s，会话存续期间租约将自动续期
+func WithNodeTTL(ttl time.Duration) NodeAllocatorOption {
+	return func(o *allocator.Options) {
+		o.TTL = ttl
+	}
+}
+
+// WithMaxNode 配置最大节点号，默认且最大为雪花算法节点号的最大值，即 2^snowflake.NodeBits-1
+func WithMaxNode(n int64) NodeAllocatorOption {
+	return func(o *allocator.Options) {
+		o.MaxNode = n
+	}
+}
+
+// WithNodeChange 配置节点号变化时执行的回调，租约丢失后将优先重新申请原节点号，原节点号已被占用时才会分配新的节点号
+func WithNodeChange(fn func(node int64)) NodeAllocatorOption {
+	return func(o *allocator.Options) {
+		o.OnChange = fn
+	}
+}
+
+// WithNodeLost 配置租约丢失时执行的回调，此时原节点号可能已被其他实例占用，重新申请成功前不应继续使用原节点号
+func WithNodeLost(fn func(node int64)) NodeAllocatorOption {
+	return func(o *allocator.Options) {
+		o.OnLost = fn
+	}
+}
+
+// WithNodeReclaim 配置租约丢失后重新申请节点号成功时执行的回调，无论节点号是否变化均会执行
+func WithNodeReclaim(fn func(node int64)) NodeAllocatorOption {
+	return func(o *allocator.Options) {
+		o.OnReclaim = fn
+	}
+}
+
+// WithNodeRelease 配置关闭分配器释放已分配的节点号时执行的回调，租约丢失、重新申请期间关闭时同样执行，此后不再执行其他回调
+func WithNodeRelease(fn func()) NodeAllocatorOption {
+	return func(o *allocator.Options) {
+		o.OnRelease = fn
+	}
+}
+
+// EtcdNodeAllocator 基于 etcd 的雪花算法节点号分配器，节点号 key 绑定会话租约，会话存续期间自动续期，
+// 实例退出或租约过期后节点号被释放；租约丢失后将在后台重新申请节点号，优先申请原节点号，
+// 重新申请成功前 Node 返回-1，Allocate 阻塞等待。存储布局如下：
+//
+//	{prefix}/{node} 已分配的节点号，值为持有者的 id
+type EtcdNodeAllocator struct {
+	client *clientv3.Client
+	prefix string
+	opts   allocator.Options
+	holder string // 写入节点号 key 的值，便于排查节点号的持有者
+
+	ctx    context.Context // 分配器关闭时取消，结束后台续期及重新申请
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	session   *concurrency.Session
+	node      int64         // 当前分配的节点号，未分配或租约丢失时为-1
+	reclaimed chan struct{} // 租约丢失后重新申请成功时关闭，未丢失租约时为 nil
+
+	notifyMu sync.Mutex // 串行执行回调，保证关闭后不再执行其他回调
+}
+
+// NewNodeAllocator 创建节点号分配器
+func NewNodeAllocator(client *clientv3.Client, prefix string, opts ...NodeAllocatorOption) *EtcdNodeAllocator {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &EtcdNodeAllocator{
+		client: client,
+		prefix: prefix,
+		opts:   allocator.NewOptions(opts...),
+		holder: election.NewOptions[ElectionOption]().Candidate.ID,
+		ctx:    ctx,
+		cancel: cancel,
+		node:   -1,
+	}
+}
+
+func (a *EtcdNodeAllocator) key(node int64) string {
+	return a.prefix + "/" + strconv.FormatInt(node, 10)
+}
+
+// Allocate 分配空闲的节点号，已分配时返回当前节点号，租约丢失时阻塞直至重新申请成功或 ctx 结束，
+// 全部节点号均已被分配时返回 ErrNoFreeNode
+func (a *EtcdNodeAllocator) Allocate(ctx context.Context) (int64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for a.reclaimed != nil && a.ctx.Err() == nil {
+		reclaimed := a.reclaimed
+		a.mu.Unlock()
+		select {
+		case <-reclaimed:
+		case <-a.ctx.Done():
+		case <-ctx.Done():
+			a.mu.Lock()
+			return -1, ctx.Err()
+		}
+		a.mu.Lock()
+	}
+	if a.ctx.Err() != nil {
+		return -1, ErrAllocatorClosed
+	}
+	if a.node >= 0 {
+		return a.node, nil
+	}
+
+	// 以机器 id 计算的节点号作为起点，分散同时启动的实例
+	session, node, err := a.claim(ctx, uid.SnowflakeNode()%(a.opts.MaxNode+1))
+	if err != nil {
+		return -1, err
+	}
+	a.session, a.node = session, node
+	go a.keep(session)
+	return node, nil
+}
+
+// Node 当前分配的节点号，未分配或租约丢失后重新申请成功前返回-1
+func (a *EtcdNodeAllocator) Node() int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.node
+}
+
+// claim 创建会话并自 start 起依次申请空闲的节点号，会话的生命周期与分配器一致，由 Close 关闭
+func (a *EtcdNodeAllocator) claim(ctx context.Context, start int64) (*concurrency.Session, int64, error) {
+	session, err := newSession(context.Background(), a.client, a.opts.TTL)
+	if err != nil {
+		return nil, -1, err
+	}
+	resp, err := a.client.Get(ctx, a.prefix+"/", clientv3.WithPrefix(), clientv3.WithKeysOnly())
+	if err != nil {
+		session.Close()
+		return nil, -1, fmt.Errorf("get allocated nodes failed: %w", err)
+	}
+	used := make(map[int64]bool, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		if n, err := strconv.ParseInt(strings.TrimPrefix(string(kv.Key), a.prefix+"/"), 10, 64); err == nil {
+			used[n] = true
+		}
+	}
+
+	total := a.opts.MaxNode + 1
+	for i := range total {
+		node := (start + i) % total
+		if used[node] {
+			continue
+		}
+		// 节点号可能已被其他实例并发申请
+		key := a.key(node)
+		txnResp, err := a.client.Txn(ctx).
+			If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+			Then(clientv3.OpPut(key, a.holder, clientv3.WithLease(session.Lease()))).
+			Commit()
+		if err != nil {
+			session.Close()
+			return nil, -1, fmt.Errorf("claim node failed: %w", err)
+		}
+		if txnResp.Succeeded {
+			return session, node, nil
+		}
+	}
+	session.Close()
+	return nil, -1, ErrNoFreeNode
+}
+
+// keep 会话失效后重新申请节点号，优先申请原节点号，失败时退避重试直至成功或分配器关闭
+func (a *EtcdNodeAllocator) keep(session *concurrency.Session) {
+	select {
+	case <-session.Done():
+	case <-a.ctx.Done():
+		return
+	}
+	if a.ctx.Err() != nil {
+		return
+	}
+
+	// 原节点号可能已被其他实例占用，重新申请成功前不再对外提供节点号
+	logger := log.GetDefaultSlog().With(slog.String("prefix", a.prefix))
+	a.mu.Lock()
+	if a.ctx.Err() != nil {
+		a.mu.Unlock()
+		return
+	}
+	prev := a.node
+	a.session, a.node = nil, -1
+	a.reclaimed = make(chan struct{})
+	a.mu.Unlock()
+	logger.Warn("snowflake node lease lost, reclaiming", slog.Int64("node", prev))
+	a.notify(func() {
+		if a.opts.OnLost != nil {
+			a.opts.OnLost(prev)
+		}
+	})
+	// 租约已失效，关闭会话释放其资源
+	session.Close()
+
+	backoff := minNodeReclaimBackoff
+	for {
+		ctx, cancel := context.WithTimeout(a.ctx, a.opts.TTL)
+		session, node, err := a.claim(ctx, prev)
+		cancel()
+		if err == nil {
+			a.mu.Lock()
+			if a.ctx.Err() != nil {
+				a.mu.Unlock()
+				session.Close()
+				return
+			}
+			a.session, a.node = session, node
+			close(a.reclaimed)
+			a.reclaimed = nil
+			a.mu.Unlock()
+			if node != prev {
+				logger.Warn("snowflake node changed", slog.Int64("from", prev), slog.Int64("to", node))
+			}
+			a.notify(func() {
+				if node != prev && a.opts.OnChange != nil {
+					a.opts.OnChange(node)
+				}
+				if a.opts.OnReclaim != nil {
+					a.opts.OnReclaim(node)
+				}
+			})
+			go a.keep(session)
+			return
+		}
+		if a.ctx.Err() != nil {
+			return
+		}
+		logger.Error("reclaim snowflake node failed", slog.Any("error", err))
+		select {
+		case <-time.After(backoff):
+		case <-a.ctx.Done():
+			return
+		}
+		backoff = min(backoff*2, a.opts.TTL)
+	}
+}
+
+// notify 执行回调，分配器关闭后不再执行
+func (a *EtcdNodeAllocator) notify(fn func()) {
+	a.notifyMu.Lock()
+	defer a.notifyMu.Unlock()
+	if a.ctx.Err() == nil {
+		fn()
+	}
+}
+
+// Close 关闭分配器并释放节点号，分配器为 nil 时不做任何操作
+func (a *EtcdNodeAllocator) Close() error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	a.cancel()
+	allocated := a.node >= 0 || a.reclaimed != nil
+	session := a.session
+	a.session, a.node = nil, -1
+	if a.reclaimed != nil {
+		close(a.reclaimed)
+		a.reclaimed = nil
+	}
+	a.mu.Unlock()
+
+	var err error
+	if session != nil {
+		err = session.Close()
+	}
+	// 等待进行中的回调执行完成，确保 OnRelease 为最后执行的回调
+	a.notifyMu.Lock()
+	defer a.notifyMu.Unlock()
+	if allocated && a.opts.OnRelease != nil {
+		a.opts.OnRelease()
+	}
+	return err
+}
+
+// InitSnowflakeNode 在 etcd 中分配唯一的节点号并据此初始化 uid.SnowflakeID 使用的生成器，
+// 租约丢失后至重新申请成功前暂停生成，避免与占用了原节点号的其他实例生成重复的 id，此时 uid.SnowflakeID 返回0，
+// uid.SnowflakeIDContext 阻塞。应用退出时需关闭返回的分配器以释放节点号，关闭后生成器恢复为基于机器 id 计算的节点号。client 为 nil 时沿用 uid.SnowflakeNode 基于机器 id 计算的节点号，此时返回 nil
+func InitSnowflakeNode(ctx context.Context, client *clientv3.Client, prefix string, opts ...NodeAllocatorOption) (*EtcdNodeAllocator, error) {
+	if client == nil {
+		return nil, uid.SetSnowflakeNode(uid.SnowflakeNode())
+	}
+	o := allocator.NewOptions(opts...)
+	opts = append(opts,
+		WithNodeLost(func(node int64) {
+			uid.SuspendSnowflakeNode()
+			if o.OnLost != nil {
+				o.OnLost(node)
+			}
+		}),
+		WithNodeReclaim(func(node int64) {
+			if err := uid.SetSnowflakeNode(node); err != nil {
+				log.GetDefaultSlog().Error("set snowflake node failed", slog.Int64("node", node), slog.Any("error", err))
+			}
+			if o.OnReclaim != nil {
+				o.OnReclaim(node)
+			}
+		}),
+		WithNodeRelease(func() {
+			if err := uid.SetSnowflakeNode(uid.SnowflakeNode()); err != nil {
+				log.GetDefaultSlog().Error("restore snowflake node failed", slog.Any("error", err))
+			}
+			if o.OnRelease != nil {
+				o.OnRelease()
+			}
+		}),
+	)
+
+	a := NewNodeAllocator(client, prefix, opts...)
+	node, err := a.Allocate(ctx)
+	if err != nil {
+		a.Close()
+		return nil, err
+	}
+	if err = uid.SetSnowflakeNode(node); err != nil {
+		a.Close()
+		return nil, err
+	}
+	return a, nil
+}
@@ -0,0 +1,181 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/haysons/gokit/distributed/internal/pubsub"
+	"github.com/haysons/gokit/log"
+	"github.com/haysons/gokit/util/uid"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"go.etcd.io/etcd/client/v3"
+)
+
+const (
+	minResubscribeBackoff = 100 * time.Millisecond
+	maxResubscribeBackoff = 5 * time.Second
+)
+
+// TopicMessage 发布至主题的消息
+type TopicMessage struct {
+	Topic    string
+	Payload  []byte
+	Revision int64 // 消息的版本号，同一主题下的消息按版本号递增的顺序投递
+}
+
+// PubSubOption 发布订阅配置项
+type PubSubOption func(*pubsub.Options)
+
+// WithMessageTTL 配置消息的保留时长，默认为10s，消息实际保留 TTL 至 2*TTL
+func WithMessageTTL(ttl time.Duration) PubSubOption {
+	return func(o *pubsub.Options) {
+		o.TTL = ttl
+	}
+}
+
+// WithSubscribeBuffer 配置订阅管道的缓冲大小，默认为64，缓冲已满时暂停投递直至订阅者取走消息
+func WithSubscribeBuffer(n int) PubSubOption {
+	return func(o *pubsub.Options) {
+		o.Buffer = n
+	}
+}
+
+// EtcdPubSub 基于 etcd watch 的发布订阅，消息写入绑定短期租约的 key，订阅者监听主题前缀，
+// 断线后自最后收到的版本号起重新监听，不会遗漏仍在保留期内的消息。存储布局如下：
+//
+//	{prefix}/{topic}/{xid} 消息，值为消息内容
+type EtcdPubSub struct {
+	client *clientv3.Client
+	prefix string
+	opts   pubsub.Options
+
+	mu        sync.Mutex
+	lease     clientv3.LeaseID // 发布消息共用的租约，时长为 2*TTL，授予后 TTL 内复用
+	leaseTill time.Time        // 租约可复用的截止时间
+}
+
+// NewPubSub 创建发布订阅
+func NewPubSub(client *clientv3.Client, prefix string, opts ...PubSubOption) *EtcdPubSub {
+	return &EtcdPubSub{
+		client: client,
+		prefix: prefix,
+		opts:   pubsub.NewOptions(opts...),
+	}
+}
+
+func (p *EtcdPubSub) topicPrefix(topic string) string {
+	return p.prefix + "/" + topic + "/"
+}
+
+// Publish 向 topic 发布消息，当前的全部订阅者均会收到该消息
+func (p *EtcdPubSub) Publish(ctx context.Context, topic string, payload []byte) error {
+	key := p.topicPrefix(topic) + uid.XID()
+	for attempt := 0; ; attempt++ {
+		lease, err := p.grant(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = p.client.Put(ctx, key, string(payload), clientv3.WithLease(lease))
+		if err == nil {
+			return nil
+		}
+		// 租约可能已被撤销，重新授予后重试一次
+		if errors.Is(err, rpctypes.ErrLeaseNotFound) && attempt == 0 {
+			p.mu.Lock()
+			if p.lease == lease {
+				p.lease = 0
+			}
+			p.mu.Unlock()
+			continue
+		}
+		return fmt.Errorf("publish message failed: %w", err)
+	}
+}
+
+// grant 返回发布消息使用的租约，每个 TTL 周期授予一次时长为 2*TTL 的租约，
+// 保证消息至少保留 TTL 的同时避免每条消息单独授予租约
+func (p *EtcdPubSub) grant(ctx context.Context) (clientv3.LeaseID, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	if p.lease != 0 && now.Before(p.leaseTill) {
+		return p.lease, nil
+	}
+	resp, err := p.client.Grant(ctx, max(int64((2*p.opts.TTL).Seconds()), 1))
+	if err != nil {
+		return 0, fmt.Errorf("grant lease failed: %w", err)
+	}
+	p.lease, p.leaseTill = resp.ID, now.Add(p.opts.TTL)
+	return p.lease, nil
+}
+
+// Subscribe 订阅 topic 此后发布的消息，返回的管道在 ctx 结束后关闭；
+// 监听中断后自最后收到的版本号起重新监听，错过的消息已被压缩时自压缩版本号起继续监听并记录日志
+func (p *EtcdPubSub) Subscribe(ctx context.Context, topic string) (<-chan *TopicMessage, error) {
+	prefix := p.topicPrefix(topic)
+	resp, err := p.client.Get(ctx, prefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		return nil, fmt.Errorf("get revision failed: %w", err)
+	}
+	ch := make(chan *TopicMessage, p.opts.Buffer)
+	go p.watch(ctx, topic, prefix, resp.Header.Revision+1, ch)
+	return ch, nil
+}
+
+// watch 自版本号 rev 起监听主题前缀并投递消息，直至 ctx 结束
+func (p *EtcdPubSub) watch(ctx context.Context, topic, prefix string, rev int64, ch chan<- *TopicMessage) {
+	defer close(ch)
+	logger := log.GetDefaultSlog().With(slog.String("topic", topic))
+	backoff := minResubscribeBackoff
+	for {
+		next, err := p.watchOnce(ctx, topic, prefix, rev, ch)
+		if ctx.Err() != nil {
+			return
+		}
+		if next > rev {
+			backoff = minResubscribeBackoff
+		}
+		rev = next
+		logger.Warn("pubsub watch interrupted, resubscribing", slog.Int64("revision", rev), slog.Any("error", err))
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		backoff = min(backoff*2, maxResubscribeBackoff)
+	}
+}
+
+// watchOnce 自版本号 rev 起监听一次，监听中断时返回下一次监听的起始版本号
+func (p *EtcdPubSub) watchOnce(ctx context.Context, topic, prefix string, rev int64, ch chan<- *TopicMessage) (int64, error) {
+	wctx, cancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+	defer cancel()
+	wch := p.client.Watch(wctx, prefix, clientv3.WithPrefix(), clientv3.WithRev(rev), clientv3.WithFilterDelete())
+	for resp := range wch {
+		if resp.CompactRevision != 0 {
+			// 错过的消息已被压缩，自压缩版本号起继续监听
+			return resp.CompactRevision, fmt.Errorf("messages before revision %d lost: %w", resp.CompactRevision, rpctypes.ErrCompacted)
+		}
+		if err := resp.Err(); err != nil {
+			return rev, err
+		}
+		for _, ev := range resp.Events {
+			rev = ev.Kv.ModRevision + 1
+			// 仅投递主题下的直接子 key，避免前缀相同的其他主题（如 a 与 a/b）相互干扰
+			if strings.Contains(strings.TrimPrefix(string(ev.Kv.Key), prefix), "/") {
+				continue
+			}
+			select {
+			case ch <- &TopicMessage{Topic: topic, Payload: ev.Kv.Value, Revision: ev.Kv.ModRevision}:
+			case <-ctx.Done():
+				return rev, ctx.Err()
+			}
+		}
+	}
+	return rev, errors.New("watch channel closed")
+}
@@ -6,10 +6,11 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/haysons/gokit/util/uid"
 	"go.etcd.io/etcd/client/v3"
 )
 
-// Queue 分布式队列
+// Queue 基于 etcd 的分布式队列
 type Queue struct {
 	client    *clientv3.Client
 	keyPrefix string
@@ -30,7 +31,8 @@ func (q *Queue) Enqueue(ctx context.Context, value interface{}) error {
 		return fmt.Errorf("marshal value failed: %w", err)
 	}
 
-	_, err = q.client.Put(ctx, fmt.Sprintf("%s/%d", q.keyPrefix, time.Now().UnixNano()), string(data))
+	// key 以 xid 保证全局唯一，出队时按版本号排序，保证严格先进先出
+	_, err = q.client.Put(ctx, fmt.Sprintf("%s/%s", q.keyPrefix, uid.XID()), string(data))
 	if err != nil {
 		return fmt.Errorf("enqueue failed: %w", err)
 	}
@@ -44,12 +46,8 @@ func (q *Queue) Dequeue(ctx context.Context, timeout time.Duration) (interface{}
 	defer cancel()
 
 	for {
-		// 获取队列中最旧的元素（按 key 升序取第一条）
-		resp, err := q.client.Get(ctx, q.keyPrefix,
-			clientv3.WithPrefix(),
-			clientv3.WithLimit(1),
-			clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend),
-		)
+		// 获取队列中最旧的元素（按创建版本号升序取第一条）
+		resp, err := q.client.Get(ctx, q.keyPrefix, clientv3.WithFirstCreate()...)
 		if err != nil {
 			return nil, fmt.Errorf("get queue elements failed: %w", err)
 		}
@@ -73,13 +71,13 @@ func (q *Queue) Dequeue(ctx context.Context, timeout time.Duration) (interface{}
 
 				return value, nil
 			}
+			// 元素已被其他消费者取走，立即重试
+			continue
 		}
 
-		// 队列为空，等待一段时间后重试
-		select {
-		case <-time.After(100 * time.Millisecond):
-		case <-ctx.Done():
-			return nil, ctx.Err()
+		// 队列为空，监听新元素入队
+		if err = waitPut(ctx, q.client, q.keyPrefix, resp.Header.Revision+1); err != nil {
+			return nil, err
 		}
 	}
 }
@@ -0,0 +1,321 @@
+package distributed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/haysons/gokit/distributed/internal/queue"
+	"github.com/haysons/gokit/util/uid"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/client/v3"
+)
+
+// ErrMessageNotInFlight 消息已不处于投递中，如可见性超时后已被重新投递、已确认或已进入死信
+var ErrMessageNotInFlight = errors.New("message is not in flight")
+
+// Message 可靠队列投递的消息
+type Message struct {
+	ID         string // 消息 id
+	Value      any    // 消息内容，以 json 编码存储，出队时解码为 any
+	Deliveries int    // 已投递的次数，包含本次投递
+	Receipt    int64  // 投递凭证，Ack 及 Nack 时据此校验消息仍由当前消费者持有
+}
+
+// ReliableQueueOption 可靠队列配置项
+type ReliableQueueOption func(*queue.ReliableOptions)
+
+// WithVisibilityTimeout 配置可见性超时，默认为30s，etcd 实现中精度为秒
+func WithVisibilityTimeout(timeout time.Duration) ReliableQueueOption {
+	return func(o *queue.ReliableOptions) {
+		o.VisibilityTimeout = timeout
+	}
+}
+
+// WithMaxDeliveries 配置最大投递次数，默认为5，为0时不限制
+func WithMaxDeliveries(n int) ReliableQueueOption {
+	return func(o *queue.ReliableOptions) {
+		o.MaxDeliveries = n
+	}
+}
+
+// envelope 消息在 etcd 中的存储格式
+type envelope struct {
+	ID         string          `json:"id"`
+	Data       json.RawMessage `json:"data"`
+	Deliveries int             `json:"deliveries"`
+}
+
+// EtcdReliableQueue 基于 etcd 的可靠队列，消息出队后移至投递中前缀并绑定租约，确认后才会被删除，
+// 消费者崩溃或未在可见性超时内确认时，租约过期，消息重新投递，超过最大投递次数的消息进入死信前缀。
+// 存储布局如下：
+//
+//	{prefix}/ready/{id}     待投递的消息
+//	{prefix}/inflight/{id}  投递中的消息
+//	{prefix}/lease/{id}     投递中消息的租约标记，租约过期后删除
+//	{prefix}/dead/{id}      死信消息
+type EtcdReliableQueue struct {
+	client *clientv3.Client
+	prefix string
+	opts   queue.ReliableOptions
+	leases sync.Map // 消息 id -> 投递租约，确认后立即撤销
+}
+
+// NewReliableQueue 创建可靠队列实例
+func NewReliableQueue(client *clientv3.Client, prefix string, opts ...ReliableQueueOption) *EtcdReliableQueue {
+	return &EtcdReliableQueue{
+		client: client,
+		prefix: prefix,
+		opts:   queue.NewReliableOptions(opts...),
+	}
+}
+
+func (q *EtcdReliableQueue) readyKey(id string) string    { return q.prefix + "/ready/" + id }
+func (q *EtcdReliableQueue) inflightKey(id string) string { return q.prefix + "/inflight/" + id }
+func (q *EtcdReliableQueue) leaseKey(id string) string    { return q.prefix + "/lease/" + id }
+func (q *EtcdReliableQueue) deadKey(id string) string     { return q.prefix + "/dead/" + id }
+
+// Enqueue 入队
+func (q *EtcdReliableQueue) Enqueue(ctx context.Context, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal value failed: %w", err)
+	}
+	id := uid.XID()
+	env, err := json.Marshal(envelope{ID: id, Data: data})
+	if err != nil {
+		return fmt.Errorf("marshal message failed: %w", err)
+	}
+	if _, err = q.client.Put(ctx, q.readyKey(id), string(env)); err != nil {
+		return fmt.Errorf("enqueue failed: %w", err)
+	}
+	return nil
+}
+
+// Dequeue 租用最早入队的消息，队列为空时阻塞直至有消息入队或投递中的消息超时，超过 timeout 时返回错误，
+// 消息处理完成后需调用 Ack 确认，否则将在可见性超时后重新投递
+func (q *EtcdReliableQueue) Dequeue(ctx context.Context, timeout time.Duration) (*Message, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		// 先回收租约已过期的消息，使其重新参与投递
+		rev, err := q.reclaim(ctx)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := q.client.Get(ctx, q.prefix+"/ready/", clientv3.WithFirstCreate()...)
+		if err != nil {
+			return nil, fmt.Errorf("get ready messages failed: %w", err)
+		}
+		if len(resp.Kvs) > 0 {
+			msg, err := q.lease(ctx, resp.Kvs[0])
+			if err != nil {
+				return nil, err
+			}
+			if msg != nil {
+				return msg, nil
+			}
+			// 消息已被其他消费者租用，立即重试
+			continue
+		}
+
+		// 队列为空，监听消息入队或投递租约过期
+		if err = q.wait(ctx, rev+1); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// lease 将消息移至投递中并绑定租约，消息已被其他消费者租用时返回 nil
+func (q *EtcdReliableQueue) lease(ctx context.Context, kv *mvccpb.KeyValue) (*Message, error) {
+	var env envelope
+	if err := json.Unmarshal(kv.Value, &env); err != nil {
+		return nil, fmt.Errorf("unmarshal message failed: %w", err)
+	}
+	env.Deliveries++
+	data, err := json.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("marshal message failed: %w", err)
+	}
+
+	ttl := int64(math.Ceil(q.opts.VisibilityTimeout.Seconds()))
+	grant, err := q.client.Grant(ctx, max(ttl, 1))
+	if err != nil {
+		return nil, fmt.Errorf("grant lease failed: %w", err)
+	}
+	readyKey := string(kv.Key)
+	txnResp, err := q.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(readyKey), "=", kv.ModRevision)).
+		Then(
+			clientv3.OpDelete(readyKey),
+			clientv3.OpPut(q.inflightKey(env.ID), string(data)),
+			clientv3.OpPut(q.leaseKey(env.ID), "", clientv3.WithLease(grant.ID)),
+		).
+		Commit()
+	if err != nil || !txnResp.Succeeded {
+		_, _ = q.client.Revoke(context.WithoutCancel(ctx), grant.ID)
+		if err != nil {
+			return nil, fmt.Errorf("lease message failed: %w", err)
+		}
+		return nil, nil
+	}
+	q.leases.Store(env.ID, grant.ID)
+
+	var value any
+	if err = json.Unmarshal(env.Data, &value); err != nil {
+		return nil, fmt.Errorf("unmarshal value failed: %w", err)
+	}
+	return &Message{ID: env.ID, Value: value, Deliveries: env.Deliveries, Receipt: txnResp.Header.Revision}, nil
+}
+
+// reclaim 将租约已过期的投递中消息重新入队，超过最大投递次数时移入死信，返回检查时的版本号
+func (q *EtcdReliableQueue) reclaim(ctx context.Context) (int64, error) {
+	resp, err := q.client.Txn(ctx).Then(
+		clientv3.OpGet(q.prefix+"/inflight/", clientv3.WithPrefix()),
+		clientv3.OpGet(q.prefix+"/lease/", clientv3.WithPrefix(), clientv3.WithKeysOnly()),
+	).Commit()
+	if err != nil {
+		return 0, fmt.Errorf("get inflight messages failed: %w", err)
+	}
+	leased := make(map[string]bool)
+	for _, kv := range resp.Responses[1].GetResponseRange().Kvs {
+		leased[strings.TrimPrefix(string(kv.Key), q.prefix+"/lease/")] = true
+	}
+	for _, kv := range resp.Responses[0].GetResponseRange().Kvs {
+		id := strings.TrimPrefix(string(kv.Key), q.prefix+"/inflight/")
+		if leased[id] {
+			continue
+		}
+		// 消息可能已被其他消费者回收
+		if err = q.release(ctx, id, kv.ModRevision, kv.Value); err != nil && !errors.Is(err, ErrMessageNotInFlight) {
+			return 0, err
+		}
+	}
+	return resp.Header.Revision, nil
+}
+
+// release 将投递中的消息重新入队至队尾，超过最大投递次数时移入死信
+func (q *EtcdReliableQueue) release(ctx context.Context, id string, receipt int64, value []byte) error {
+	var env envelope
+	if err := json.Unmarshal(value, &env); err != nil {
+		return fmt.Errorf("unmarshal message failed: %w", err)
+	}
+	target := q.readyKey(id)
+	if q.opts.Exhausted(env.Deliveries) {
+		target = q.deadKey(id)
+	}
+	resp, err := q.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(q.inflightKey(id)), "=", receipt)).
+		Then(
+			clientv3.OpDelete(q.inflightKey(id)),
+			clientv3.OpDelete(q.leaseKey(id)),
+			clientv3.OpPut(target, string(value)),
+		).
+		Commit()
+	if err != nil {
+		return fmt.Errorf("release message failed: %w", err)
+	}
+	if !resp.Succeeded {
+		return ErrMessageNotInFlight
+	}
+	q.revoke(ctx, id)
+	return nil
+}
+
+// wait 监听消息入队或投递租约过期
+func (q *EtcdReliableQueue) wait(ctx context.Context, rev int64) error {
+	readyPrefix, leasePrefix := q.prefix+"/ready/", q.prefix+"/lease/"
+	return waitEvent(ctx, q.client, q.prefix+"/", rev, func(ev *clientv3.Event) bool {
+		key := string(ev.Kv.Key)
+		return (ev.Type == mvccpb.PUT && strings.HasPrefix(key, readyPrefix)) ||
+			(ev.Type == mvccpb.DELETE && strings.HasPrefix(key, leasePrefix))
+	}, clientv3.WithPrefix())
+}
+
+// revoke 撤销消息的投递租约
+func (q *EtcdReliableQueue) revoke(ctx context.Context, id string) {
+	if leaseID, ok := q.leases.LoadAndDelete(id); ok {
+		_, _ = q.client.Revoke(context.WithoutCancel(ctx), leaseID.(clientv3.LeaseID))
+	}
+}
+
+// Ack 确认消息处理完成并删除消息，消息已不处于投递中时返回 ErrMessageNotInFlight
+func (q *EtcdReliableQueue) Ack(ctx context.Context, msg *Message) error {
+	resp, err := q.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(q.inflightKey(msg.ID)), "=", msg.Receipt)).
+		Then(clientv3.OpDelete(q.inflightKey(msg.ID)), clientv3.OpDelete(q.leaseKey(msg.ID))).
+		Commit()
+	if err != nil {
+		return fmt.Errorf("ack message failed: %w", err)
+	}
+	if !resp.Succeeded {
+		return ErrMessageNotInFlight
+	}
+	q.revoke(ctx, msg.ID)
+	return nil
+}
+
+// Nack 放弃处理消息，消息立即重新入队至队尾，超过最大投递次数时进入死信
+func (q *EtcdReliableQueue) Nack(ctx context.Context, msg *Message) error {
+	resp, err := q.client.Get(ctx, q.inflightKey(msg.ID))
+	if err != nil {
+		return fmt.Errorf("get message failed: %w", err)
+	}
+	if len(resp.Kvs) == 0 || resp.Kvs[0].ModRevision != msg.Receipt {
+		return ErrMessageNotInFlight
+	}
+	return q.release(ctx, msg.ID, msg.Receipt, resp.Kvs[0].Value)
+}
+
+// Length 待投递的消息数
+func (q *EtcdReliableQueue) Length(ctx context.Context) (int64, error) {
+	return q.count(ctx, "/ready/")
+}
+
+// InFlight 投递中的消息数
+func (q *EtcdReliableQueue) InFlight(ctx context.Context) (int64, error) {
+	return q.count(ctx, "/inflight/")
+}
+
+func (q *EtcdReliableQueue) count(ctx context.Context, dir string) (int64, error) {
+	resp, err := q.client.Get(ctx, q.prefix+dir, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		return 0, fmt.Errorf("count messages failed: %w", err)
+	}
+	return resp.Count, nil
+}
+
+// DeadLetters 返回全部死信消息
+func (q *EtcdReliableQueue) DeadLetters(ctx context.Context) ([]*Message, error) {
+	resp, err := q.client.Get(ctx, q.prefix+"/dead/", clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByCreateRevision, clientv3.SortAscend))
+	if err != nil {
+		return nil, fmt.Errorf("get dead letters failed: %w", err)
+	}
+	msgs := make([]*Message, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var env envelope
+		if err = json.Unmarshal(kv.Value, &env); err != nil {
+			return nil, fmt.Errorf("unmarshal message failed: %w", err)
+		}
+		var value any
+		if err = json.Unmarshal(env.Data, &value); err != nil {
+			return nil, fmt.Errorf("unmarshal value failed: %w", err)
+		}
+		msgs = append(msgs, &Message{ID: env.ID, Value: value, Deliveries: env.Deliveries})
+	}
+	return msgs, nil
+}
+
+// Clear 清空队列，包括投递中的消息及死信
+func (q *EtcdReliableQueue) Clear(ctx context.Context) error {
+	if _, err := q.client.Delete(ctx, q.prefix+"/", clientv3.WithPrefix()); err != nil {
+		return fmt.Errorf("clear queue failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+// RWMutex 分布式读写锁，同一时刻允许多个读者或一个写者持有锁。
+// 读者与写者按写入 key 的版本号排队，读者需等待先于其排队的写者，写者需等待先于其排队的全部读者及写者，
+// 因此写者排队后新到的读者将等待该写者，写者不会被持续到达的读者饿死
+type RWMutex struct {
+	client  *clientv3.Client
+	pfx     string
+	session *concurrency.Session
+
+	mu    sync.Mutex // 串行化获取及释放锁的操作
+	key   string     // 当前持有锁的 key，未持有时为空
+	write bool       // 当前是否持有写锁
+}
+
+// RWMutexOption 读写锁配置项
+type RWMutexOption func(*sessionOptions)
+
+// WithRWMutexTTL 配置会话租约时长，默认为60s，会话存续期间租约将自动续期
+func WithRWMutexTTL(ttl time.Duration) RWMutexOption {
+	return func(o *sessionOptions) {
+		o.ttl = ttl
+	}
+}
+
+// NewRWMutex 创建分布式读写锁实例
+func NewRWMutex(client *clientv3.Client, pfx string, opts ...RWMutexOption) (*RWMutex, error) {
+	o := newSessionOptions(opts...)
+	session, err := newSession(context.Background(), client, o.ttl)
+	if err != nil {
+		return nil, err
+	}
+	return &RWMutex{client: client, pfx: pfx, session: session}, nil
+}
+
+// RLock 获取读锁，阻塞直至获取成功或 ctx 结束
+func (m *RWMutex) RLock(ctx context.Context) error {
+	_, err := m.acquire(ctx, false, true)
+	return err
+}
+
+// TryRLock 尝试获取读锁，立即返回结果，存在排队中或持有锁的写者时返回 false
+func (m *RWMutex) TryRLock(ctx context.Context) (bool, error) {
+	return m.acquire(ctx, false, false)
+}
+
+// RUnlock 释放读锁
+func (m *RWMutex) RUnlock(ctx context.Context) error {
+	return m.release(ctx, false)
+}
+
+// Lock 获取写锁，阻塞直至获取成功或 ctx 结束
+func (m *RWMutex) Lock(ctx context.Context) error {
+	_, err := m.acquire(ctx, true, true)
+	return err
+}
+
+// TryLock 尝试获取写锁，立即返回结果，锁被其他实例持有时返回 false
+func (m *RWMutex) TryLock(ctx context.Context) (bool, error) {
+	return m.acquire(ctx, true, false)
+}
+
+// Unlock 释放写锁
+func (m *RWMutex) Unlock(ctx context.Context) error {
+	return m.release(ctx, true)
+}
+
+// acquire 写入排队的 key 并等待先于其排队的冲突者释放锁，wait 为 false 时不等待
+func (m *RWMutex) acquire(ctx context.Context, write, wait bool) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.key != "" {
+		return false, ErrLockHeld
+	}
+
+	keyPrefix, waitPrefix := m.pfx+"/read/", m.pfx+"/write/"
+	if write {
+		keyPrefix, waitPrefix = m.pfx+"/write/", m.pfx+"/"
+	}
+	key, rev, err := putEphemeral(ctx, m.session, keyPrefix)
+	if err != nil {
+		return false, err
+	}
+	for {
+		// 查找先于当前 key 排队的最后一个冲突者
+		resp, err := m.client.Get(ctx, waitPrefix, append(clientv3.WithLastCreate(), clientv3.WithMaxCreateRev(rev-1))...)
+		if err != nil {
+			return false, abandon(ctx, m.client, key, fmt.Errorf("get waiters failed: %w", err))
+		}
+		if len(resp.Kvs) == 0 {
+			m.key, m.write = key, write
+			return true, nil
+		}
+		if !wait {
+			return false, deleteKey(ctx, m.client, key)
+		}
+		if err = waitDelete(ctx, m.client, string(resp.Kvs[0].Key), resp.Header.Revision+1); err != nil {
+			return false, abandon(ctx, m.client, key, err)
+		}
+	}
+}
+
+// release 删除持有锁的 key
+func (m *RWMutex) release(ctx context.Context, write bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.key == "" || m.write != write {
+		return ErrLockNotHeld
+	}
+	if err := deleteKey(ctx, m.client, m.key); err != nil {
+		return err
+	}
+	m.key = ""
+	return nil
+}
+
+// Close 关闭读写锁实例，释放锁并清理资源
+func (m *RWMutex) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.key = ""
+	return m.session.Close()
+}
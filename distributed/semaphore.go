@@ -0,0 +1,119 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+// EtcdSemaphore 基于 etcd 的分布式计数信号量，同一时刻最多 permits 个实例持有许可，可用于限制集群内并发执行的任务数。
+// 实例按写入 key 的版本号排队，排在前 permits 位的实例持有许可，同一 key 的全部实例需使用相同的 permits
+type EtcdSemaphore struct {
+	client  *clientv3.Client
+	pfx     string
+	permits int
+	session *concurrency.Session
+
+	mu  sync.Mutex // 串行化获取及释放许可的操作
+	key string     // 当前持有许可的 key，未持有时为空
+}
+
+// SemaphoreOption 信号量配置项
+type SemaphoreOption func(*sessionOptions)
+
+// WithSemaphoreTTL 配置会话租约时长，默认为60s，会话存续期间租约将自动续期，持有者异常退出后最长经过 ttl 许可将被释放
+func WithSemaphoreTTL(ttl time.Duration) SemaphoreOption {
+	return func(o *sessionOptions) {
+		o.ttl = ttl
+	}
+}
+
+// NewSemaphore 创建分布式信号量实例，每个实例最多持有一个许可
+func NewSemaphore(client *clientv3.Client, pfx string, permits int, opts ...SemaphoreOption) (*EtcdSemaphore, error) {
+	if permits <= 0 {
+		return nil, errors.New("semaphore permits must be positive")
+	}
+	o := newSessionOptions(opts...)
+	session, err := newSession(context.Background(), client, o.ttl)
+	if err != nil {
+		return nil, err
+	}
+	return &EtcdSemaphore{client: client, pfx: pfx, permits: permits, session: session}, nil
+}
+
+// Acquire 获取许可，阻塞直至获取成功或 ctx 结束
+func (s *EtcdSemaphore) Acquire(ctx context.Context) error {
+	_, err := s.acquire(ctx, true)
+	return err
+}
+
+// TryAcquire 尝试获取许可，立即返回结果，许可已耗尽时返回 false
+func (s *EtcdSemaphore) TryAcquire(ctx context.Context) (bool, error) {
+	return s.acquire(ctx, false)
+}
+
+func (s *EtcdSemaphore) acquire(ctx context.Context, wait bool) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.key != "" {
+		return false, ErrLockHeld
+	}
+
+	prefix := s.pfx + "/"
+	key, _, err := putEphemeral(ctx, s.session, prefix)
+	if err != nil {
+		return false, err
+	}
+	for {
+		// 按创建版本号取排在前 permits 位的实例，当前 key 位于其中时获取许可
+		resp, err := s.client.Get(ctx, prefix,
+			clientv3.WithPrefix(),
+			clientv3.WithSort(clientv3.SortByCreateRevision, clientv3.SortAscend),
+			clientv3.WithLimit(int64(s.permits)),
+			clientv3.WithKeysOnly(),
+		)
+		if err != nil {
+			return false, abandon(ctx, s.client, key, fmt.Errorf("get holders failed: %w", err))
+		}
+		if slices.ContainsFunc(resp.Kvs, func(kv *mvccpb.KeyValue) bool { return string(kv.Key) == key }) {
+			s.key = key
+			return true, nil
+		}
+		if !wait {
+			return false, deleteKey(ctx, s.client, key)
+		}
+		// 任一实例释放许可后重新统计
+		if err = waitDelete(ctx, s.client, prefix, resp.Header.Revision+1, clientv3.WithPrefix()); err != nil {
+			return false, abandon(ctx, s.client, key, err)
+		}
+	}
+}
+
+// Release 释放许可
+func (s *EtcdSemaphore) Release(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.key == "" {
+		return ErrLockNotHeld
+	}
+	if err := deleteKey(ctx, s.client, s.key); err != nil {
+		return err
+	}
+	s.key = ""
+	return nil
+}
+
+// Close 关闭信号量实例，释放许可并清理资源
+func (s *EtcdSemaphore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.key = ""
+	return s.session.Close()
+}
@@ -0,0 +1,157 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+
+	"github.com/haysons/gokit/distributed/internal/counter"
+	"go.etcd.io/etcd/client/v3"
+)
+
+// EtcdShardedCounter 基于 etcd 的分片计数器，递增操作随机分散至 shards 个 key，读取时累加全部分片，
+// 可避免高并发写入单个 key 造成的热点及 CAS 冲突，同一 key 的全部实例需使用相同的 shards
+type EtcdShardedCounter struct {
+	client *clientv3.Client
+	key    string
+	shards int
+	opts   counter.Options
+}
+
+// NewShardedCounter 创建分片计数器实例
+func NewShardedCounter(client *clientv3.Client, key string, shards int, opts ...CounterOption) (*EtcdShardedCounter, error) {
+	if shards <= 0 {
+		return nil, errors.New("counter shards must be positive")
+	}
+	return &EtcdShardedCounter{
+		client: client,
+		key:    key,
+		shards: shards,
+		opts:   counter.NewOptions(opts...),
+	}, nil
+}
+
+func (c *EtcdShardedCounter) prefix() string {
+	return c.key + "/"
+}
+
+func (c *EtcdShardedCounter) shardKey(i int) string {
+	return c.prefix() + strconv.Itoa(i)
+}
+
+// Get 获取当前计数值，即全部分片之和
+func (c *EtcdShardedCounter) Get(ctx context.Context) (int64, error) {
+	resp, err := c.client.Get(ctx, c.prefix(), clientv3.WithPrefix())
+	if err != nil {
+		return 0, fmt.Errorf("get counter failed: %w", err)
+	}
+	return sumCounters(resp)
+}
+
+// Incr 原子加1，返回新值
+func (c *EtcdShardedCounter) Incr(ctx context.Context) (int64, error) {
+	return c.IncrBy(ctx, 1)
+}
+
+// IncrBy 随机选择分片原子增加 delta，返回写入后全部分片之和，并发冲突且重试次数耗尽时返回 ErrConflict
+func (c *EtcdShardedCounter) IncrBy(ctx context.Context, delta int64) (int64, error) {
+	_, txnResp, err := cas(ctx, c.client, c.opts, casRequest{
+		key:    c.shardKey(rand.N(c.shards)),
+		update: func(cur int64) int64 { return cur + delta },
+		then:   []clientv3.Op{clientv3.OpGet(c.prefix(), clientv3.WithPrefix())},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return sumCounters((*clientv3.GetResponse)(txnResp.Responses[1].GetResponseRange()))
+}
+
+// Decr 原子减1，返回新值
+func (c *EtcdShardedCounter) Decr(ctx context.Context) (int64, error) {
+	return c.DecrBy(ctx, 1)
+}
+
+// DecrBy 原子减少 delta，返回新值
+func (c *EtcdShardedCounter) DecrBy(ctx context.Context, delta int64) (int64, error) {
+	return c.IncrBy(ctx, -delta)
+}
+
+// Set 设置计数值，清空全部分片后写入首个分片
+func (c *EtcdShardedCounter) Set(ctx context.Context, value int64) error {
+	_, err := c.client.Txn(ctx).Then(c.setOps(value)...).Commit()
+	if err != nil {
+		return fmt.Errorf("set counter failed: %w", err)
+	}
+	return nil
+}
+
+// setOps 将首个分片写为 value 并删除其余分片，同一事务中的删除范围不能包含写入的 key
+func (c *EtcdShardedCounter) setOps(value int64) []clientv3.Op {
+	first := c.shardKey(0)
+	return []clientv3.Op{
+		clientv3.OpDelete(c.prefix(), clientv3.WithRange(first)),
+		clientv3.OpDelete(first+"\x00", clientv3.WithRange(clientv3.GetPrefixRangeEnd(c.prefix()))),
+		clientv3.OpPut(first, strconv.FormatInt(value, 10)),
+	}
+}
+
+// CompareAndSet 当前计数值等于 old 时设置为 value，返回是否设置成功，分片被并发修改时重新比较
+func (c *EtcdShardedCounter) CompareAndSet(ctx context.Context, old, value int64) (bool, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.client.Get(ctx, c.prefix(), clientv3.WithPrefix())
+		if err != nil {
+			return false, fmt.Errorf("get counter failed: %w", err)
+		}
+		sum, err := sumCounters(resp)
+		if err != nil {
+			return false, err
+		}
+		if sum != old {
+			return false, nil
+		}
+
+		// 全部分片均未被修改、删除且未新增分片时写入
+		cmps := []clientv3.Cmp{clientv3.Compare(clientv3.ModRevision(c.prefix()).WithPrefix(), "<", resp.Header.Revision+1)}
+		for _, kv := range resp.Kvs {
+			cmps = append(cmps, clientv3.Compare(clientv3.ModRevision(string(kv.Key)), "=", kv.ModRevision))
+		}
+		txnResp, err := c.client.Txn(ctx).
+			If(cmps...).
+			Then(c.setOps(value)...).
+			Commit()
+		if err != nil {
+			return false, fmt.Errorf("compare and set counter failed: %w", err)
+		}
+		if txnResp.Succeeded {
+			return true, nil
+		}
+		if attempt >= c.opts.Retries {
+			return false, ErrConflict
+		}
+		if err = c.opts.Wait(ctx, attempt); err != nil {
+			return false, err
+		}
+	}
+}
+
+// GetAndReset 原子地获取当前计数值并重置为0
+func (c *EtcdShardedCounter) GetAndReset(ctx context.Context) (int64, error) {
+	resp, err := c.client.Txn(ctx).Then(
+		clientv3.OpGet(c.prefix(), clientv3.WithPrefix()),
+		clientv3.OpDelete(c.prefix(), clientv3.WithPrefix()),
+	).Commit()
+	if err != nil {
+		return 0, fmt.Errorf("get and reset counter failed: %w", err)
+	}
+	return sumCounters((*clientv3.GetResponse)(resp.Responses[0].GetResponseRange()))
+}
+
+// Reset 重置计数值为0
+func (c *EtcdShardedCounter) Reset(ctx context.Context) error {
+	if _, err := c.client.Delete(ctx, c.prefix(), clientv3.WithPrefix()); err != nil {
+		return fmt.Errorf("reset counter failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package distributed
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/haysons/gokit/distributed/internal/queue"
+	"github.com/haysons/gokit/util/uid"
+	"go.etcd.io/etcd/client/v3"
+)
+
+// TypedQueueOption 泛型队列配置项
+type TypedQueueOption func(*queue.TypedOptions)
+
+// WithCodec 配置元素编解码器，默认为 JSONCodec
+func WithCodec(codec Codec) TypedQueueOption {
+	return func(o *queue.TypedOptions) {
+		o.Codec = codec
+	}
+}
+
+// WithPriorityLevels 配置优先级数量，默认为3，最大为100
+func WithPriorityLevels(n int) TypedQueueOption {
+	return func(o *queue.TypedOptions) {
+		o.PriorityLevels = n
+	}
+}
+
+// EnqueueOption 入队配置项
+type EnqueueOption func(*queue.EnqueueOptions)
+
+// WithPriority 配置元素优先级，默认为0，即最低优先级，超出范围时取最近的有效值
+func WithPriority(priority int) EnqueueOption {
+	return func(o *queue.EnqueueOptions) {
+		o.Priority = priority
+	}
+}
+
+// WithDelay 配置元素延迟 d 后可见
+func WithDelay(d time.Duration) EnqueueOption {
+	return func(o *queue.EnqueueOptions) {
+		o.VisibleAt = time.Now().Add(d)
+	}
+}
+
+// WithDeliverAt 配置元素在 t 时刻可见
+func WithDeliverAt(t time.Time) EnqueueOption {
+	return func(o *queue.EnqueueOptions) {
+		o.VisibleAt = t
+	}
+}
+
+// EtcdTypedQueue 基于 etcd 的泛型队列，支持可插拔的编解码器、优先级及延迟投递。
+// 元素 key 以 xid 保证全局唯一，同一优先级内按 etcd 版本号严格先进先出，高优先级的元素先出队。
+// 存储布局如下：
+//
+//	{prefix}/ready/{priority}/{id}               可出队的元素
+//	{prefix}/delayed/{visibleAt}/{priority}/{id} 延迟的元素，到期后移至 ready
+type EtcdTypedQueue[T any] struct {
+	client *clientv3.Client
+	prefix string
+	opts   queue.TypedOptions
+}
+
+// NewTypedQueue 创建泛型队列实例
+func NewTypedQueue[T any](client *clientv3.Client, prefix string, opts ...TypedQueueOption) *EtcdTypedQueue[T] {
+	return &EtcdTypedQueue[T]{
+		client: client,
+		prefix: prefix,
+		opts:   queue.NewTypedOptions(opts...),
+	}
+}
+
+func (q *EtcdTypedQueue[T]) readyPrefix(priority int) string {
+	return fmt.Sprintf("%s/ready/%02d/", q.prefix, priority)
+}
+
+func (q *EtcdTypedQueue[T]) delayedKey(visibleAt int64, priority int, id string) string {
+	return fmt.Sprintf("%s/delayed/%020d/%02d/%s", q.prefix, visibleAt, priority, id)
+}
+
+// Enqueue 入队，可通过 WithPriority 指定优先级，通过 WithDelay 或 WithDeliverAt 延迟投递
+func (q *EtcdTypedQueue[T]) Enqueue(ctx context.Context, value T, opts ...EnqueueOption) error {
+	o := queue.NewEnqueueOptions(q.opts.PriorityLevels, opts...)
+	data, err := q.opts.Codec.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal value failed: %w", err)
+	}
+	key := q.readyPrefix(o.Priority) + uid.XID()
+	if o.Delayed() {
+		key = q.delayedKey(o.VisibleAt.UnixNano(), o.Priority, uid.XID())
+	}
+	if _, err = q.client.Put(ctx, key, string(data)); err != nil {
+		return fmt.Errorf("enqueue failed: %w", err)
+	}
+	return nil
+}
+
+// Dequeue 出队优先级最高的元素中最早入队的元素，队列为空时阻塞直至有元素可见，超过 timeout 时返回错误
+func (q *EtcdTypedQueue[T]) Dequeue(ctx context.Context, timeout time.Duration) (T, error) {
+	var zero T
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		// 将已到期的延迟元素移至 ready
+		next, rev, err := q.promote(ctx)
+		if err != nil {
+			return zero, err
+		}
+
+		ops := make([]clientv3.Op, 0, q.opts.PriorityLevels)
+		for p := q.opts.PriorityLevels - 1; p >= 0; p-- {
+			ops = append(ops, clientv3.OpGet(q.readyPrefix(p), clientv3.WithFirstCreate()...))
+		}
+		resp, err := q.client.Txn(ctx).Then(ops...).Commit()
+		if err != nil {
+			return zero, fmt.Errorf("get queue elements failed: %w", err)
+		}
+		for _, r := range resp.Responses {
+			kvs := r.GetResponseRange().Kvs
+			if len(kvs) == 0 {
+				continue
+			}
+			// 删除成功即出队成功，防止并发重复消费
+			key := string(kvs[0].Key)
+			delResp, err := q.client.Txn(ctx).
+				If(clientv3.Compare(clientv3.ModRevision(key), "=", kvs[0].ModRevision)).
+				Then(clientv3.OpDelete(key)).
+				Commit()
+			if err != nil {
+				return zero, fmt.Errorf("dequeue transaction failed: %w", err)
+			}
+			if !delResp.Succeeded {
+				break
+			}
+			var value T
+			if err = q.opts.Codec.Unmarshal(kvs[0].Value, &value); err != nil {
+				return zero, fmt.Errorf("unmarshal value failed: %w", err)
+			}
+			return value, nil
+		}
+		if hasElement(resp) {
+			// 元素已被其他消费者取走，立即重试
+			continue
+		}
+
+		// 队列为空，监听元素入队，存在延迟元素时最多等待至其到期
+		waitCtx, waitCancel := ctx, context.CancelFunc(func() {})
+		if !next.IsZero() {
+			waitCtx, waitCancel = context.WithDeadline(ctx, next)
+		}
+		err = waitPut(waitCtx, q.client, q.prefix+"/", rev+1)
+		waitCancel()
+		if err != nil && ctx.Err() != nil {
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// hasElement 各优先级中是否存在元素
+func hasElement(resp *clientv3.TxnResponse) bool {
+	for _, r := range resp.Responses {
+		if len(r.GetResponseRange().Kvs) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// promote 将已到期的延迟元素移至 ready，返回最早的未到期元素的可见时间（不存在时为零值）及检查时的版本号
+func (q *EtcdTypedQueue[T]) promote(ctx context.Context) (time.Time, int64, error) {
+	delayedPrefix := q.prefix + "/delayed/"
+	end := fmt.Sprintf("%s%020d", delayedPrefix, time.Now().UnixNano()+1)
+	resp, err := q.client.Txn(ctx).Then(
+		clientv3.OpGet(delayedPrefix, clientv3.WithRange(end)),
+		clientv3.OpGet(end, clientv3.WithRange(clientv3.GetPrefixRangeEnd(delayedPrefix)), clientv3.WithLimit(1), clientv3.WithKeysOnly()),
+	).Commit()
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("get delayed elements failed: %w", err)
+	}
+	for _, kv := range resp.Responses[0].GetResponseRange().Kvs {
+		key := string(kv.Key)
+		_, priority, id, err := parseDelayedKey(strings.TrimPrefix(key, delayedPrefix))
+		if err != nil {
+			return time.Time{}, 0, err
+		}
+		// 元素可能已被其他消费者移动
+		_, err = q.client.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(key), "=", kv.ModRevision)).
+			Then(clientv3.OpDelete(key), clientv3.OpPut(q.readyPrefix(priority)+id, string(kv.Value))).
+			Commit()
+		if err != nil {
+			return time.Time{}, 0, fmt.Errorf("promote delayed element failed: %w", err)
+		}
+	}
+	if kvs := resp.Responses[1].GetResponseRange().Kvs; len(kvs) > 0 {
+		visibleAt, _, _, err := parseDelayedKey(strings.TrimPrefix(string(kvs[0].Key), delayedPrefix))
+		if err != nil {
+			return time.Time{}, 0, err
+		}
+		return time.Unix(0, visibleAt), resp.Header.Revision, nil
+	}
+	return time.Time{}, resp.Header.Revision, nil
+}
+
+// parseDelayedKey 解析 {visibleAt}/{priority}/{id} 格式的延迟元素 key
+func parseDelayedKey(key string) (visibleAt int64, priority int, id string, err error) {
+	parts := strings.SplitN(key, "/", 3)
+	if len(parts) != 3 {
+		return 0, 0, "", fmt.Errorf("invalid delayed key %q", key)
+	}
+	if visibleAt, err = strconv.ParseInt(parts[0], 10, 64); err != nil {
+		return 0, 0, "", fmt.Errorf("invalid delayed key %q: %w", key, err)
+	}
+	if priority, err = strconv.Atoi(parts[1]); err != nil {
+		return 0, 0, "", fmt.Errorf("invalid delayed key %q: %w", key, err)
+	}
+	return visibleAt, priority, parts[2], nil
+}
+
+// Length 队列长度，包含尚未到期的延迟元素
+func (q *EtcdTypedQueue[T]) Length(ctx context.Context) (int64, error) {
+	resp, err := q.client.Get(ctx, q.prefix+"/", clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		return 0, fmt.Errorf("get queue length failed: %w", err)
+	}
+	return resp.Count, nil
+}
+
+// Clear 清空队列
+func (q *EtcdTypedQueue[T]) Clear(ctx context.Context) error {
+	if _, err := q.client.Delete(ctx, q.prefix+"/", clientv3.WithPrefix()); err != nil {
+		return fmt.Errorf("clear queue failed: %w", err)
+	}
+	return nil
+}
@@ -1,7 +1,14 @@
 package errors
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+
 	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/errbase"
+	"github.com/cockroachdb/errors/markers"
+	"github.com/gogo/protobuf/proto"
 )
 
 // WithHint 为错误附加提示信息，提示信息一般用于直接展示给用户
@@ -32,3 +39,72 @@ func GetAllHints(err error) []string {
 func FlattenHints(err error) string {
 	return errors.FlattenHints(err)
 }
+
+// WithHintID 为错误附加待本地化的提示信息，id 为消息目录中的消息 id，args 为消息模板的参数，
+// 响应时由 i18n 包根据请求的语言解析为提示信息。消息 id 本身不作为提示信息，需兜底时可先通过 WithHint 附加兜底语言的提示信息，
+// 未经本地化时 GetHint 返回兜底的提示信息，本地化后返回请求语言的提示信息
+func WithHintID(err error, id string, args map[string]any) error {
+	if err == nil {
+		return nil
+	}
+	return &withHintID{cause: err, id: id, args: args}
+}
+
+// GetHintID 获取错误中最后附加的待本地化提示信息
+func GetHintID(err error) (id string, args map[string]any, ok bool) {
+	if err == nil {
+		return "", nil, false
+	}
+	if v, found := markers.If(err, func(err error) (any, bool) {
+		if w, ok := err.(*withHintID); ok {
+			return w, true
+		}
+		return nil, false
+	}); found {
+		w := v.(*withHintID)
+		return w.id, w.args, true
+	}
+	return "", nil, false
+}
+
+// withHintID 使用待本地化的提示信息包装一个错误
+type withHintID struct {
+	cause error
+	id    string
+	args  map[string]any
+}
+
+func (w *withHintID) Error() string { return w.cause.Error() }
+
+func (w *withHintID) Cause() error { return w.cause }
+
+func (w *withHintID) Unwrap() error { return w.cause }
+
+func (w *withHintID) Format(s fmt.State, verb rune) { errors.FormatError(w, s, verb) }
+
+func encodeWithHintID(_ context.Context, err error) (string, []string, proto.Message) {
+	w := err.(*withHintID)
+	details := []string{w.id}
+	if len(w.args) > 0 {
+		if b, err := json.Marshal(w.args); err == nil {
+			details = append(details, string(b))
+		}
+	}
+	return "", details, nil
+}
+
+func decodeWithHintID(_ context.Context, cause error, _ string, details []string, _ proto.Message) error {
+	if len(details) == 0 {
+		return cause
+	}
+	w := &withHintID{cause: cause, id: details[0]}
+	if len(details) > 1 {
+		_ = json.Unmarshal([]byte(details[1]), &w.args)
+	}
+	return w
+}
+
+func init() {
+	errbase.RegisterWrapperEncoder(errbase.GetTypeKey((*withHintID)(nil)), encodeWithHintID)
+	errbase.RegisterWrapperDecoder(errbase.GetTypeKey((*withHintID)(nil)), decodeWithHintID)
+}
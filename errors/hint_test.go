@@ -1,10 +1,12 @@
 package errors
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHints(t *testing.T) {
@@ -55,3 +57,31 @@ func TestMultipleHints(t *testing.T) {
 	hint = GetHint(errors.New("some other error"))
 	assert.Equal(t, "", hint)
 }
+
+func TestHintID(t *testing.T) {
+	assert.Nil(t, WithHintID(nil, "user.not_found", nil))
+	_, _, ok := GetHintID(New("plain"))
+	assert.False(t, ok)
+
+	err := WithHintID(New("quota exceeded"), "user.quota", map[string]any{"Limit": 10})
+	err = Wrap(err, "create order")
+	id, args, ok := GetHintID(err)
+	require.True(t, ok)
+	assert.Equal(t, "user.quota", id)
+	assert.Equal(t, map[string]any{"Limit": 10}, args)
+	// 消息 id 不作为提示信息
+	assert.Empty(t, GetHint(err))
+	assert.Equal(t, "create order: quota exceeded", err.Error())
+
+	// 未经本地化时返回兜底的提示信息，本地化后附加的提示信息优先
+	fallback := WithHintID(WithHint(New("quota exceeded"), "超出配额"), "user.quota", nil)
+	assert.Equal(t, "超出配额", GetHint(fallback))
+	assert.Equal(t, "Quota exceeded", GetHint(WithHint(fallback, "Quota exceeded")))
+
+	// 跨网络传输后仍可获取消息 id 及参数
+	decoded := DecodeError(context.Background(), EncodeError(context.Background(), err))
+	id, args, ok = GetHintID(decoded)
+	require.True(t, ok)
+	assert.Equal(t, "user.quota", id)
+	assert.EqualValues(t, 10, args["Limit"])
+}
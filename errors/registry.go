@@ -0,0 +1,176 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"google.golang.org/grpc/codes"
+)
+
+// Definition 错误码的定义，每个错误码仅在注册时声明一次，此后通过 New、Wrap 或 FromCode 构造错误
+type Definition struct {
+	Code     int        // 业务状态码，全局唯一
+	Message  string     // 默认错误信息，面向开发者
+	Hint     string     // 提示信息，面向用户
+	HintID   string     // 待本地化的提示信息 id，响应时根据请求的语言解析，未经本地化时提示信息为 Hint
+	HttpCode int        // http 状态码，为0时默认为500
+	GrpcCode codes.Code // grpc 状态码，为 codes.OK 时默认为 codes.Unknown
+	Module   string     // 所属模块，导出目录时用于分组
+}
+
+var registry = struct {
+	sync.RWMutex
+	defs map[int]*Definition
+}{defs: make(map[int]*Definition)}
+
+// Register 注册错误码，一般在包级变量初始化时调用，code 重复或为0时 panic，以便在启动时发现冲突
+func Register(def Definition) *Definition {
+	if def.Code == 0 {
+		panic("errors: register code 0")
+	}
+	if def.HttpCode == 0 {
+		def.HttpCode = http.StatusInternalServerError
+	}
+	if def.GrpcCode == codes.OK {
+		def.GrpcCode = codes.Unknown
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	if exist, ok := registry.defs[def.Code]; ok {
+		panic(fmt.Sprintf("errors: duplicate code %d: %q already registered", def.Code, exist.Message))
+	}
+	d := &def
+	registry.defs[def.Code] = d
+	return d
+}
+
+// Lookup 查找已注册的错误码
+func Lookup(code int) (*Definition, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	d, ok := registry.defs[code]
+	return d, ok
+}
+
+// FromCode 根据已注册的错误码构造错误，错误码未注册时返回携带该错误码的未知错误
+func FromCode(code int) error {
+	if d, ok := Lookup(code); ok {
+		return d.build(errors.NewWithDepth(1, d.Message))
+	}
+	err := errors.NewWithDepthf(1, "unknown error code %d", code)
+	err = WithCode(err, code)
+	err = WithHttpCode(err, http.StatusInternalServerError)
+	return WithGrpcCode(err, codes.Unknown)
+}
+
+// Catalog 返回全部已注册的错误码，按错误码升序排列
+func Catalog() []Definition {
+	registry.RLock()
+	defs := make([]Definition, 0, len(registry.defs))
+	for _, d := range registry.defs {
+		defs = append(defs, *d)
+	}
+	registry.RUnlock()
+	slices.SortFunc(defs, func(a, b Definition) int { return a.Code - b.Code })
+	return defs
+}
+
+// New 使用默认错误信息构造错误
+func (d *Definition) New() error {
+	return d.build(errors.NewWithDepth(1, d.Message))
+}
+
+// Newf 使用格式化的错误信息构造错误，替换默认错误信息
+func (d *Definition) Newf(format string, args ...any) error {
+	return d.build(errors.NewWithDepthf(1, format, args...))
+}
+
+// Wrap 使用默认错误信息作为前缀包装 err，并附加错误码，err 为 nil 时返回 nil
+func (d *Definition) Wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	return d.build(errors.WrapWithDepth(1, err, d.Message))
+}
+
+// Is 判断错误是否携带该错误码
+func (d *Definition) Is(err error) bool {
+	return err != nil && GetCode(err) == d.Code
+}
+
+func (d *Definition) build(err error) error {
+	err = WithCode(err, d.Code)
+	err = WithHttpCode(err, d.HttpCode)
+	err = WithGrpcCode(err, d.GrpcCode)
+	if d.Hint != "" {
+		err = WithHint(err, d.Hint)
+	}
+	if d.HintID != "" {
+		err = WithHintID(err, d.HintID, nil)
+	}
+	return err
+}
+
+// catalogEntry 导出的错误码
+type catalogEntry struct {
+	Code     int    `json:"code"`
+	Module   string `json:"module,omitempty"`
+	Message  string `json:"message"`
+	Hint     string `json:"hint,omitempty"`
+	HintID   string `json:"hint_id,omitempty"`
+	HttpCode int    `json:"http_code"`
+	GrpcCode string `json:"grpc_code"`
+}
+
+func catalogEntries() []catalogEntry {
+	defs := Catalog()
+	entries := make([]catalogEntry, 0, len(defs))
+	for _, d := range defs {
+		entries = append(entries, catalogEntry{
+			Code:     d.Code,
+			Module:   d.Module,
+			Message:  d.Message,
+			Hint:     d.Hint,
+			HintID:   d.HintID,
+			HttpCode: d.HttpCode,
+			GrpcCode: d.GrpcCode.String(),
+		})
+	}
+	return entries
+}
+
+// ExportJSON 将全部已注册的错误码以 json 数组导出，用于生成接口文档
+func ExportJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(catalogEntries())
+}
+
+// ExportMarkdown 将全部已注册的错误码以 markdown 表格导出，用于生成接口文档
+func ExportMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("| Code | Module | HTTP | gRPC | Message | Hint |\n")
+	b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for _, e := range catalogEntries() {
+		// 未声明默认提示信息时展示提示信息 id
+		hint := e.Hint
+		if hint == "" {
+			hint = e.HintID
+		}
+		fmt.Fprintf(&b, "| %d | %s | %d | %s | %s | %s |\n",
+			e.Code, escapeCell(e.Module), e.HttpCode, e.GrpcCode, escapeCell(e.Message), escapeCell(hint))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escapeCell 转义 markdown 表格单元格中的竖线及换行
+func escapeCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
@@ -0,0 +1,114 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	errUserNotFound = Register(Definition{
+		Code:     990001,
+		Message:  "user not found",
+		Hint:     "用户不存在",
+		HttpCode: http.StatusNotFound,
+		GrpcCode: codes.NotFound,
+		Module:   "user",
+	})
+	errInternal = Register(Definition{Code: 990002, Message: "internal | error"})
+	errQuota    = Register(Definition{Code: 990003, Message: "quota exceeded", HintID: "user.quota", Module: "user"})
+	errBalance  = Register(Definition{Code: 990004, Message: "insufficient balance", Hint: "余额不足", HintID: "user.balance"})
+)
+
+func TestRegister(t *testing.T) {
+	err := errUserNotFound.New()
+	assert.Equal(t, "user not found", Cause(err).Error())
+	assert.Equal(t, 990001, GetCode(err))
+	assert.Equal(t, http.StatusNotFound, GetHttpCode(err, 0))
+	assert.Equal(t, codes.NotFound, GetGrpcCode(err))
+	assert.Equal(t, "用户不存在", GetHint(err))
+	assert.True(t, errUserNotFound.Is(err))
+	assert.False(t, errInternal.Is(err))
+
+	// 未指定时使用默认的 http 及 grpc 状态码
+	err = errInternal.Newf("internal error: %d", 42)
+	assert.Contains(t, err.Error(), "internal error: 42")
+	assert.Equal(t, http.StatusInternalServerError, GetHttpCode(err, 0))
+	assert.Equal(t, codes.Unknown, GetGrpcCode(err))
+	assert.Empty(t, GetHint(err))
+
+	// 声明提示信息 id 时附加待本地化的提示信息
+	id, _, ok := GetHintID(errQuota.New())
+	assert.True(t, ok)
+	assert.Equal(t, "user.quota", id)
+	assert.Empty(t, GetHint(errQuota.New()))
+
+	// 同时声明提示信息时，未经本地化的错误使用提示信息兜底
+	err = errBalance.New()
+	assert.Equal(t, "余额不足", GetHint(err))
+	id, _, _ = GetHintID(err)
+	assert.Equal(t, "user.balance", id)
+
+	assert.PanicsWithValue(t, `errors: duplicate code 990001: "user not found" already registered`, func() {
+		Register(Definition{Code: 990001, Message: "duplicate"})
+	})
+	assert.Panics(t, func() { Register(Definition{Message: "zero"}) })
+}
+
+func TestDefinition_Wrap(t *testing.T) {
+	base := New("record not found")
+	err := errUserNotFound.Wrap(base)
+	assert.Contains(t, err.Error(), "user not found: record not found")
+	assert.True(t, Is(err, base))
+	assert.True(t, errUserNotFound.Is(err))
+	assert.Nil(t, errUserNotFound.Wrap(nil))
+}
+
+func TestFromCode(t *testing.T) {
+	err := FromCode(990001)
+	assert.True(t, errUserNotFound.Is(err))
+	assert.Equal(t, "用户不存在", GetHint(err))
+
+	err = FromCode(990999)
+	assert.Equal(t, 990999, GetCode(err))
+	assert.Contains(t, err.Error(), "unknown error code 990999")
+	assert.Equal(t, http.StatusInternalServerError, GetHttpCode(err, 0))
+
+	d, ok := Lookup(990002)
+	require.True(t, ok)
+	assert.Equal(t, errInternal, d)
+}
+
+func TestCatalog(t *testing.T) {
+	defs := Catalog()
+	for i := 1; i < len(defs); i++ {
+		assert.Less(t, defs[i-1].Code, defs[i].Code)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, ExportJSON(&buf))
+	var entries []map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entries))
+	var found map[string]any
+	for _, e := range entries {
+		if e["code"] == float64(990001) {
+			found = e
+		}
+	}
+	require.NotNil(t, found)
+	assert.Equal(t, "NotFound", found["grpc_code"])
+	assert.EqualValues(t, http.StatusNotFound, found["http_code"])
+	assert.Equal(t, "user", found["module"])
+	assert.NotContains(t, found, "hint_id")
+
+	buf.Reset()
+	require.NoError(t, ExportMarkdown(&buf))
+	assert.Contains(t, buf.String(), "| 990001 | user | 404 | NotFound | user not found | 用户不存在 |\n")
+	assert.Contains(t, buf.String(), `| 990002 |  | 500 | Unknown | internal \| error |  |`)
+	assert.Contains(t, buf.String(), "| 990003 | user | 500 | Unknown | quota exceeded | user.quota |\n")
+}
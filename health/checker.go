@@ -0,0 +1,30 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// EtcdChecker etcd 连通性检查，通过读取一个 key 判断 etcd 集群是否可用
+func EtcdChecker(client *clientv3.Client) Checker {
+	return func(ctx context.Context) error {
+		if _, err := client.Get(ctx, "health"); err != nil {
+			return fmt.Errorf("etcd unavailable: %w", err)
+		}
+		return nil
+	}
+}
+
+// StatusReporter 可报告自身状态的组件，如 distributed.Election
+type StatusReporter interface {
+	Status() error
+}
+
+// ElectionChecker 选举状态检查，选举未就绪或选举会话失效时视为不健康
+func ElectionChecker(el StatusReporter) Checker {
+	return func(context.Context) error {
+		return el.Status()
+	}
+}
@@ -0,0 +1,110 @@
+package health
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// defaultWatchInterval Watch 接口重新执行检查的默认间隔
+const defaultWatchInterval = 5 * time.Second
+
+var _ healthpb.HealthServer = (*GRPCServer)(nil)
+
+// GRPCServer 基于 Registry 实现的 grpc.health.v1 健康检查服务，
+// 服务名为空时返回就绪检查的整体结果，否则返回同名检查项的结果
+type GRPCServer struct {
+	healthpb.UnimplementedHealthServer
+
+	registry      *Registry
+	watchInterval time.Duration
+}
+
+// GRPCOption grpc 健康检查服务配置
+type GRPCOption func(*GRPCServer)
+
+// WithWatchInterval 配置 Watch 接口重新执行检查的间隔，默认为5s
+func WithWatchInterval(interval time.Duration) GRPCOption {
+	return func(s *GRPCServer) {
+		s.watchInterval = interval
+	}
+}
+
+// NewGRPCServer 创建 grpc 健康检查服务，可通过 transport/grpc.WithHealthServer 注册至 grpc 服务器
+func NewGRPCServer(registry *Registry, opts ...GRPCOption) *GRPCServer {
+	s := &GRPCServer{
+		registry:      registry,
+		watchInterval: defaultWatchInterval,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Check 执行健康检查
+func (s *GRPCServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	st, ok := s.servingStatus(ctx, req.GetService())
+	if !ok {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+	return &healthpb.HealthCheckResponse{Status: st}, nil
+}
+
+// List 返回整体及全部检查项的健康状态
+func (s *GRPCServer) List(ctx context.Context, _ *healthpb.HealthListRequest) (*healthpb.HealthListResponse, error) {
+	report := s.registry.Readiness(ctx)
+	statuses := make(map[string]*healthpb.HealthCheckResponse, len(report.Checks)+1)
+	statuses[""] = &healthpb.HealthCheckResponse{Status: toServingStatus(report.Status)}
+	for name, result := range report.Checks {
+		statuses[name] = &healthpb.HealthCheckResponse{Status: toServingStatus(result.Status)}
+	}
+	return &healthpb.HealthListResponse{Statuses: statuses}, nil
+}
+
+// Watch 定期执行健康检查，健康状态发生变化时推送至客户端
+func (s *GRPCServer) Watch(req *healthpb.HealthCheckRequest, stream grpc.ServerStreamingServer[healthpb.HealthCheckResponse]) error {
+	ticker := time.NewTicker(s.watchInterval)
+	defer ticker.Stop()
+
+	var lastStatus healthpb.HealthCheckResponse_ServingStatus = -1
+	for {
+		st, ok := s.servingStatus(stream.Context(), req.GetService())
+		if !ok {
+			st = healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+		if st != lastStatus {
+			lastStatus = st
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: st}); err != nil {
+				return status.Error(codes.Canceled, "stream has ended")
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-stream.Context().Done():
+			return status.Error(codes.Canceled, "stream has ended")
+		}
+	}
+}
+
+func (s *GRPCServer) servingStatus(ctx context.Context, service string) (healthpb.HealthCheckResponse_ServingStatus, bool) {
+	if service == "" {
+		return toServingStatus(s.registry.Readiness(ctx).Status), true
+	}
+	result, ok := s.registry.Check(ctx, service)
+	if !ok {
+		return healthpb.HealthCheckResponse_SERVICE_UNKNOWN, false
+	}
+	return toServingStatus(result.Status), true
+}
+
+func toServingStatus(st Status) healthpb.HealthCheckResponse_ServingStatus {
+	if st == StatusUp {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
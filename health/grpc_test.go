@@ -0,0 +1,53 @@
+package health_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/haysons/gokit/health"
+	gokitgrpc "github.com/haysons/gokit/transport/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestGRPCServer_Watch(t *testing.T) {
+	registry := health.NewRegistry()
+	registry.Register("db", func(context.Context) error { return nil })
+
+	srv := gokitgrpc.NewServer(
+		gokitgrpc.WithAddr("127.0.0.1:18089"),
+		gokitgrpc.WithHealthServer(health.NewGRPCServer(registry, health.WithWatchInterval(50*time.Millisecond))),
+	)
+	go func() {
+		_ = srv.Start(context.Background())
+	}()
+	<-srv.Ready()
+	defer srv.Stop(context.Background())
+
+	conn, err := grpc.NewClient("127.0.0.1:18089", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	// 进入停止阶段后推送 NOT_SERVING
+	registry.Shutdown()
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+}
@@ -0,0 +1,231 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultCheckTimeout 单个检查项的默认超时时间
+const defaultCheckTimeout = 3 * time.Second
+
+// ErrShutdown 应用进入停止阶段后，就绪检查将返回此错误
+var ErrShutdown = errors.New("health: shutting down")
+
+// Checker 健康检查函数，返回 nil 表示健康
+type Checker func(ctx context.Context) error
+
+// Status 健康状态
+type Status string
+
+const (
+	StatusUp   Status = "UP"
+	StatusDown Status = "DOWN"
+)
+
+// CheckResult 单个检查项的检查结果
+type CheckResult struct {
+	Status   Status        `json:"status"`
+	Critical bool          `json:"critical"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Report 一次健康检查的整体结果，任一关键检查项失败时整体状态为 DOWN，非关键检查项失败仅体现在检查项结果中
+type Report struct {
+	Status Status                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Healthy 整体状态是否健康
+func (r Report) Healthy() bool {
+	return r.Status == StatusUp
+}
+
+// CheckOption 检查项配置
+type CheckOption func(*check)
+
+// WithTimeout 配置检查项的超时时间，默认为3s
+func WithTimeout(timeout time.Duration) CheckOption {
+	return func(c *check) {
+		c.timeout = timeout
+	}
+}
+
+// WithCritical 配置检查项是否为关键检查项，默认为关键检查项，仅关键检查项失败会导致整体状态为 DOWN
+func WithCritical(critical bool) CheckOption {
+	return func(c *check) {
+		c.critical = critical
+	}
+}
+
+// WithLiveness 检查项同时参与存活检查，默认仅参与就绪检查。
+// 存活检查失败一般会导致进程被重启，故仅应将进程自身无法恢复的异常作为存活检查项
+func WithLiveness() CheckOption {
+	return func(c *check) {
+		c.liveness = true
+	}
+}
+
+type check struct {
+	name     string
+	checker  Checker
+	timeout  time.Duration
+	critical bool
+	liveness bool
+}
+
+// Registry 健康检查项的注册中心，提供存活检查（liveness）及就绪检查（readiness）
+type Registry struct {
+	mu       sync.RWMutex
+	checks   map[string]*check
+	shutdown atomic.Bool
+}
+
+// NewRegistry 创建健康检查注册中心
+func NewRegistry() *Registry {
+	return &Registry{
+		checks: make(map[string]*check),
+	}
+}
+
+// Register 注册检查项，同名检查项将被覆盖
+func (r *Registry) Register(name string, checker Checker, opts ...CheckOption) {
+	c := &check{
+		name:     name,
+		checker:  checker,
+		timeout:  defaultCheckTimeout,
+		critical: true,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	r.mu.Lock()
+	r.checks[name] = c
+	r.mu.Unlock()
+}
+
+// Unregister 移除检查项
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	delete(r.checks, name)
+	r.mu.Unlock()
+}
+
+// Names 获取全部检查项名称
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.checks))
+	for name := range r.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Shutdown 标记应用进入停止阶段，此后就绪检查将始终失败，以便负载均衡摘除流量
+func (r *Registry) Shutdown() {
+	r.shutdown.Store(true)
+}
+
+// Resume 取消停止阶段标记
+func (r *Registry) Resume() {
+	r.shutdown.Store(false)
+}
+
+// Liveness 执行存活检查，仅执行通过 WithLiveness 注册的检查项
+func (r *Registry) Liveness(ctx context.Context) Report {
+	return r.run(ctx, func(c *check) bool { return c.liveness })
+}
+
+// Readiness 执行就绪检查，执行全部检查项
+func (r *Registry) Readiness(ctx context.Context) Report {
+	report := r.run(ctx, func(*check) bool { return true })
+	if r.shutdown.Load() {
+		report.Status = StatusDown
+	}
+	return report
+}
+
+// Check 执行单个检查项，检查项不存在时 ok 为 false
+func (r *Registry) Check(ctx context.Context, name string) (result CheckResult, ok bool) {
+	r.mu.RLock()
+	c, ok := r.checks[name]
+	r.mu.RUnlock()
+	if !ok {
+		return CheckResult{}, false
+	}
+	return c.run(ctx), true
+}
+
+// run 并发执行满足条件的检查项
+func (r *Registry) run(ctx context.Context, filter func(*check) bool) Report {
+	r.mu.RLock()
+	checks := make([]*check, 0, len(r.checks))
+	for _, c := range r.checks {
+		if filter(c) {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = c.run(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(checks))}
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+		if results[i].Status == StatusDown && c.critical {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+// run 执行检查项，检查超时或 panic 均视为检查失败
+func (c *check) run(ctx context.Context) (result CheckResult) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			if e := recover(); e != nil {
+				errCh <- fmt.Errorf("panic: %v", e)
+			}
+		}()
+		errCh <- c.checker(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = fmt.Errorf("check timeout: %w", ctx.Err())
+	}
+
+	result = CheckResult{
+		Status:   StatusUp,
+		Critical: c.critical,
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,133 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func ok(context.Context) error { return nil }
+
+func fail(context.Context) error { return errors.New("connection refused") }
+
+func TestRegistry_Readiness(t *testing.T) {
+	r := NewRegistry()
+	r.Register("db", ok)
+	r.Register("cache", fail, WithCritical(false))
+
+	report := r.Readiness(context.Background())
+	assert.True(t, report.Healthy())
+	assert.Equal(t, StatusUp, report.Checks["db"].Status)
+	assert.Equal(t, StatusDown, report.Checks["cache"].Status)
+	assert.Equal(t, "connection refused", report.Checks["cache"].Error)
+	assert.False(t, report.Checks["cache"].Critical)
+
+	r.Register("mq", fail)
+	report = r.Readiness(context.Background())
+	assert.False(t, report.Healthy())
+	assert.Equal(t, []string{"cache", "db", "mq"}, r.Names())
+
+	r.Unregister("mq")
+	assert.True(t, r.Readiness(context.Background()).Healthy())
+}
+
+func TestRegistry_Liveness(t *testing.T) {
+	r := NewRegistry()
+	r.Register("db", fail)
+	r.Register("deadlock", ok, WithLiveness())
+
+	report := r.Liveness(context.Background())
+	assert.True(t, report.Healthy())
+	assert.Len(t, report.Checks, 1)
+	assert.Contains(t, report.Checks, "deadlock")
+}
+
+func TestRegistry_Timeout(t *testing.T) {
+	r := NewRegistry()
+	r.Register("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}, WithTimeout(50*time.Millisecond))
+
+	start := time.Now()
+	report := r.Readiness(context.Background())
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.False(t, report.Healthy())
+	assert.Contains(t, report.Checks["slow"].Error, "timeout")
+}
+
+func TestRegistry_Panic(t *testing.T) {
+	r := NewRegistry()
+	r.Register("panic", func(context.Context) error { panic("boom") })
+
+	report := r.Readiness(context.Background())
+	assert.False(t, report.Healthy())
+	assert.Contains(t, report.Checks["panic"].Error, "boom")
+}
+
+func TestRegistry_Shutdown(t *testing.T) {
+	r := NewRegistry()
+	r.Register("db", ok)
+	r.Shutdown()
+	assert.False(t, r.Readiness(context.Background()).Healthy())
+	assert.True(t, r.Liveness(context.Background()).Healthy())
+	r.Resume()
+	assert.True(t, r.Readiness(context.Background()).Healthy())
+}
+
+func TestHTTPHandlers(t *testing.T) {
+	r := NewRegistry()
+	r.Register("db", ok, WithLiveness())
+	mux := http.NewServeMux()
+	r.RegisterHTTP(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, LivenessPath, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	r.Register("cache", fail)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ReadinessPath, nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var report Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, StatusDown, report.Checks["cache"].Status)
+	assert.Equal(t, StatusUp, report.Checks["db"].Status)
+}
+
+func TestGRPCServer_Check(t *testing.T) {
+	r := NewRegistry()
+	r.Register("db", ok)
+	r.Register("cache", fail, WithCritical(false))
+	srv := NewGRPCServer(r)
+	ctx := context.Background()
+
+	resp, err := srv.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	resp, err = srv.Check(ctx, &healthpb.HealthCheckRequest{Service: "cache"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+
+	_, err = srv.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	list, err := srv.List(ctx, &healthpb.HealthListRequest{})
+	require.NoError(t, err)
+	assert.Len(t, list.Statuses, 3)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, list.Statuses["db"].Status)
+}
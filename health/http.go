@@ -0,0 +1,47 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+const (
+	// LivenessPath 存活检查的默认 http 路径
+	LivenessPath = "/healthz"
+	// ReadinessPath 就绪检查的默认 http 路径
+	ReadinessPath = "/readyz"
+)
+
+// LivenessHandler 存活检查 http handler，健康时返回200，否则返回503
+func (r *Registry) LivenessHandler() http.Handler {
+	return reportHandler(r.Liveness)
+}
+
+// ReadinessHandler 就绪检查 http handler，健康时返回200，否则返回503
+func (r *Registry) ReadinessHandler() http.Handler {
+	return reportHandler(r.Readiness)
+}
+
+// RegisterHTTP 在 mux 上注册 /healthz 及 /readyz
+func (r *Registry) RegisterHTTP(mux *http.ServeMux) {
+	mux.Handle(LivenessPath, r.LivenessHandler())
+	mux.Handle(ReadinessPath, r.ReadinessHandler())
+}
+
+func reportHandler(check func(context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := check(req.Context())
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if report.Healthy() {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if req.Method == http.MethodHead {
+			return
+		}
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
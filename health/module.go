@@ -0,0 +1,65 @@
+package health
+
+import (
+	"context"
+
+	"github.com/haysons/gokit/app"
+	"go.uber.org/fx"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Module 健康检查的 fx 模块，向依赖图提供 *Registry 及 *GRPCServer。
+// 若依赖图中存在 *app.App，应用进入流量摘除阶段时将调用 Registry.Shutdown 使就绪检查失败，
+// 并在启动前将 *GRPCServer 注册至由 App 管理的 grpc 服务器（transport/grpc.Server），替换其默认的健康检查服务
+var Module = fx.Module("health",
+	fx.Provide(newModuleRegistry),
+	fx.Provide(func(r *Registry) *GRPCServer {
+		return NewGRPCServer(r)
+	}),
+	fx.Invoke(registerGRPCServer),
+)
+
+type moduleParams struct {
+	fx.In
+
+	App *app.App `optional:"true"`
+}
+
+func newModuleRegistry(p moduleParams) *Registry {
+	r := NewRegistry()
+	if p.App != nil {
+		p.App.OnDrain(func(context.Context) error {
+			r.Shutdown()
+			return nil
+		})
+	}
+	return r
+}
+
+// healthServerSetter 支持替换健康检查服务的 grpc 服务器，如 transport/grpc.Server
+type healthServerSetter interface {
+	SetHealthServer(srv healthpb.HealthServer)
+}
+
+type registerParams struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+	App       *app.App `optional:"true"`
+	Server    *GRPCServer
+}
+
+// registerGRPCServer 服务启动前将健康检查服务注册至 App 管理的 grpc 服务器，
+// App 管理的服务在全部 fx.Invoke 执行后才能确定，因此于 OnStart 中注册
+func registerGRPCServer(p registerParams) {
+	if p.App == nil {
+		return
+	}
+	p.Lifecycle.Append(fx.StartHook(func() {
+		for _, srv := range p.App.Servers() {
+			if s, ok := srv.(healthServerSetter); ok {
+				s.SetHealthServer(p.Server)
+			}
+		}
+	}))
+}
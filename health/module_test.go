@@ -0,0 +1,84 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/haysons/gokit/app"
+	transgrpc "github.com/haysons/gokit/transport/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestModule(t *testing.T) {
+	var (
+		registry *Registry
+		grpcSrv  *GRPCServer
+	)
+	a := app.New(
+		app.WithModules(Module),
+		app.WithInvokes(fx.Populate(&registry, &grpcSrv)),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, a.Start(ctx))
+	defer a.Stop(ctx)
+
+	require.NotNil(t, registry)
+	require.NotNil(t, grpcSrv)
+	assert.True(t, registry.Readiness(ctx).Healthy())
+}
+
+func TestModule_GRPCServer(t *testing.T) {
+	srv := transgrpc.NewServer(transgrpc.WithAddr("127.0.0.1:0"))
+	var (
+		registry   *Registry
+		shutdowner fx.Shutdowner
+	)
+	a := app.New(
+		app.WithModules(Module),
+		app.WithServers(srv),
+		app.WithInvokes(fx.Populate(&registry, &shutdowner)),
+	)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.Run()
+	}()
+	<-srv.Ready()
+
+	u, err := srv.Endpoint()
+	require.NoError(t, err)
+	conn, err := grpc.NewClient(u.Host, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	ctx := context.Background()
+	resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	// grpc 服务器使用基于检查项的健康检查服务，而非始终返回 SERVING 的默认实现
+	registry.Register("db", func(context.Context) error { return errors.New("down") })
+	resp, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+	registry.Unregister("db")
+
+	// 应用进入流量摘除阶段后就绪检查失败
+	require.NoError(t, shutdowner.Shutdown())
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("app did not stop")
+	}
+	report := registry.Readiness(ctx)
+	assert.False(t, report.Healthy())
+}
@@ -0,0 +1,238 @@
+// Package i18n 提供多语言消息目录及本地化，消息目录以 YAML 或 JSON 文件按语言维护，
+// 请求处理时根据 Accept-Language 或元数据中的语言选择消息，未找到时使用兜底语言
+package i18n
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/haysons/gokit/errors"
+	"golang.org/x/text/language"
+)
+
+// DefaultFallback 默认的兜底语言
+var DefaultFallback = language.Chinese
+
+// message 消息目录中的一条消息，不包含模板动作时直接返回原文
+type message struct {
+	text string
+	tmpl *template.Template
+}
+
+func (m *message) render(args map[string]any) (string, error) {
+	if m.tmpl == nil {
+		return m.text, nil
+	}
+	var buf bytes.Buffer
+	if err := m.tmpl.Execute(&buf, args); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+type BundleOption func(*Bundle)
+
+// WithFallback 配置兜底语言，请求的语言均不受支持或消息缺失时使用，默认为中文
+func WithFallback(lang language.Tag) BundleOption {
+	return func(b *Bundle) {
+		b.fallback = lang
+	}
+}
+
+// Bundle 多语言消息目录，并发安全
+type Bundle struct {
+	fallback language.Tag
+
+	mu       sync.RWMutex
+	messages map[language.Tag]map[string]*message
+	tags     []language.Tag // 支持的语言，兜底语言位于首位
+	matcher  language.Matcher
+	resolved language.Tag // 兜底语言实际使用的已加载语言，如兜底语言为 zh 而仅加载了 zh-CN 时为 zh-CN
+}
+
+// NewBundle 创建多语言消息目录
+func NewBundle(opts ...BundleOption) *Bundle {
+	b := &Bundle{
+		fallback: DefaultFallback,
+		messages: make(map[language.Tag]map[string]*message),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	b.tags = []language.Tag{b.fallback}
+	b.matcher = language.NewMatcher(b.tags)
+	b.resolved = b.fallback
+	return b
+}
+
+var defaultBundle = NewBundle()
+
+// Default 返回默认的消息目录，框架内置的消息均注册于此
+func Default() *Bundle {
+	return defaultBundle
+}
+
+// Fallback 返回兜底语言
+func (b *Bundle) Fallback() language.Tag {
+	return b.fallback
+}
+
+// AddMessages 添加某一语言的消息，消息 id 已存在时覆盖，消息可使用 text/template 语法引用参数，如 {{.Name}}
+func (b *Bundle) AddMessages(lang language.Tag, messages map[string]string) error {
+	parsed := make(map[string]*message, len(messages))
+	for id, text := range messages {
+		m := &message{text: text}
+		if strings.Contains(text, "{{") {
+			tmpl, err := template.New(id).Option("missingkey=zero").Parse(text)
+			if err != nil {
+				return fmt.Errorf("i18n: parse message %s of %s: %w", id, lang, err)
+			}
+			m.tmpl = tmpl
+		}
+		parsed[id] = m
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	msgs, ok := b.messages[lang]
+	if !ok {
+		msgs = make(map[string]*message, len(parsed))
+		b.messages[lang] = msgs
+		if lang != b.fallback {
+			b.tags = append(b.tags, lang)
+			b.matcher = language.NewMatcher(b.tags)
+		}
+		b.resolved = b.resolveFallback()
+	}
+	for id, m := range parsed {
+		msgs[id] = m
+	}
+	return nil
+}
+
+// resolveFallback 返回兜底语言实际使用的语言，兜底语言未加载消息时通过匹配选取最接近的已加载语言，需持有 b.mu
+func (b *Bundle) resolveFallback() language.Tag {
+	if _, ok := b.messages[b.fallback]; ok || len(b.tags) == 1 {
+		return b.fallback
+	}
+	loaded := b.tags[1:]
+	if _, i, conf := language.NewMatcher(loaded).Match(b.fallback); conf != language.No {
+		return loaded[i]
+	}
+	return b.fallback
+}
+
+// Languages 返回支持的语言，兜底语言位于首位
+func (b *Bundle) Languages() []language.Tag {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return append([]language.Tag(nil), b.tags...)
+}
+
+// Localizer 根据语言偏好创建本地化器，langs 可为 Accept-Language 格式的字符串（如 zh-CN,zh;q=0.9,en;q=0.8）或语言标签，
+// 越靠前的优先级越高，无法解析的语言将被忽略
+func (b *Bundle) Localizer(langs ...string) *Localizer {
+	var prefs []language.Tag
+	for _, lang := range langs {
+		tags, _, err := language.ParseAcceptLanguage(lang)
+		if err != nil {
+			continue
+		}
+		prefs = append(prefs, tags...)
+	}
+
+	b.mu.RLock()
+	tag := b.fallback
+	if len(prefs) > 0 {
+		if _, i, conf := b.matcher.Match(prefs...); conf != language.No {
+			tag = b.tags[i]
+		}
+	}
+	if tag == b.fallback {
+		tag = b.resolved
+	}
+	b.mu.RUnlock()
+	return &Localizer{bundle: b, tag: tag}
+}
+
+// lookup 依次查找语言 tag 及其上级语言（如 en-GB、en-001、en）的消息，消息缺失时以同样方式查找兜底语言的消息
+func (b *Bundle) lookup(tag language.Tag, id string) (*message, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, t := range []language.Tag{tag, b.resolved} {
+		for ; ; t = t.Parent() {
+			if m, ok := b.messages[t][id]; ok {
+				return m, true
+			}
+			if t == language.Und {
+				break
+			}
+		}
+	}
+	return nil, false
+}
+
+// Localizer 本地化器，将消息 id 解析为请求语言的消息
+type Localizer struct {
+	bundle *Bundle
+	tag    language.Tag
+}
+
+// Language 返回本地化器匹配到的语言
+func (l *Localizer) Language() language.Tag {
+	return l.tag
+}
+
+// Localize 将消息 id 解析为消息，消息不存在或渲染失败时返回 false
+func (l *Localizer) Localize(id string, args map[string]any) (string, bool) {
+	m, ok := l.bundle.lookup(l.tag, id)
+	if !ok {
+		return "", false
+	}
+	text, err := m.render(args)
+	if err != nil {
+		return "", false
+	}
+	return text, true
+}
+
+// MustLocalize 将消息 id 解析为消息，消息不存在时返回消息 id
+func (l *Localizer) MustLocalize(id string, args map[string]any) string {
+	if text, ok := l.Localize(id, args); ok {
+		return text
+	}
+	return id
+}
+
+// LocalizeError 将错误中待本地化的提示信息解析为提示信息并附加至错误，
+// 此后 errors.GetHint 返回本地化后的提示信息，错误不包含待本地化的提示信息或消息不存在时原样返回
+func (l *Localizer) LocalizeError(err error) error {
+	id, args, ok := errors.GetHintID(err)
+	if !ok {
+		return err
+	}
+	text, ok := l.Localize(id, args)
+	if !ok {
+		return err
+	}
+	return errors.WithHint(err, text)
+}
+
+type localizerKey struct{}
+
+// NewContext 将本地化器注入 context
+func NewContext(ctx context.Context, l *Localizer) context.Context {
+	return context.WithValue(ctx, localizerKey{}, l)
+}
+
+// FromContext 自 context 中获取本地化器，不存在时返回默认消息目录兜底语言的本地化器
+func FromContext(ctx context.Context) *Localizer {
+	if l, ok := ctx.Value(localizerKey{}).(*Localizer); ok {
+		return l
+	}
+	return defaultBundle.Localizer()
+}
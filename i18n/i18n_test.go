@@ -0,0 +1,134 @@
+package i18n
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/haysons/gokit/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func newTestBundle(t *testing.T) *Bundle {
+	b := NewBundle()
+	require.NoError(t, b.LoadDir("testdata/locales"))
+	return b
+}
+
+func TestBundle_Localizer(t *testing.T) {
+	b := newTestBundle(t)
+	assert.Equal(t, []language.Tag{language.Chinese, language.English, language.Japanese}, b.Languages())
+
+	cases := []struct {
+		langs []string
+		want  language.Tag
+	}{
+		{[]string{"en-US,en;q=0.9"}, language.English},
+		{[]string{"zh-CN,zh;q=0.9,en;q=0.8"}, language.Chinese},
+		{[]string{"fr-FR,ja;q=0.5"}, language.Japanese},
+		// 元数据中的语言优先于 Accept-Language
+		{[]string{"ja", "en"}, language.Japanese},
+		// 不受支持或无法解析的语言使用兜底语言
+		{[]string{"fr"}, language.Chinese},
+		{[]string{"!!"}, language.Chinese},
+		{nil, language.Chinese},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, b.Localizer(c.langs...).Language(), c.langs)
+	}
+}
+
+func TestLocalizer_Localize(t *testing.T) {
+	b := newTestBundle(t)
+
+	en := b.Localizer("en")
+	text, ok := en.Localize("user.quota", map[string]any{"Limit": 10})
+	require.True(t, ok)
+	assert.Equal(t, "Quota exceeded, the limit is 10", text)
+
+	// 消息缺失时使用兜底语言的消息
+	ja := b.Localizer("ja")
+	assert.Equal(t, "ユーザーが見つかりません", ja.MustLocalize("user.not_found", nil))
+	assert.Equal(t, "你好，gokit", ja.MustLocalize("greeting", map[string]any{"Name": "gokit"}))
+
+	// 消息不存在时返回消息 id
+	_, ok = en.Localize("user.missing", nil)
+	assert.False(t, ok)
+	assert.Equal(t, "user.missing", en.MustLocalize("user.missing", nil))
+
+	// 兜底语言可配置
+	b = NewBundle(WithFallback(language.English))
+	require.NoError(t, b.LoadDir("testdata/locales"))
+	assert.Equal(t, "User not found", b.Localizer("fr").MustLocalize("user.not_found", nil))
+}
+
+func TestLocalizer_LocalizeError(t *testing.T) {
+	l := newTestBundle(t).Localizer("en-GB")
+	err := errors.WithHintID(errors.New("quota exceeded"), "user.quota", map[string]any{"Limit": 3})
+	assert.Equal(t, "Quota exceeded, the limit is 3", errors.GetHint(l.LocalizeError(err)))
+
+	plain := errors.New("plain")
+	assert.Equal(t, plain, l.LocalizeError(plain))
+	unknown := errors.WithHintID(plain, "unknown", nil)
+	assert.Empty(t, errors.GetHint(l.LocalizeError(unknown)))
+}
+
+func TestBundle_AddMessages(t *testing.T) {
+	b := NewBundle()
+	require.NoError(t, b.AddMessages(language.Chinese, map[string]string{"a": "甲"}))
+	require.NoError(t, b.AddMessages(language.Chinese, map[string]string{"a": "乙", "b": "丙"}))
+	l := b.Localizer()
+	assert.Equal(t, "乙", l.MustLocalize("a", nil))
+	assert.Equal(t, "丙", l.MustLocalize("b", nil))
+	assert.Error(t, b.AddMessages(language.English, map[string]string{"bad": "{{.Name"}))
+}
+
+func TestBundle_LoadFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+		return p
+	}
+	b := NewBundle()
+	require.NoError(t, b.LoadFile(write("zh-Hant.yaml", "user:\n  not_found: 使用者不存在\n")))
+	assert.Equal(t, "使用者不存在", b.Localizer("zh-TW").MustLocalize("user.not_found", nil))
+
+	assert.Error(t, b.LoadFile(write("xx-invalid-lang.yaml", "a: b\n")))
+	assert.Error(t, b.LoadFile(write("en.yaml", "a: [1, 2]\n")))
+	assert.Error(t, b.LoadFile(write("en.json", "{")))
+	assert.Error(t, b.LoadFile(write("en.toml", "a = 1\n")))
+	assert.Error(t, b.LoadFile(filepath.Join(dir, "missing.yaml")))
+}
+
+func TestBundle_RegionCatalogs(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "zh-CN.yaml"), []byte("user:\n  not_found: 用户不存在\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "en.yaml"), []byte("user:\n  not_found: User not found\n  quota: Quota exceeded\n"), 0o644))
+	b := NewBundle()
+	require.NoError(t, b.LoadDir(dir))
+
+	// 兜底语言 zh 仅加载了 zh-CN 的消息时使用 zh-CN
+	for _, langs := range [][]string{nil, {"zh"}, {"fr"}, {"zh-CN"}} {
+		l := b.Localizer(langs...)
+		assert.Equal(t, language.MustParse("zh-CN"), l.Language(), langs)
+		assert.Equal(t, "用户不存在", l.MustLocalize("user.not_found", nil), langs)
+	}
+	assert.Equal(t, "user.quota", b.Localizer("fr").MustLocalize("user.quota", nil))
+	assert.Equal(t, "User not found", b.Localizer("en-GB").MustLocalize("user.not_found", nil))
+
+	// 语言未加载消息时查找上级语言的消息，如 en-GB 依次查找 en-001、en
+	l := b.Localizer()
+	l.tag = language.MustParse("en-GB")
+	assert.Equal(t, "Quota exceeded", l.MustLocalize("user.quota", nil))
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Equal(t, Default().Fallback(), FromContext(context.Background()).Language())
+
+	l := newTestBundle(t).Localizer("en")
+	assert.Same(t, l, FromContext(NewContext(context.Background(), l)))
+}
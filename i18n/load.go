@@ -0,0 +1,116 @@
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadFile 加载消息目录文件，文件名（不含扩展名）的最后一段为语言，如 zh-CN.yaml、messages.en.json，
+// 支持 .yaml、.yml 及 .json 格式，嵌套的 key 以 . 连接作为消息 id
+func (b *Bundle) LoadFile(name string) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	return b.load(filepath.Base(name), data)
+}
+
+// LoadDir 加载目录下全部消息目录文件，不会递归加载子目录
+func (b *Bundle) LoadDir(dir string) error {
+	return b.LoadFS(os.DirFS(dir), ".")
+}
+
+// LoadFS 加载 fsys 中 dir 目录下的全部消息目录文件，可配合 embed 将消息目录打包进二进制
+func (b *Bundle) LoadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !supportedExt(path.Ext(entry.Name())) {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		if err = b.load(entry.Name(), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MustLoadFS 同 LoadFS，加载失败时 panic，用于在包初始化时加载内置的消息目录
+func (b *Bundle) MustLoadFS(fsys fs.FS, dir string) {
+	if err := b.LoadFS(fsys, dir); err != nil {
+		panic(err)
+	}
+}
+
+func supportedExt(ext string) bool {
+	switch ext {
+	case ".yaml", ".yml", ".json":
+		return true
+	default:
+		return false
+	}
+}
+
+// load 解析消息目录文件并添加至消息目录
+func (b *Bundle) load(name string, data []byte) error {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	lang, err := language.Parse(base[strings.LastIndex(base, ".")+1:])
+	if err != nil {
+		return fmt.Errorf("i18n: invalid language of %s: %w", name, err)
+	}
+
+	var raw map[string]any
+	switch ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".json":
+		err = json.Unmarshal(data, &raw)
+	default:
+		return fmt.Errorf("i18n: unsupported file %s", name)
+	}
+	if err != nil {
+		return fmt.Errorf("i18n: parse %s: %w", name, err)
+	}
+
+	messages := make(map[string]string)
+	if err = flatten("", raw, messages); err != nil {
+		return fmt.Errorf("i18n: parse %s: %w", name, err)
+	}
+	return b.AddMessages(lang, messages)
+}
+
+// flatten 将嵌套的消息展开，key 以 . 连接
+func flatten(prefix string, raw map[string]any, messages map[string]string) error {
+	for k, v := range raw {
+		id := k
+		if prefix != "" {
+			id = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case string:
+			messages[id] = v
+		case map[string]any:
+			if err := flatten(id, v, messages); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("message %s must be a string, got %T", id, v)
+		}
+	}
+	return nil
+}
@@ -21,6 +21,9 @@ func init() {
 	})
 }
 
+// defaultLevel 默认日志对象的日志级别，可在运行期间动态调整
+var defaultLevel = new(slog.LevelVar)
+
 // GetDefaultSlog 获取默认的日志对象
 func GetDefaultSlog() *slog.Logger {
 	return slog.Default()
@@ -28,5 +31,21 @@ func GetDefaultSlog() *slog.Logger {
 
 // SetDefaultSlog 基于配置信息设置默认的日志对象
 func SetDefaultSlog(conf *Config) {
-	slog.SetDefault(NewSlogger(conf))
+	defaultLevel.Set(parseLevel(conf.Level))
+	slog.SetDefault(newSlogger(conf, defaultLevel))
+}
+
+// GetLevel 获取默认日志对象当前的日志级别
+func GetLevel() slog.Level {
+	return defaultLevel.Level()
+}
+
+// SetLevel 动态调整默认日志对象的日志级别，支持 debug info warn error
+func SetLevel(level string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return err
+	}
+	defaultLevel.Set(l)
+	return nil
 }
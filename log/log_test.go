@@ -80,3 +80,18 @@ func TestParseLevel(t *testing.T) {
 	assert.Equal(t, slog.LevelError, parseLevel("error"))
 	assert.Equal(t, slog.LevelInfo, parseLevel("invalid")) // 默认值
 }
+
+func TestSetLevel(t *testing.T) {
+	defer SetDefaultSlog(&Config{Level: "info", ConsoleFmt: true, ConsoleColor: true})
+
+	SetDefaultSlog(&Config{Level: "info"})
+	assert.Equal(t, slog.LevelInfo, GetLevel())
+	assert.False(t, GetDefaultSlog().Enabled(context.Background(), slog.LevelDebug))
+
+	assert.NoError(t, SetLevel("debug"))
+	assert.Equal(t, slog.LevelDebug, GetLevel())
+	assert.True(t, GetDefaultSlog().Enabled(context.Background(), slog.LevelDebug))
+
+	assert.Error(t, SetLevel("verbose"))
+	assert.Equal(t, slog.LevelDebug, GetLevel())
+}
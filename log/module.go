@@ -0,0 +1,23 @@
+package log
+
+import (
+	"log/slog"
+
+	"go.uber.org/fx"
+)
+
+// Module 日志的 fx 模块，向依赖图提供 *slog.Logger，若依赖图中存在 *Config，将以此配置设置默认日志对象
+var Module = fx.Module("log", fx.Provide(newModuleLogger))
+
+type moduleParams struct {
+	fx.In
+
+	Config *Config `optional:"true"`
+}
+
+func newModuleLogger(p moduleParams) *slog.Logger {
+	if p.Config != nil {
+		SetDefaultSlog(p.Config)
+	}
+	return GetDefaultSlog()
+}
@@ -0,0 +1,32 @@
+package log
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+func TestModule(t *testing.T) {
+	defer SetDefaultSlog(&Config{Level: "info", ConsoleFmt: true, ConsoleColor: true})
+
+	var logger *slog.Logger
+	fxtest.New(t,
+		Module,
+		fx.Supply(&Config{Level: "error"}),
+		fx.Populate(&logger),
+	).RequireStart().RequireStop()
+
+	assert.NotNil(t, logger)
+	assert.Equal(t, GetDefaultSlog(), logger)
+	assert.False(t, logger.Enabled(t.Context(), slog.LevelWarn))
+	assert.True(t, logger.Enabled(t.Context(), slog.LevelError))
+}
+
+func TestModule_WithoutConfig(t *testing.T) {
+	var logger *slog.Logger
+	fxtest.New(t, Module, fx.Populate(&logger)).RequireStart().RequireStop()
+	assert.Equal(t, GetDefaultSlog(), logger)
+}
@@ -13,6 +13,10 @@ import (
 
 // NewSlogger 创建slog对象
 func NewSlogger(conf *Config) *slog.Logger {
+	return newSlogger(conf, parseLevel(conf.Level))
+}
+
+func newSlogger(conf *Config, level slog.Leveler) *slog.Logger {
 	var writer io.Writer
 
 	if conf.Filename == "" {
@@ -28,8 +32,6 @@ func NewSlogger(conf *Config) *slog.Logger {
 		}
 	}
 
-	level := parseLevel(conf.Level)
-
 	var handler slog.Handler
 	if conf.ConsoleFmt {
 		handler = tint.NewHandler(writer, &tint.Options{
@@ -2,13 +2,17 @@ package jwt
 
 import (
 	"context"
+	"embed"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/haysons/gokit/errors"
+	"github.com/haysons/gokit/i18n"
 	"github.com/haysons/gokit/middleware"
 	"github.com/haysons/gokit/transport"
+	"google.golang.org/grpc/codes"
 )
 
 type authKey struct{}
@@ -22,18 +26,37 @@ const (
 )
 
 var (
-	ErrMissingJwtToken        = errors.NewUnauthorized(10001, "获取 jwt header 失败", "JWT token is missing")
-	ErrMissingKeyFunc         = errors.NewUnauthorized(10002, "秘钥获取函数缺失", "keyFunc is missing")
-	ErrTokenInvalid           = errors.NewUnauthorized(10003, "jwt 验证失败", "Token is invalid")
-	ErrTokenExpired           = errors.NewUnauthorized(10004, "jwt 已过期", "JWT token has expired")
-	ErrTokenParseFail         = errors.NewUnauthorized(10005, "jwt 解析失败", "Fail to parse JWT token ")
-	ErrUnSupportSigningMethod = errors.NewUnauthorized(10006, "jwt 签名方式异常", "Wrong signing method")
-	ErrWrongContext           = errors.NewUnauthorized(10007, "middleware context 异常", "Wrong context for middleware")
-	ErrNeedTokenProvider      = errors.NewUnauthorized(10008, "获取 token 秘钥失败", "Token provider is missing")
-	ErrSignToken              = errors.NewUnauthorized(10009, "生成 token 失败", "Can not sign token.Is the key correct?")
-	ErrGetKey                 = errors.NewUnauthorized(10010, "获取 token 秘钥失败", "Can not get key while signing token")
+	ErrMissingJwtToken        = unauthorized(10001, "获取 jwt header 失败", "jwt.missing_token", "JWT token is missing")
+	ErrMissingKeyFunc         = unauthorized(10002, "秘钥获取函数缺失", "jwt.missing_key_func", "keyFunc is missing")
+	ErrTokenInvalid           = unauthorized(10003, "jwt 验证失败", "jwt.token_invalid", "Token is invalid")
+	ErrTokenExpired           = unauthorized(10004, "jwt 已过期", "jwt.token_expired", "JWT token has expired")
+	ErrTokenParseFail         = unauthorized(10005, "jwt 解析失败", "jwt.token_parse_fail", "Fail to parse JWT token ")
+	ErrUnSupportSigningMethod = unauthorized(10006, "jwt 签名方式异常", "jwt.unsupported_signing_method", "Wrong signing method")
+	ErrWrongContext           = unauthorized(10007, "middleware context 异常", "jwt.wrong_context", "Wrong context for middleware")
+	ErrNeedTokenProvider      = unauthorized(10008, "获取 token 秘钥失败", "jwt.need_token_provider", "Token provider is missing")
+	ErrSignToken              = unauthorized(10009, "生成 token 失败", "jwt.sign_token", "Can not sign token.Is the key correct?")
+	ErrGetKey                 = unauthorized(10010, "获取 token 秘钥失败", "jwt.get_key", "Can not get key while signing token")
 )
 
+//go:embed locales
+var locales embed.FS
+
+// 提示信息的消息目录注册至默认消息目录，由 localize 中间件根据请求的语言解析
+func init() { i18n.Default().MustLoadFS(locales, "locales") }
+
+// unauthorized 注册未授权类型的错误码并构造错误，hint 为未经本地化时的提示信息，hintID 为 locales 中的消息 id
+func unauthorized(code int, hint, hintID, msg string) error {
+	return errors.Register(errors.Definition{
+		Code:     code,
+		Message:  msg,
+		Hint:     hint,
+		HintID:   hintID,
+		HttpCode: http.StatusUnauthorized,
+		GrpcCode: codes.PermissionDenied,
+		Module:   "jwt",
+	}).New()
+}
+
 type Option func(*options)
 
 type options struct {
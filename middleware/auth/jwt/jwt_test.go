@@ -0,0 +1,28 @@
+package jwt
+
+import (
+	"testing"
+
+	"github.com/haysons/gokit/errors"
+	"github.com/haysons/gokit/i18n"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocales(t *testing.T) {
+	zh, en := i18n.Default().Localizer("zh"), i18n.Default().Localizer("en")
+	for _, err := range []error{
+		ErrMissingJwtToken, ErrMissingKeyFunc, ErrTokenInvalid, ErrTokenExpired, ErrTokenParseFail,
+		ErrUnSupportSigningMethod, ErrWrongContext, ErrNeedTokenProvider, ErrSignToken, ErrGetKey,
+	} {
+		id, _, ok := errors.GetHintID(err)
+		require.True(t, ok, err)
+		// 内置的中文消息与未经本地化时的提示信息一致
+		text, ok := zh.Localize(id, nil)
+		require.True(t, ok, id)
+		assert.Equal(t, errors.GetHint(err), text, id)
+		_, ok = en.Localize(id, nil)
+		assert.True(t, ok, id)
+	}
+	assert.Equal(t, "Authorization token has expired", errors.GetHint(en.LocalizeError(ErrTokenExpired)))
+}
@@ -0,0 +1,59 @@
+package idempotency
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Codec 响应的编解码器，解码时需还原出与原响应类型一致的值
+type Codec interface {
+	Marshal(resp any) ([]byte, error)
+	Unmarshal(data []byte) (any, error)
+}
+
+// ProtoCodec protobuf 编解码器，编码结果包含消息类型，解码时据此还原响应，适用于 grpc 服务及以 protobuf 定义响应的 http 服务
+var ProtoCodec Codec = protoCodec{}
+
+type protoCodec struct{}
+
+func (protoCodec) Marshal(resp any) ([]byte, error) {
+	msg, ok := resp.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("response %T is not a proto message", resp)
+	}
+	a, err := anypb.New(msg)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(a)
+}
+
+func (protoCodec) Unmarshal(data []byte) (any, error) {
+	var a anypb.Any
+	if err := proto.Unmarshal(data, &a); err != nil {
+		return nil, err
+	}
+	return a.UnmarshalNew()
+}
+
+// JSONCodec json 编解码器，newResp 返回用于解码的响应指针，如 func() any { return new(Reply) }
+func JSONCodec(newResp func() any) Codec {
+	return jsonCodec{newResp: newResp}
+}
+
+type jsonCodec struct {
+	newResp func() any
+}
+
+func (jsonCodec) Marshal(resp any) ([]byte, error) { return json.Marshal(resp) }
+
+func (c jsonCodec) Unmarshal(data []byte) (any, error) {
+	resp := c.newResp()
+	if err := json.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,130 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"go.etcd.io/etcd/client/v3"
+)
+
+// EtcdStore 基于 etcd 的存储，记录绑定租约，租约到期后记录随之删除。存储布局如下：
+//
+//	{prefix}/{operation}/{key} 处理记录，值为 json 编码的 Record
+type EtcdStore struct {
+	client *clientv3.Client
+	prefix string
+}
+
+// NewEtcdStore 创建基于 etcd 的存储
+func NewEtcdStore(client *clientv3.Client, prefix string) *EtcdStore {
+	return &EtcdStore{client: client, prefix: prefix}
+}
+
+func (s *EtcdStore) key(key string) string {
+	return s.prefix + "/" + key
+}
+
+// grant 创建时长为 ttl 的租约，不足1s的部分向上取整
+func (s *EtcdStore) grant(ctx context.Context, ttl time.Duration) (clientv3.LeaseID, error) {
+	resp, err := s.client.Grant(ctx, max(int64(math.Ceil(ttl.Seconds())), 1))
+	if err != nil {
+		return 0, fmt.Errorf("grant lease failed: %w", err)
+	}
+	return resp.ID, nil
+}
+
+// Begin 开始处理请求，key 不存在时写入绑定时长为 ttl 的租约的处理中记录，返回该租约的 id 作为 owner 及 nil；
+// 已存在时返回已有记录
+func (s *EtcdStore) Begin(ctx context.Context, key string, ttl time.Duration) (int64, *Record, error) {
+	data, err := json.Marshal(Record{Status: StatusInFlight, UpdatedAt: time.Now()})
+	if err != nil {
+		return 0, nil, err
+	}
+	lease, err := s.grant(ctx, ttl)
+	if err != nil {
+		return 0, nil, err
+	}
+	k := s.key(key)
+	for {
+		resp, err := s.client.Txn(ctx).
+			If(clientv3.Compare(clientv3.CreateRevision(k), "=", 0)).
+			Then(clientv3.OpPut(k, string(data), clientv3.WithLease(lease))).
+			Else(clientv3.OpGet(k)).
+			Commit()
+		if err != nil {
+			return 0, nil, fmt.Errorf("begin request failed: %w", err)
+		}
+		if resp.Succeeded {
+			return int64(lease), nil, nil
+		}
+		kvs := resp.Responses[0].GetResponseRange().Kvs
+		if len(kvs) == 0 {
+			// 记录恰好过期，重新尝试写入
+			continue
+		}
+		// 租约未被使用，撤销失败时到期后自动删除
+		s.revoke(ctx, lease)
+		var record Record
+		if err = json.Unmarshal(kvs[0].Value, &record); err != nil {
+			return 0, nil, fmt.Errorf("unmarshal record failed: %w", err)
+		}
+		return 0, &record, nil
+	}
+}
+
+// Complete 请求处理成功，保存响应并绑定时长为 ttl 的新租约，记录仍绑定 owner 租约或已过期且未被接管时才写入，
+// 否则返回 ErrNotOwner
+func (s *EtcdStore) Complete(ctx context.Context, key string, owner int64, response []byte, ttl time.Duration) error {
+	data, err := json.Marshal(Record{Status: StatusCompleted, Response: response, UpdatedAt: time.Now()})
+	if err != nil {
+		return err
+	}
+	lease, err := s.grant(ctx, ttl)
+	if err != nil {
+		return err
+	}
+	k := s.key(key)
+	put := clientv3.OpPut(k, string(data), clientv3.WithLease(lease))
+	resp, err := s.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.LeaseValue(k), "=", owner), clientv3.Compare(clientv3.CreateRevision(k), ">", 0)).
+		Then(put).
+		Else(clientv3.OpTxn([]clientv3.Cmp{clientv3.Compare(clientv3.CreateRevision(k), "=", 0)}, []clientv3.Op{put}, nil)).
+		Commit()
+	if err != nil {
+		s.revoke(ctx, lease)
+		return fmt.Errorf("complete request failed: %w", err)
+	}
+	if !resp.Succeeded && !resp.Responses[0].GetResponseTxn().Succeeded {
+		s.revoke(ctx, lease)
+		return ErrNotOwner
+	}
+	// 处理中记录的租约不再使用
+	s.revoke(ctx, clientv3.LeaseID(owner))
+	return nil
+}
+
+// Release 请求处理失败，删除记录以便客户端使用相同的幂等键重试，记录已被其他请求接管时返回 ErrNotOwner
+func (s *EtcdStore) Release(ctx context.Context, key string, owner int64) error {
+	k := s.key(key)
+	resp, err := s.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.LeaseValue(k), "=", owner)).
+		Then(clientv3.OpDelete(k)).
+		Else(clientv3.OpGet(k, clientv3.WithCountOnly())).
+		Commit()
+	if err != nil {
+		return fmt.Errorf("release request failed: %w", err)
+	}
+	s.revoke(ctx, clientv3.LeaseID(owner))
+	if !resp.Succeeded && resp.Responses[0].GetResponseRange().Count > 0 {
+		return ErrNotOwner
+	}
+	return nil
+}
+
+// revoke 撤销不再使用的租约，撤销失败时租约到期后自动删除
+func (s *EtcdStore) revoke(ctx context.Context, lease clientv3.LeaseID) {
+	_, _ = s.client.Revoke(context.WithoutCancel(ctx), lease)
+}
@@ -0,0 +1,171 @@
+package idempotency
+
+import (
+	"context"
+	"embed"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/haysons/gokit/errors"
+	"github.com/haysons/gokit/i18n"
+	"github.com/haysons/gokit/log"
+	"github.com/haysons/gokit/middleware"
+	"github.com/haysons/gokit/transport"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	// DefaultHeader 默认的幂等键 header
+	DefaultHeader = "Idempotency-Key"
+	// ReplayedHeader 返回缓存的响应时在响应 header 中写入该 header，值为 true
+	ReplayedHeader = "Idempotent-Replayed"
+
+	defaultTTL         = 24 * time.Hour
+	defaultInFlightTTL = time.Minute
+)
+
+var (
+	ErrMissingKey      = newError(10101, http.StatusBadRequest, codes.InvalidArgument, "缺少幂等键", "idempotency.missing_key", "idempotency key is missing")
+	ErrRequestInFlight = newError(10102, http.StatusConflict, codes.Aborted, "请求正在处理中，请稍后重试", "idempotency.request_in_flight", "request with the same idempotency key is in flight")
+)
+
+//go:embed locales
+var locales embed.FS
+
+// 提示信息的消息目录注册至默认消息目录，由 localize 中间件根据请求的语言解析
+func init() { i18n.Default().MustLoadFS(locales, "locales") }
+
+// newError 注册错误码并构造错误，hint 为未经本地化时的提示信息，hintID 为 locales 中的消息 id
+func newError(code, httpCode int, grpcCode codes.Code, hint, hintID, msg string) error {
+	return errors.Register(errors.Definition{
+		Code:     code,
+		Message:  msg,
+		Hint:     hint,
+		HintID:   hintID,
+		HttpCode: httpCode,
+		GrpcCode: grpcCode,
+		Module:   "idempotency",
+	}).New()
+}
+
+type Option func(*options)
+
+type options struct {
+	header      string        // 幂等键 header
+	required    bool          // 缺少幂等键时是否拒绝请求
+	ttl         time.Duration // 响应的保留时长
+	inFlightTTL time.Duration // 处理中记录的保留时长
+	codec       Codec         // 响应的编解码器
+}
+
+// WithHeader 配置读取幂等键的 header，默认为 Idempotency-Key
+func WithHeader(header string) Option {
+	return func(o *options) {
+		o.header = header
+	}
+}
+
+// WithRequired 缺少幂等键时返回 ErrMissingKey，默认直接处理请求
+func WithRequired() Option {
+	return func(o *options) {
+		o.required = true
+	}
+}
+
+// WithTTL 配置响应的保留时长，保留期间相同幂等键的请求直接返回缓存的响应，默认为24h
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithInFlightTTL 配置处理中记录的保留时长，需大于请求的最长处理时间，避免实例异常退出后幂等键一直处于处理中，默认为1m
+func WithInFlightTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.inFlightTTL = ttl
+	}
+}
+
+// WithCodec 配置响应的编解码器，默认为 ProtoCodec
+func WithCodec(codec Codec) Option {
+	return func(o *options) {
+		o.codec = codec
+	}
+}
+
+// Server 幂等中间件，自请求 header 中读取幂等键，相同操作下幂等键相同的请求仅处理一次：
+// 首个请求处理成功后保存其响应，重复的请求直接返回保存的响应并在响应 header 中写入 Idempotent-Replayed；
+// 首个请求仍在处理时重复的请求返回 ErrRequestInFlight；处理失败时删除记录，客户端可使用相同的幂等键重试
+func Server(store Store, opts ...Option) middleware.Middleware {
+	o := &options{
+		header:      DefaultHeader,
+		ttl:         defaultTTL,
+		inFlightTTL: defaultInFlightTTL,
+		codec:       ProtoCodec,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			idempotencyKey := tr.RequestHeader().Get(o.header)
+			if idempotencyKey == "" {
+				if o.required {
+					return nil, ErrMissingKey
+				}
+				return handler(ctx, req)
+			}
+			// 幂等键的作用域为单个操作，避免不同接口间的幂等键冲突
+			key := tr.Operation() + "/" + idempotencyKey
+
+			owner, record, err := store.Begin(ctx, key, o.inFlightTTL)
+			if err != nil {
+				return nil, errors.Wrap(err, "begin idempotent request failed")
+			}
+			if record != nil {
+				return replay(tr, record, o.codec)
+			}
+
+			logger := log.GetDefaultSlog().With(slog.String("operation", tr.Operation()), slog.String("key", idempotencyKey))
+			release := func() {
+				if rerr := store.Release(context.WithoutCancel(ctx), key, owner); rerr != nil {
+					logger.Error("release idempotency key failed", slog.Any("error", rerr))
+				}
+			}
+			resp, err := handler(ctx, req)
+			if err != nil {
+				release()
+				return resp, err
+			}
+			data, err := o.codec.Marshal(resp)
+			if err != nil {
+				logger.Error("marshal idempotent response failed", slog.Any("error", err))
+				release()
+				return resp, nil
+			}
+			// 处理时间超过处理中记录的保留时长时记录可能已被重复的请求接管，此时不覆盖其记录
+			if err = store.Complete(context.WithoutCancel(ctx), key, owner, data, o.ttl); err != nil {
+				logger.Error("save idempotent response failed", slog.Any("error", err))
+			}
+			return resp, nil
+		}
+	}
+}
+
+// replay 根据已有记录响应重复的请求
+func replay(tr transport.Transporter, record *Record, codec Codec) (any, error) {
+	if record.Status == StatusInFlight {
+		return nil, ErrRequestInFlight
+	}
+	resp, err := codec.Unmarshal(record.Response)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal idempotent response failed")
+	}
+	tr.ReplyHeader().Set(ReplayedHeader, "true")
+	return resp, nil
+}
@@ -0,0 +1,235 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/haysons/gokit/errors"
+	"github.com/haysons/gokit/i18n"
+	"github.com/haysons/gokit/internal/etcdtest"
+	"github.com/haysons/gokit/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type header http.Header
+
+func (h header) Get(key string) string      { return http.Header(h).Get(key) }
+func (h header) Set(key, value string)      { http.Header(h).Set(key, value) }
+func (h header) Add(key, value string)      { http.Header(h).Add(key, value) }
+func (h header) Values(key string) []string { return http.Header(h).Values(key) }
+func (h header) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type fakeTransport struct {
+	operation   string
+	reqHeader   header
+	replyHeader header
+}
+
+func (tr *fakeTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (tr *fakeTransport) Operation() string               { return tr.operation }
+func (tr *fakeTransport) Endpoint() string                { return "" }
+func (tr *fakeTransport) RequestHeader() transport.Header { return tr.reqHeader }
+func (tr *fakeTransport) ReplyHeader() transport.Header   { return tr.replyHeader }
+func (tr *fakeTransport) PathTemplate() string            { return "" }
+func (tr *fakeTransport) Request() interface{}            { return nil }
+
+// call 以幂等键 key 调用 operation
+func call(h func(ctx context.Context, req any) (any, error), operation, key string) (any, *fakeTransport, error) {
+	tr := &fakeTransport{operation: operation, reqHeader: header{}, replyHeader: header{}}
+	if key != "" {
+		tr.reqHeader.Set(DefaultHeader, key)
+	}
+	resp, err := h(transport.InjectServerContext(context.Background(), tr), nil)
+	return resp, tr, err
+}
+
+func testServer(t *testing.T, store Store) {
+	var calls atomic.Int32
+	handler := Server(store)(func(ctx context.Context, req any) (any, error) {
+		n := calls.Add(1)
+		return wrapperspb.Int32(n), nil
+	})
+
+	resp, tr, err := call(handler, "/order/Create", "k1")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, resp.(*wrapperspb.Int32Value).Value)
+	assert.Empty(t, tr.replyHeader.Get(ReplayedHeader))
+
+	// 重复的请求返回缓存的响应
+	resp, tr, err = call(handler, "/order/Create", "k1")
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(wrapperspb.Int32(1), resp.(proto.Message)))
+	assert.Equal(t, "true", tr.replyHeader.Get(ReplayedHeader))
+	assert.EqualValues(t, 1, calls.Load())
+
+	// 不同操作及不同幂等键互不影响，缺少幂等键时直接处理
+	_, _, err = call(handler, "/order/Cancel", "k1")
+	require.NoError(t, err)
+	_, _, err = call(handler, "/order/Create", "k2")
+	require.NoError(t, err)
+	_, _, err = call(handler, "/order/Create", "")
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, calls.Load())
+}
+
+func testInFlight(t *testing.T, store Store) {
+	started, finish := make(chan struct{}), make(chan struct{})
+	handler := Server(store)(func(ctx context.Context, req any) (any, error) {
+		close(started)
+		<-finish
+		return wrapperspb.String("done"), nil
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, _, err := call(handler, "/order/Create", "k")
+		assert.NoError(t, err)
+	}()
+	<-started
+	_, _, err := call(handler, "/order/Create", "k")
+	assert.ErrorIs(t, err, ErrRequestInFlight)
+	assert.Equal(t, http.StatusConflict, errors.GetHttpCode(err, 0))
+
+	close(finish)
+	wg.Wait()
+	resp, _, err := call(handler, "/order/Create", "k")
+	require.NoError(t, err)
+	assert.Equal(t, "done", resp.(*wrapperspb.StringValue).Value)
+}
+
+func testFailure(t *testing.T, store Store) {
+	var calls atomic.Int32
+	handler := Server(store)(func(ctx context.Context, req any) (any, error) {
+		if calls.Add(1) == 1 {
+			return nil, errors.New("boom")
+		}
+		return wrapperspb.String("ok"), nil
+	})
+
+	// 处理失败后可使用相同的幂等键重试
+	_, _, err := call(handler, "/order/Create", "k")
+	require.Error(t, err)
+	resp, _, err := call(handler, "/order/Create", "k")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp.(*wrapperspb.StringValue).Value)
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+func TestServer_Memory(t *testing.T) {
+	t.Run("Replay", func(t *testing.T) { testServer(t, NewMemoryStore(0)) })
+	t.Run("InFlight", func(t *testing.T) { testInFlight(t, NewMemoryStore(0)) })
+	t.Run("Failure", func(t *testing.T) { testFailure(t, NewMemoryStore(0)) })
+	t.Run("TakeOver", func(t *testing.T) { testTakeOver(t, NewMemoryStore(0), 50*time.Millisecond) })
+}
+
+func TestServer_Etcd(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping embedded etcd in short mode")
+	}
+	client := etcdtest.Start(t)
+	t.Run("Replay", func(t *testing.T) { testServer(t, NewEtcdStore(client, "/idempotency/"+t.Name())) })
+	t.Run("InFlight", func(t *testing.T) { testInFlight(t, NewEtcdStore(client, "/idempotency/"+t.Name())) })
+	t.Run("Failure", func(t *testing.T) { testFailure(t, NewEtcdStore(client, "/idempotency/"+t.Name())) })
+	t.Run("TakeOver", func(t *testing.T) { testTakeOver(t, NewEtcdStore(client, "/idempotency/"+t.Name()), time.Second) })
+}
+
+func TestServer_Required(t *testing.T) {
+	handler := Server(NewMemoryStore(0), WithRequired(), WithCodec(JSONCodec(func() any { return new(string) })))(
+		func(ctx context.Context, req any) (any, error) {
+			return "ok", nil
+		})
+	_, _, err := call(handler, "/order/Create", "")
+	assert.ErrorIs(t, err, ErrMissingKey)
+
+	_, _, err = call(handler, "/order/Create", "k")
+	require.NoError(t, err)
+	resp, _, err := call(handler, "/order/Create", "k")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", *resp.(*string))
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(2)
+	for _, key := range []string{"a", "b", "c"} {
+		_, record, err := s.Begin(ctx, key, time.Minute)
+		require.NoError(t, err)
+		assert.Nil(t, record)
+	}
+	// 超过容量时淘汰最久未访问的记录
+	assert.Equal(t, 2, s.Len())
+	owner, record, err := s.Begin(ctx, "a", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+
+	// 过期的记录视为不存在
+	require.NoError(t, s.Complete(ctx, "a", owner, []byte("resp"), 50*time.Millisecond))
+	_, record, err = s.Begin(ctx, "a", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, StatusCompleted, record.Status)
+	assert.Equal(t, []byte("resp"), record.Response)
+	time.Sleep(100 * time.Millisecond)
+	_, record, err = s.Begin(ctx, "a", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+}
+
+// testTakeOver 处理中记录过期后被重复的请求接管，原请求不能删除或覆盖接管者的记录
+func testTakeOver(t *testing.T, s Store, ttl time.Duration) {
+	ctx := context.Background()
+	first, record, err := s.Begin(ctx, "k", ttl)
+	require.NoError(t, err)
+	require.Nil(t, record)
+
+	var second int64
+	require.Eventually(t, func() bool {
+		second, record, err = s.Begin(ctx, "k", time.Minute)
+		return err == nil && record == nil
+	}, 10*time.Second, 20*time.Millisecond)
+	assert.NotEqual(t, first, second)
+
+	assert.ErrorIs(t, s.Release(ctx, "k", first), ErrNotOwner)
+	assert.ErrorIs(t, s.Complete(ctx, "k", first, []byte("first"), time.Minute), ErrNotOwner)
+	_, record, err = s.Begin(ctx, "k", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, StatusInFlight, record.Status)
+
+	require.NoError(t, s.Complete(ctx, "k", second, []byte("second"), time.Minute))
+	_, record, err = s.Begin(ctx, "k", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, []byte("second"), record.Response)
+	// 已完成的记录不能被删除
+	assert.ErrorIs(t, s.Release(ctx, "k", second), ErrNotOwner)
+}
+
+func TestLocales(t *testing.T) {
+	zh, en := i18n.Default().Localizer("zh"), i18n.Default().Localizer("en")
+	for _, err := range []error{ErrMissingKey, ErrRequestInFlight} {
+		id, _, ok := errors.GetHintID(err)
+		require.True(t, ok, err)
+		// 内置的中文消息与未经本地化时的提示信息一致
+		text, ok := zh.Localize(id, nil)
+		require.True(t, ok, id)
+		assert.Equal(t, errors.GetHint(err), text, id)
+		_, ok = en.Localize(id, nil)
+		assert.True(t, ok, id)
+	}
+}
@@ -0,0 +1,152 @@
+package idempotency
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const defaultMemoryStoreSize = 10000
+
+// ErrNotOwner 处理中记录已被其他请求接管，如处理时间超过处理中记录的保留时长后重复的请求重新开始处理
+var ErrNotOwner = errors.New("idempotency: record is owned by another request")
+
+// Status 请求的处理状态
+type Status int
+
+const (
+	// StatusInFlight 请求正在处理中
+	StatusInFlight Status = iota
+	// StatusCompleted 请求已处理成功，记录中保存了响应
+	StatusCompleted
+)
+
+// Record 幂等键对应的处理记录
+type Record struct {
+	Status    Status    `json:"status"`
+	Response  []byte    `json:"response,omitempty"` // 编码后的响应
+	UpdatedAt time.Time `json:"updated_at"`         // 记录的更新时间
+}
+
+// Store 处理记录的存储，多实例部署时需使用 EtcdStore 等共享存储
+type Store interface {
+	// Begin 开始处理请求，key 不存在时写入保留 ttl 的处理中记录，返回标识该记录持有者的 owner 及 nil；
+	// 已存在时返回已有记录
+	Begin(ctx context.Context, key string, ttl time.Duration) (owner int64, record *Record, err error)
+	// Complete 请求处理成功，保存响应并保留 ttl，记录已被其他请求接管时返回 ErrNotOwner
+	Complete(ctx context.Context, key string, owner int64, response []byte, ttl time.Duration) error
+	// Release 请求处理失败，删除记录以便客户端使用相同的幂等键重试，记录已被其他请求接管时返回 ErrNotOwner
+	Release(ctx context.Context, key string, owner int64) error
+}
+
+// MemoryStore 进程内的 LRU 存储，记录数超过容量时淘汰最久未访问的记录，适用于单实例部署及测试
+type MemoryStore struct {
+	mu    sync.Mutex
+	size  int
+	seq   int64 // 最近分配的 owner
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type memoryItem struct {
+	key      string
+	owner    int64 // 处理中记录的持有者，已完成的记录为0
+	record   Record
+	expireAt time.Time
+}
+
+// NewMemoryStore 创建容量为 size 的进程内存储，size 不大于0时使用默认容量10000
+func NewMemoryStore(size int) *MemoryStore {
+	if size <= 0 {
+		size = defaultMemoryStoreSize
+	}
+	return &MemoryStore{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Begin 开始处理请求，key 不存在时写入保留 ttl 的处理中记录，返回标识该记录持有者的 owner 及 nil；
+// 已存在时返回已有记录
+func (s *MemoryStore) Begin(_ context.Context, key string, ttl time.Duration) (int64, *Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if e, ok := s.items[key]; ok {
+		item := e.Value.(*memoryItem)
+		if now.Before(item.expireAt) {
+			s.ll.MoveToFront(e)
+			record := item.record
+			return 0, &record, nil
+		}
+		s.remove(e)
+	}
+	s.seq++
+	s.items[key] = s.ll.PushFront(&memoryItem{
+		key:      key,
+		owner:    s.seq,
+		record:   Record{Status: StatusInFlight, UpdatedAt: now},
+		expireAt: now.Add(ttl),
+	})
+	for s.ll.Len() > s.size {
+		s.remove(s.ll.Back())
+	}
+	return s.seq, nil, nil
+}
+
+// Complete 请求处理成功，保存响应并保留 ttl，记录已被其他请求接管时返回 ErrNotOwner
+func (s *MemoryStore) Complete(_ context.Context, key string, owner int64, response []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	e, ok := s.items[key]
+	if ok && e.Value.(*memoryItem).owner != owner {
+		return ErrNotOwner
+	}
+	if !ok {
+		// 处理中记录已被淘汰且未被其他请求接管，仍保存响应
+		e = s.ll.PushFront(&memoryItem{key: key})
+		s.items[key] = e
+		for s.ll.Len() > s.size {
+			s.remove(s.ll.Back())
+		}
+	}
+	item := e.Value.(*memoryItem)
+	item.owner = 0
+	item.record.Status = StatusCompleted
+	item.record.Response = response
+	item.record.UpdatedAt = now
+	item.expireAt = now.Add(ttl)
+	s.ll.MoveToFront(e)
+	return nil
+}
+
+// Release 请求处理失败，删除记录以便客户端使用相同的幂等键重试，记录已被其他请求接管时返回 ErrNotOwner
+func (s *MemoryStore) Release(_ context.Context, key string, owner int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.items[key]
+	if !ok {
+		return nil
+	}
+	if e.Value.(*memoryItem).owner != owner {
+		return ErrNotOwner
+	}
+	s.remove(e)
+	return nil
+}
+
+// Len 当前保存的记录数，包含已过期但尚未淘汰的记录
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ll.Len()
+}
+
+func (s *MemoryStore) remove(e *list.Element) {
+	s.ll.Remove(e)
+	delete(s.items, e.Value.(*memoryItem).key)
+}
@@ -0,0 +1,81 @@
+package localize
+
+import (
+	"context"
+
+	"github.com/haysons/gokit/i18n"
+	"github.com/haysons/gokit/metadata"
+	"github.com/haysons/gokit/middleware"
+	"github.com/haysons/gokit/transport"
+)
+
+const (
+	// DefaultMetadataKey 默认的语言元数据 key，优先级高于 Accept-Language，便于 grpc 调用方透传终端用户的语言
+	DefaultMetadataKey = "x-md-global-lang"
+	// AcceptLanguageHeader 标准的语言偏好 header
+	AcceptLanguageHeader = "Accept-Language"
+)
+
+type Option func(*options)
+
+type options struct {
+	bundle      *i18n.Bundle
+	metadataKey string
+}
+
+// WithBundle 配置消息目录，默认为 i18n.Default()
+func WithBundle(b *i18n.Bundle) Option {
+	return func(o *options) {
+		o.bundle = b
+	}
+}
+
+// WithMetadataKey 配置读取语言的元数据 key，默认为 x-md-global-lang
+func WithMetadataKey(key string) Option {
+	return func(o *options) {
+		o.metadataKey = key
+	}
+}
+
+// Server 根据请求的语言创建本地化器并注入 context，处理函数可通过 i18n.FromContext 获取；
+// 处理函数返回的错误中包含待本地化的提示信息（errors.WithHintID）时，将其解析为请求语言的提示信息。
+// 语言依次取自元数据、请求 header 中的元数据 key 及 Accept-Language，均不受支持时使用兜底语言
+func Server(opts ...Option) middleware.Middleware {
+	o := &options{
+		bundle:      i18n.Default(),
+		metadataKey: DefaultMetadataKey,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			l := o.bundle.Localizer(o.languages(ctx)...)
+			resp, err := next(i18n.NewContext(ctx, l), req)
+			if err != nil {
+				err = l.LocalizeError(err)
+			}
+			return resp, err
+		}
+	}
+}
+
+// languages 自请求中获取语言偏好，优先级由高到低
+func (o *options) languages(ctx context.Context) []string {
+	var langs []string
+	if md, ok := metadata.FromServerContext(ctx); ok {
+		if lang := md.Get(o.metadataKey); lang != "" {
+			langs = append(langs, lang)
+		}
+	}
+	if tr, ok := transport.FromServerContext(ctx); ok {
+		header := tr.RequestHeader()
+		if lang := header.Get(o.metadataKey); lang != "" {
+			langs = append(langs, lang)
+		}
+		if lang := header.Get(AcceptLanguageHeader); lang != "" {
+			langs = append(langs, lang)
+		}
+	}
+	return langs
+}
@@ -0,0 +1,94 @@
+package localize
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/haysons/gokit/errors"
+	"github.com/haysons/gokit/i18n"
+	"github.com/haysons/gokit/metadata"
+	"github.com/haysons/gokit/middleware/auth/jwt"
+	"github.com/haysons/gokit/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+type header http.Header
+
+func (h header) Get(key string) string      { return http.Header(h).Get(key) }
+func (h header) Set(key, value string)      { http.Header(h).Set(key, value) }
+func (h header) Add(key, value string)      { http.Header(h).Add(key, value) }
+func (h header) Values(key string) []string { return http.Header(h).Values(key) }
+func (h header) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type fakeTransport struct {
+	reqHeader header
+}
+
+func (tr *fakeTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (tr *fakeTransport) Operation() string               { return "/user/Get" }
+func (tr *fakeTransport) Endpoint() string                { return "" }
+func (tr *fakeTransport) RequestHeader() transport.Header { return tr.reqHeader }
+func (tr *fakeTransport) ReplyHeader() transport.Header   { return header{} }
+func (tr *fakeTransport) PathTemplate() string            { return "" }
+func (tr *fakeTransport) Request() interface{}            { return nil }
+
+func newContext(kv ...string) context.Context {
+	tr := &fakeTransport{reqHeader: header{}}
+	for i := 0; i < len(kv); i += 2 {
+		tr.reqHeader.Set(kv[i], kv[i+1])
+	}
+	return transport.InjectServerContext(context.Background(), tr)
+}
+
+func TestServer(t *testing.T) {
+	bundle := i18n.NewBundle()
+	require.NoError(t, bundle.AddMessages(language.Chinese, map[string]string{"user.quota": "已超出配额，上限为 {{.Limit}}"}))
+	require.NoError(t, bundle.AddMessages(language.English, map[string]string{"user.quota": "Quota exceeded, the limit is {{.Limit}}"}))
+	require.NoError(t, bundle.AddMessages(language.Japanese, map[string]string{"user.quota": "クォータを超えました"}))
+
+	var got language.Tag
+	handler := Server(WithBundle(bundle))(func(ctx context.Context, req any) (any, error) {
+		got = i18n.FromContext(ctx).Language()
+		return nil, errors.WithHintID(errors.New("quota exceeded"), "user.quota", map[string]any{"Limit": 5})
+	})
+
+	cases := []struct {
+		ctx      context.Context
+		wantLang language.Tag
+		wantHint string
+	}{
+		{newContext(AcceptLanguageHeader, "en-US,en;q=0.9"), language.English, "Quota exceeded, the limit is 5"},
+		{newContext(AcceptLanguageHeader, "fr"), language.Chinese, "已超出配额，上限为 5"},
+		{context.Background(), language.Chinese, "已超出配额，上限为 5"},
+		// 元数据中的语言优先于 Accept-Language
+		{newContext(DefaultMetadataKey, "ja", AcceptLanguageHeader, "en"), language.Japanese, "クォータを超えました"},
+		{metadata.InjectServerContext(newContext(AcceptLanguageHeader, "ja"), metadata.New(map[string][]string{DefaultMetadataKey: {"en"}})),
+			language.English, "Quota exceeded, the limit is 5"},
+	}
+	for i, c := range cases {
+		_, err := handler(c.ctx, nil)
+		require.Error(t, err)
+		assert.Equal(t, c.wantLang, got, i)
+		assert.Equal(t, c.wantHint, errors.GetHint(err), i)
+	}
+}
+
+func TestServer_BuiltinMessages(t *testing.T) {
+	handler := Server()(func(ctx context.Context, req any) (any, error) {
+		return nil, jwt.ErrTokenExpired
+	})
+	_, err := handler(newContext(AcceptLanguageHeader, "en"), nil)
+	assert.Equal(t, "Authorization token has expired", errors.GetHint(err))
+	_, err = handler(newContext(), nil)
+	assert.Equal(t, "jwt 已过期", errors.GetHint(err))
+	assert.True(t, errors.Is(err, jwt.ErrTokenExpired))
+}
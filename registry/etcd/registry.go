@@ -0,0 +1,246 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/haysons/gokit/log"
+	"github.com/haysons/gokit/registry"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+const (
+	defaultNamespace     = "/gokit/services"
+	defaultTTL           = 10 * time.Second
+	defaultRetryInterval = 5 * time.Second
+)
+
+var (
+	_ registry.Registrar = (*Registry)(nil)
+	_ registry.Discovery = (*Registry)(nil)
+)
+
+// Option 函数式配置项
+type Option func(*options)
+
+type options struct {
+	namespace     string        // 服务注册的 key 前缀
+	ttl           time.Duration // 租约时长
+	retryInterval time.Duration // 会话失效后重新注册的间隔
+}
+
+// WithNamespace 配置服务注册的 key 前缀，默认为 /gokit/services，实例 key 为 {namespace}/{name}/{id}
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// WithTTL 配置租约时长，实例异常退出后最长经过 ttl 其注册信息将被删除，默认为10s，
+// etcd 租约以秒为单位，不足1s的部分向上取整
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithRetryInterval 配置会话失效后重新注册的间隔，默认为5s
+func WithRetryInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.retryInterval = interval
+	}
+}
+
+// Registry 基于 etcd 的服务注册与发现，实例信息写入绑定租约的 key，并通过会话维持租约，
+// 会话失效（如网络分区导致租约过期）后将自动重新注册
+type Registry struct {
+	client *clientv3.Client
+	opts   options
+
+	mu            sync.Mutex
+	registrations map[string]*registration
+}
+
+// registration 单个实例的注册状态
+type registration struct {
+	key     string
+	value   string
+	cancel  context.CancelFunc
+	done    chan struct{}
+	mu      sync.Mutex
+	session *concurrency.Session
+}
+
+// New 创建基于 etcd 的服务注册与发现
+func New(client *clientv3.Client, opts ...Option) *Registry {
+	o := options{
+		namespace:     defaultNamespace,
+		ttl:           defaultTTL,
+		retryInterval: defaultRetryInterval,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Registry{
+		client:        client,
+		opts:          o,
+		registrations: make(map[string]*registration),
+	}
+}
+
+// Register 注册服务实例，同一实例重复注册时将覆盖此前的注册信息
+func (r *Registry) Register(ctx context.Context, service *registry.ServiceInstance) error {
+	if service == nil || service.Name == "" || service.ID == "" {
+		return errors.New("registry: service name and id are required")
+	}
+	value, err := json.Marshal(service)
+	if err != nil {
+		return fmt.Errorf("marshal service instance failed: %w", err)
+	}
+	key := r.instanceKey(service.Name, service.ID)
+	session, err := r.put(ctx, key, string(value))
+	if err != nil {
+		return err
+	}
+
+	regCtx, cancel := context.WithCancel(context.Background())
+	reg := &registration{
+		key:     key,
+		value:   string(value),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		session: session,
+	}
+	r.mu.Lock()
+	prev := r.registrations[key]
+	r.registrations[key] = reg
+	r.mu.Unlock()
+	if prev != nil {
+		prev.stop()
+	}
+	go r.keepAlive(regCtx, reg)
+	return nil
+}
+
+// Deregister 注销服务实例，撤销租约并删除实例信息
+func (r *Registry) Deregister(ctx context.Context, service *registry.ServiceInstance) error {
+	if service == nil {
+		return nil
+	}
+	key := r.instanceKey(service.Name, service.ID)
+	r.mu.Lock()
+	reg := r.registrations[key]
+	delete(r.registrations, key)
+	r.mu.Unlock()
+
+	var errs []error
+	if reg != nil {
+		errs = append(errs, reg.stop())
+	}
+	if _, err := r.client.Delete(ctx, key); err != nil {
+		errs = append(errs, fmt.Errorf("delete service instance failed: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// GetService 获取服务的全部实例
+func (r *Registry) GetService(ctx context.Context, name string) ([]*registry.ServiceInstance, error) {
+	resp, err := r.client.Get(ctx, r.serviceKey(name), clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("get service instances failed: %w", err)
+	}
+	instances := make([]*registry.ServiceInstance, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		si := new(registry.ServiceInstance)
+		if err = json.Unmarshal(kv.Value, si); err != nil {
+			// 忽略无法解析的实例
+			continue
+		}
+		instances = append(instances, si)
+	}
+	return instances, nil
+}
+
+// Watch 监听服务实例的变化
+func (r *Registry) Watch(ctx context.Context, name string) (registry.Watcher, error) {
+	return newWatcher(ctx, r, name), nil
+}
+
+// put 申请租约并写入实例信息，返回维持租约的会话
+func (r *Registry) put(ctx context.Context, key, value string) (*concurrency.Session, error) {
+	lease, err := r.client.Grant(ctx, leaseTTL(r.opts.ttl))
+	if err != nil {
+		return nil, fmt.Errorf("grant lease failed: %w", err)
+	}
+	session, err := concurrency.NewSession(r.client, concurrency.WithLease(lease.ID))
+	if err != nil {
+		return nil, fmt.Errorf("create session failed: %w", err)
+	}
+	if _, err = r.client.Put(ctx, key, value, clientv3.WithLease(session.Lease())); err != nil {
+		_ = session.Close()
+		return nil, fmt.Errorf("put service instance failed: %w", err)
+	}
+	return session, nil
+}
+
+// keepAlive 监听会话状态，会话失效后按间隔重试直至重新注册成功
+func (r *Registry) keepAlive(ctx context.Context, reg *registration) {
+	defer close(reg.done)
+	for {
+		reg.mu.Lock()
+		sessionDone := reg.session.Done()
+		reg.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return
+		case <-sessionDone:
+		}
+
+		log.GetDefaultSlog().Warn("registry session expired, re-registering", slog.String("key", reg.key))
+		for {
+			session, err := r.put(ctx, reg.key, reg.value)
+			if err == nil {
+				reg.mu.Lock()
+				reg.session = session
+				reg.mu.Unlock()
+				break
+			}
+			log.GetDefaultSlog().Error("re-register service instance failed", slog.String("key", reg.key), slog.Any("error", err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(r.opts.retryInterval):
+			}
+		}
+	}
+}
+
+// stop 停止维持注册状态并撤销租约
+func (reg *registration) stop() error {
+	reg.cancel()
+	<-reg.done
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	return reg.session.Close()
+}
+
+// leaseTTL 将租约时长转换为 etcd 租约的秒数，向上取整且至少为1s，避免不足1s的时长被截断为0
+func leaseTTL(ttl time.Duration) int64 {
+	return max(int64(math.Ceil(ttl.Seconds())), 1)
+}
+
+func (r *Registry) serviceKey(name string) string {
+	return path.Join(r.opts.namespace, name) + "/"
+}
+
+func (r *Registry) instanceKey(name, id string) string {
+	return path.Join(r.opts.namespace, name, id)
+}
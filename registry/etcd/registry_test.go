@@ -0,0 +1,118 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/haysons/gokit/internal/etcdtest"
+	"github.com/haysons/gokit/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func newInstance(id string) *registry.ServiceInstance {
+	return &registry.ServiceInstance{
+		ID:        id,
+		Name:      "helloworld",
+		Version:   "v1",
+		Endpoints: []string{"grpc://127.0.0.1:9000"},
+	}
+}
+
+func instanceIDs(instances []*registry.ServiceInstance) []string {
+	ids := make([]string, 0, len(instances))
+	for _, si := range instances {
+		ids = append(ids, si.ID)
+	}
+	return ids
+}
+
+func TestLeaseTTL(t *testing.T) {
+	// 不足1s的租约时长向上取整，而非截断为0
+	assert.EqualValues(t, 1, leaseTTL(0))
+	assert.EqualValues(t, 1, leaseTTL(500*time.Millisecond))
+	assert.EqualValues(t, 2, leaseTTL(1500*time.Millisecond))
+	assert.EqualValues(t, 10, leaseTTL(10*time.Second))
+}
+
+func TestRegistry(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip etcd registry tests in short mode")
+	}
+	client := etcdtest.Start(t)
+	ctx := context.Background()
+
+	t.Run("RegisterDeregister", func(t *testing.T) {
+		r := New(client, WithNamespace("/"+t.Name()))
+		si := newInstance("1")
+		require.NoError(t, r.Register(ctx, si))
+		instances, err := r.GetService(ctx, si.Name)
+		require.NoError(t, err)
+		require.Len(t, instances, 1)
+		assert.Equal(t, si, instances[0])
+
+		require.NoError(t, r.Deregister(ctx, si))
+		instances, err = r.GetService(ctx, si.Name)
+		require.NoError(t, err)
+		assert.Empty(t, instances)
+		assert.Error(t, r.Register(ctx, &registry.ServiceInstance{Name: "helloworld"}))
+	})
+
+	t.Run("ReRegisterAfterSessionLoss", func(t *testing.T) {
+		r := New(client, WithNamespace("/"+t.Name()), WithRetryInterval(100*time.Millisecond))
+		si := newInstance("1")
+		require.NoError(t, r.Register(ctx, si))
+		defer r.Deregister(ctx, si)
+
+		// 撤销租约模拟会话失效，实例信息随租约被删除后自动重新注册
+		key := r.instanceKey(si.Name, si.ID)
+		resp, err := client.Get(ctx, key)
+		require.NoError(t, err)
+		require.Len(t, resp.Kvs, 1)
+		lease := clientv3.LeaseID(resp.Kvs[0].Lease)
+		_, err = client.Revoke(ctx, lease)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			resp, err := client.Get(ctx, key)
+			return err == nil && len(resp.Kvs) == 1 && clientv3.LeaseID(resp.Kvs[0].Lease) != lease
+		}, 10*time.Second, 50*time.Millisecond)
+
+		// 注销后不再重新注册
+		require.NoError(t, r.Deregister(ctx, si))
+		time.Sleep(300 * time.Millisecond)
+		instances, err := r.GetService(ctx, si.Name)
+		require.NoError(t, err)
+		assert.Empty(t, instances)
+	})
+
+	t.Run("Watch", func(t *testing.T) {
+		r := New(client, WithNamespace("/"+t.Name()))
+		si1, si2 := newInstance("1"), newInstance("2")
+		require.NoError(t, r.Register(ctx, si1))
+		defer r.Deregister(ctx, si1)
+
+		w, err := r.Watch(ctx, si1.Name)
+		require.NoError(t, err)
+		// 首次调用立即返回全部实例
+		instances, err := w.Next()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1"}, instanceIDs(instances))
+
+		require.NoError(t, r.Register(ctx, si2))
+		instances, err = w.Next()
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"1", "2"}, instanceIDs(instances))
+
+		require.NoError(t, r.Deregister(ctx, si2))
+		instances, err = w.Next()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1"}, instanceIDs(instances))
+
+		require.NoError(t, w.Stop())
+		_, err = w.Next()
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,67 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/haysons/gokit/registry"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+var _ registry.Watcher = (*watcher)(nil)
+
+// watcher 监听服务前缀下全部 key 的变化，每次变化后返回服务当前的全部实例
+type watcher struct {
+	r       *Registry
+	name    string
+	prefix  string
+	ctx     context.Context
+	cancel  context.CancelFunc
+	watchCh clientv3.WatchChan
+	first   bool
+}
+
+func newWatcher(ctx context.Context, r *Registry, name string) *watcher {
+	ctx, cancel := context.WithCancel(ctx)
+	w := &watcher{
+		r:      r,
+		name:   name,
+		prefix: r.serviceKey(name),
+		ctx:    ctx,
+		cancel: cancel,
+		first:  true,
+	}
+	// 先建立监听再获取全量实例，避免遗漏两者之间发生的变化
+	w.watchCh = r.client.Watch(w.ctx, w.prefix, clientv3.WithPrefix())
+	return w
+}
+
+// Next 首次调用立即返回全部实例，此后阻塞直至实例发生变化
+func (w *watcher) Next() ([]*registry.ServiceInstance, error) {
+	if w.first {
+		w.first = false
+		return w.r.GetService(w.ctx, w.name)
+	}
+	select {
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case resp, ok := <-w.watchCh:
+		if !ok {
+			if err := w.ctx.Err(); err != nil {
+				return nil, err
+			}
+			return nil, errors.New("registry: watch channel closed")
+		}
+		if resp.Err() != nil {
+			// 监听异常（如历史版本被压缩）时重新建立监听，并返回全量实例
+			w.watchCh = w.r.client.Watch(w.ctx, w.prefix, clientv3.WithPrefix())
+		}
+		return w.r.GetService(w.ctx, w.name)
+	}
+}
+
+// Stop 停止监听
+func (w *watcher) Stop() error {
+	w.cancel()
+	return nil
+}
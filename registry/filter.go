@@ -0,0 +1,36 @@
+package registry
+
+// Filter 服务实例过滤器，返回 true 表示保留该实例
+type Filter func(*ServiceInstance) bool
+
+// Version 仅保留指定版本的实例
+func Version(version string) Filter {
+	return func(si *ServiceInstance) bool {
+		return si.Version == version
+	}
+}
+
+// Metadata 仅保留元数据中 key 对应值为 value 的实例，如按机房、分组过滤
+func Metadata(key, value string) Filter {
+	return func(si *ServiceInstance) bool {
+		return si.Metadata[key] == value
+	}
+}
+
+// ApplyFilters 过滤服务实例，仅保留满足全部过滤器的实例
+func ApplyFilters(instances []*ServiceInstance, filters ...Filter) []*ServiceInstance {
+	if len(filters) == 0 {
+		return instances
+	}
+	out := make([]*ServiceInstance, 0, len(instances))
+next:
+	for _, si := range instances {
+		for _, f := range filters {
+			if !f(si) {
+				continue next
+			}
+		}
+		out = append(out, si)
+	}
+	return out
+}
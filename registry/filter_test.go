@@ -0,0 +1,29 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyFilters(t *testing.T) {
+	instances := []*ServiceInstance{
+		{ID: "1", Version: "v1", Metadata: map[string]string{"zone": "a"}},
+		{ID: "2", Version: "v2", Metadata: map[string]string{"zone": "a"}},
+		{ID: "3", Version: "v1", Metadata: map[string]string{"zone": "b"}},
+	}
+	assert.Len(t, ApplyFilters(instances), 3)
+
+	out := ApplyFilters(instances, Version("v1"), Metadata("zone", "a"))
+	assert.Len(t, out, 1)
+	assert.Equal(t, "1", out[0].ID)
+
+	assert.Empty(t, ApplyFilters(instances, Version("v3")))
+}
+
+func TestServiceInstanceEndpoint(t *testing.T) {
+	si := &ServiceInstance{Endpoints: []string{"http://10.0.0.1:8000", "grpc://10.0.0.1:9000"}}
+	assert.Equal(t, "10.0.0.1:9000", si.Endpoint("grpc"))
+	assert.Equal(t, "10.0.0.1:8000", si.Endpoint("http"))
+	assert.Equal(t, "", si.Endpoint("https"))
+}
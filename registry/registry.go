@@ -0,0 +1,54 @@
+package registry
+
+import (
+	"context"
+	"net/url"
+)
+
+// ServiceInstance 服务实例
+type ServiceInstance struct {
+	ID        string            `json:"id"`        // 实例唯一标识
+	Name      string            `json:"name"`      // 服务名称
+	Version   string            `json:"version"`   // 服务版本
+	Metadata  map[string]string `json:"metadata"`  // 元数据，如权重、机房等
+	Endpoints []string          `json:"endpoints"` // 服务入口点，如 grpc://10.0.0.1:9000、http://10.0.0.1:8000
+}
+
+// Endpoint 获取指定协议的入口点，如 scheme 为 grpc 时返回 10.0.0.1:9000，不存在时返回空字符串
+func (s *ServiceInstance) Endpoint(scheme string) string {
+	for _, ep := range s.Endpoints {
+		u, err := url.Parse(ep)
+		if err != nil {
+			continue
+		}
+		if u.Scheme == scheme {
+			return u.Host
+		}
+	}
+	return ""
+}
+
+// Registrar 服务注册
+type Registrar interface {
+	// Register 注册服务实例，注册后由实现方负责维持注册状态，直至调用 Deregister
+	Register(ctx context.Context, service *ServiceInstance) error
+	// Deregister 注销服务实例
+	Deregister(ctx context.Context, service *ServiceInstance) error
+}
+
+// Discovery 服务发现
+type Discovery interface {
+	// GetService 获取服务的全部实例
+	GetService(ctx context.Context, name string) ([]*ServiceInstance, error)
+	// Watch 监听服务实例的变化
+	Watch(ctx context.Context, name string) (Watcher, error)
+}
+
+// Watcher 服务实例变化的监听器
+type Watcher interface {
+	// Next 阻塞直至首次调用或服务实例发生变化，返回服务当前的全部实例，
+	// 监听器停止或 ctx 取消后返回错误
+	Next() ([]*ServiceInstance, error)
+	// Stop 停止监听
+	Stop() error
+}
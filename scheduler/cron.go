@@ -0,0 +1,255 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 任务的调度计划
+type Schedule interface {
+	// Next 返回 t 之后的下一次执行时间，不存在时返回零值
+	Next(t time.Time) time.Time
+}
+
+// starBit 字段为 * 或 ? 时置位，用于判断日与周是否受限
+const starBit = 1 << 63
+
+// cronField cron 字段的取值范围及名称
+type cronField struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	secondField = cronField{min: 0, max: 59}
+	minuteField = cronField{min: 0, max: 59}
+	hourField   = cronField{min: 0, max: 23}
+	domField    = cronField{min: 1, max: 31}
+	monthField  = cronField{min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 星期取值 0-7，0 与 7 均为周日
+	dowField = cronField{min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// ParseCron 解析 cron 表达式，执行时间以传入 Next 的时间所在的时区计算，支持以下格式：
+//
+//	分 时 日 月 周          如 */5 * * * *，每5分钟执行
+//	秒 分 时 日 月 周       如 30 0 9 * * MON-FRI，工作日 09:00:30 执行
+//	@yearly @monthly @weekly @daily @hourly 等预定义表达式
+//	@every 1h30m           固定间隔执行，间隔最短为1s
+//
+// 字段支持 * ? , - / 语法，月及周支持英文缩写，日与周均受限时满足其一即执行，与标准 cron 一致
+func ParseCron(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, errors.New("empty cron spec")
+	}
+	if d, ok := strings.CutPrefix(spec, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(d))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron spec %q: %w", spec, err)
+		}
+		if interval < time.Second {
+			return nil, fmt.Errorf("invalid cron spec %q: interval must be at least 1s", spec)
+		}
+		return everySchedule{interval: interval.Truncate(time.Second)}, nil
+	}
+	if expanded, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = expanded
+	} else if strings.HasPrefix(spec, "@") {
+		return nil, fmt.Errorf("invalid cron spec %q: unknown descriptor", spec)
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("invalid cron spec %q: expected 5 or 6 fields, got %d", spec, len(fields))
+	}
+	var (
+		s   cronSchedule
+		err error
+	)
+	for i, f := range []struct {
+		dst   *uint64
+		field cronField
+	}{
+		{&s.second, secondField},
+		{&s.minute, minuteField},
+		{&s.hour, hourField},
+		{&s.dom, domField},
+		{&s.month, monthField},
+		{&s.dow, dowField},
+	} {
+		if *f.dst, err = parseField(fields[i], f.field); err != nil {
+			return nil, fmt.Errorf("invalid cron spec %q: %w", spec, err)
+		}
+	}
+	// 7 与 0 均表示周日
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	return s, nil
+}
+
+// parseField 解析以逗号分隔的字段，返回取值的位图
+func parseField(expr string, field cronField) (uint64, error) {
+	var bitmap uint64
+	for part := range strings.SplitSeq(expr, ",") {
+		b, err := parseRange(part, field)
+		if err != nil {
+			return 0, err
+		}
+		bitmap |= b
+	}
+	return bitmap, nil
+}
+
+// parseRange 解析 *、a、a-b 及其后可选的 /step
+func parseRange(expr string, field cronField) (uint64, error) {
+	rangeExpr, stepExpr, hasStep := strings.Cut(expr, "/")
+	lo, hi := field.min, field.max
+	var extra uint64
+	switch rangeExpr {
+	case "*", "?":
+		if !hasStep {
+			extra = starBit
+		}
+	default:
+		loExpr, hiExpr, hasHi := strings.Cut(rangeExpr, "-")
+		var err error
+		if lo, err = parseValue(loExpr, field); err != nil {
+			return 0, err
+		}
+		switch {
+		case hasHi:
+			if hi, err = parseValue(hiExpr, field); err != nil {
+				return 0, err
+			}
+		case !hasStep:
+			hi = lo
+		}
+	}
+	step := 1
+	if hasStep {
+		var err error
+		if step, err = strconv.Atoi(stepExpr); err != nil || step <= 0 {
+			return 0, fmt.Errorf("invalid step %q", stepExpr)
+		}
+	}
+	if lo > hi {
+		return 0, fmt.Errorf("invalid range %q", expr)
+	}
+	var bitmap uint64
+	for v := lo; v <= hi; v += step {
+		bitmap |= 1 << v
+	}
+	return bitmap | extra, nil
+}
+
+// parseValue 解析数值或名称，并校验取值范围
+func parseValue(expr string, field cronField) (int, error) {
+	if v, ok := field.names[strings.ToLower(expr)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(expr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", expr)
+	}
+	if v < field.min || v > field.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, field.min, field.max)
+	}
+	return v, nil
+}
+
+// cronSchedule cron 表达式对应的调度计划，各字段为取值的位图
+type cronSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+}
+
+// Next 返回 t 之后的下一次执行时间，5年内不存在满足条件的时间时返回零值
+func (s cronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Second).Add(time.Second)
+	yearLimit := t.Year() + 5
+
+	// 自高位字段起逐个匹配，低位字段进位时自高位字段重新匹配
+wrap:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+	for !has(s.month, int(t.Month())) {
+		t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+	for !s.dayMatches(t) {
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+	for !has(s.hour, t.Hour()) {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+	for !has(s.minute, t.Minute()) {
+		t = t.Truncate(time.Minute).Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+	for !has(s.second, t.Second()) {
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto wrap
+		}
+	}
+	return t
+}
+
+// dayMatches 日与周任一为 * 时需同时满足，否则满足其一即可
+func (s cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := has(s.dom, t.Day())
+	dowMatch := has(s.dow, int(t.Weekday()))
+	if s.dom&starBit != 0 || s.dow&starBit != 0 {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+func has(bitmap uint64, v int) bool {
+	return bitmap&(1<<v) != 0
+}
+
+// everySchedule 固定间隔的调度计划
+type everySchedule struct {
+	interval time.Duration
+}
+
+// Next 返回 t 之后间隔 interval 的时间，精确到秒
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Truncate(time.Second).Add(s.interval)
+}
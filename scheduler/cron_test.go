@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCron_Next(t *testing.T) {
+	from := time.Date(2024, 1, 31, 10, 15, 30, 500, time.UTC) // 周三
+	cases := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 31, 10, 16, 0, 0, time.UTC)},
+		{"* * * * * *", time.Date(2024, 1, 31, 10, 15, 31, 0, time.UTC)},
+		{"*/20 * * * *", time.Date(2024, 1, 31, 10, 20, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{"30 0 9 * * MON-FRI", time.Date(2024, 2, 1, 9, 0, 30, 0, time.UTC)},
+		{"0 0 * * SAT,SUN", time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1-7/3 jan-mar ?", time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)},
+		// 日与周均受限时满足其一即可
+		{"0 0 15 * FRI", time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 1, 31, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", time.Date(2024, 1, 31, 10, 17, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		s, err := ParseCron(c.spec)
+		require.NoError(t, err, c.spec)
+		assert.Equal(t, c.want, s.Next(from), c.spec)
+	}
+}
+
+func TestParseCron_Location(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	s, err := ParseCron("0 9 * * *")
+	require.NoError(t, err)
+	next := s.Next(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC).In(loc))
+	assert.Equal(t, time.Date(2024, 1, 31, 9, 0, 0, 0, loc), next)
+	assert.Equal(t, time.Date(2024, 1, 31, 1, 0, 0, 0, time.UTC), next.UTC())
+}
+
+func TestParseCron_Never(t *testing.T) {
+	s, err := ParseCron("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, s.Next(time.Now()).IsZero())
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"@every 500ms",
+		"@every x",
+		"@fortnightly",
+	} {
+		_, err := ParseCron(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,159 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/haysons/gokit/distributed"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	defaultMisfireThreshold = 5 * time.Second
+	defaultMaxCatchUp       = 10
+)
+
+const (
+	DefaultRunsCounterName      = "scheduler_job_runs_total"
+	DefaultSecondsHistogramName = "scheduler_job_seconds_bucket"
+)
+
+// MisfirePolicy 误触发策略，即错过计划执行时间（如 leader 切换、实例重启或上一次执行耗时过长）后的处理方式
+type MisfirePolicy int
+
+const (
+	// MisfireRunOnce 错过的执行合并为一次，立即补执行，默认策略
+	MisfireRunOnce MisfirePolicy = iota
+	// MisfireSkip 跳过错过的执行，等待下一次计划执行时间
+	MisfireSkip
+	// MisfireRunAll 依次补执行错过的执行，最多补执行最近的 WithMaxCatchUp 次
+	MisfireRunAll
+)
+
+// Option 调度器配置项
+type Option func(*options)
+
+type options struct {
+	id       string                  // 实例 id，记录于任务状态中
+	elector  distributed.Elector     // 未配置任务锁的任务仅在 leader 上执行，为 nil 时在全部实例上执行
+	store    Store                   // 任务状态的存储
+	location *time.Location          // cron 表达式所使用的时区
+	runs     metric.Int64Counter     // 任务执行计数器
+	seconds  metric.Float64Histogram // 任务执行耗时直方图
+}
+
+// WithID 配置实例 id，默认为 elector 的 id，未配置 elector 时为 xid
+func WithID(id string) Option {
+	return func(o *options) {
+		o.id = id
+	}
+}
+
+// WithElector 配置领导者选举，未配置任务锁的任务仅在 leader 上执行，elector 的生命周期由调用方管理
+func WithElector(elector distributed.Elector) Option {
+	return func(o *options) {
+		o.elector = elector
+	}
+}
+
+// WithStore 配置任务状态的存储，默认为进程内存储，多实例部署时需使用 EtcdStore 等共享存储，
+// 以便 leader 切换后新的 leader 不会重复执行或遗漏执行
+func WithStore(store Store) Option {
+	return func(o *options) {
+		o.store = store
+	}
+}
+
+// WithLocation 配置 cron 表达式所使用的时区，默认为 time.Local
+func WithLocation(loc *time.Location) Option {
+	return func(o *options) {
+		o.location = loc
+	}
+}
+
+// WithRuns 统计不同任务及结果的累计执行次数，结果包括 success、failure 及 misfire
+func WithRuns(c metric.Int64Counter) Option {
+	return func(o *options) {
+		o.runs = c
+	}
+}
+
+// WithSeconds 统计任务执行耗时的分布情况
+func WithSeconds(histogram metric.Float64Histogram) Option {
+	return func(o *options) {
+		o.seconds = histogram
+	}
+}
+
+// DefaultRunsCounter 任务执行计数器，构造完成后可通过 WithRuns 统计累计执行次数
+func DefaultRunsCounter(meter metric.Meter, name string) (metric.Int64Counter, error) {
+	return meter.Int64Counter(name, metric.WithUnit("{run}"))
+}
+
+// DefaultSecondsHistogram 任务执行耗时直方图，构造完成后可通过 WithSeconds 统计执行耗时的分布情况
+func DefaultSecondsHistogram(meter metric.Meter, name string) (metric.Float64Histogram, error) {
+	return meter.Float64Histogram(
+		name,
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300),
+	)
+}
+
+// JobOption 任务配置项
+type JobOption func(*jobOptions)
+
+type jobOptions struct {
+	locker           distributed.Locker // 任务锁，配置后获取锁的实例执行任务，不再依赖 leader
+	misfire          MisfirePolicy      // 误触发策略
+	misfireThreshold time.Duration      // 晚于计划执行时间超过该时长视为错过执行
+	maxCatchUp       int                // MisfireRunAll 策略下最多补执行的次数
+	timeout          time.Duration      // 单次执行的超时时间，为0时不限制
+}
+
+func newJobOptions(opts ...JobOption) jobOptions {
+	o := jobOptions{
+		misfire:          MisfireRunOnce,
+		misfireThreshold: defaultMisfireThreshold,
+		maxCatchUp:       defaultMaxCatchUp,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.maxCatchUp = max(o.maxCatchUp, 1)
+	return o
+}
+
+// WithLocker 配置任务锁，每次执行前尝试获取锁，获取成功的实例执行任务，执行期间锁丢失时取消任务的 ctx。
+// 配置任务锁后任务不再依赖 leader，可将不同任务分散至不同实例执行
+func WithLocker(locker distributed.Locker) JobOption {
+	return func(o *jobOptions) {
+		o.locker = locker
+	}
+}
+
+// WithMisfirePolicy 配置误触发策略，默认为 MisfireRunOnce
+func WithMisfirePolicy(policy MisfirePolicy) JobOption {
+	return func(o *jobOptions) {
+		o.misfire = policy
+	}
+}
+
+// WithMisfireThreshold 配置误触发阈值，晚于计划执行时间超过该时长视为错过执行，默认为5s
+func WithMisfireThreshold(d time.Duration) JobOption {
+	return func(o *jobOptions) {
+		o.misfireThreshold = d
+	}
+}
+
+// WithMaxCatchUp 配置 MisfireRunAll 策略下最多补执行的次数，默认为10，更早的执行将被跳过
+func WithMaxCatchUp(n int) JobOption {
+	return func(o *jobOptions) {
+		o.maxCatchUp = n
+	}
+}
+
+// WithTimeout 配置单次执行的超时时间，默认不限制
+func WithTimeout(d time.Duration) JobOption {
+	return func(o *jobOptions) {
+		o.timeout = d
+	}
+}
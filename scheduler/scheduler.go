@@ -0,0 +1,497 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/haysons/gokit/log"
+	"github.com/haysons/gokit/transport"
+	"github.com/haysons/gokit/util/uid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var _ transport.Server = (*Scheduler)(nil)
+
+const (
+	metricLabelJob    = "job"
+	metricLabelResult = "result"
+
+	resultSuccess = "success"
+	resultFailure = "failure"
+	resultMisfire = "misfire"
+)
+
+// Job 定时任务，ctx 在调度器停止、失去执行权（失去领导权或任务锁丢失）或执行超时时取消
+type Job func(ctx context.Context) error
+
+// JobStats 任务在当前实例上的执行统计
+type JobStats struct {
+	Name         string
+	Spec         string
+	Runs         int64         // 执行次数
+	Failures     int64         // 执行失败的次数，包括 panic
+	Misfires     int64         // 按误触发策略未执行的计划执行次数
+	Running      bool          // 是否正在执行
+	LastRun      time.Time     // 最近一次执行对应的计划执行时间
+	LastDuration time.Duration // 最近一次执行的耗时
+	LastError    string        // 最近一次执行的错误，成功时为空
+	NextRun      time.Time     // 下一次计划执行时间
+}
+
+// job 已注册的任务
+type job struct {
+	name     string
+	spec     string
+	schedule Schedule
+	fn       Job
+	opts     jobOptions
+	since    time.Time     // 开始调度的时间，任务不存在状态时作为计算首次执行时间的起点
+	wake     chan struct{} // 成为 leader 时唤醒任务，检查错过的执行
+
+	mu    sync.Mutex
+	stats JobStats
+}
+
+// Scheduler 分布式定时任务调度器，按 cron 表达式调度任务，任务默认仅在 leader 上执行，也可为任务配置独立的分布式锁。
+// 任务每次执行前先在 Store 中以乐观锁认领计划执行时间，leader 切换或多实例争抢时同一计划执行时间仅执行一次，
+// 新的 leader 根据已记录的最近一次执行时间，按误触发策略处理切换期间错过的执行。
+// Scheduler 实现了 transport.Server，可通过 app.AsServer 交由应用管理其生命周期
+type Scheduler struct {
+	opts options
+
+	mu      sync.Mutex
+	jobs    map[string]*job
+	ctx     context.Context    // 运行期间有效，未启动时为 nil
+	cancel  context.CancelFunc // 停止调度
+	term    context.Context    // 当前任期的 ctx，非 leader 时为 nil
+	elected sync.Once
+	wg      sync.WaitGroup
+}
+
+// New 创建调度器
+func New(opts ...Option) *Scheduler {
+	o := options{location: time.Local}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.store == nil {
+		o.store = NewMemoryStore()
+	}
+	if o.id == "" {
+		if o.elector != nil {
+			o.id = o.elector.ID()
+		} else {
+			o.id = uid.XID()
+		}
+	}
+	return &Scheduler{opts: o, jobs: make(map[string]*job)}
+}
+
+// Add 按 cron 表达式注册任务，表达式格式见 ParseCron，调度器运行期间注册的任务立即开始调度
+func (s *Scheduler) Add(name, spec string, fn Job, opts ...JobOption) error {
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+	return s.add(name, spec, schedule, fn, opts...)
+}
+
+// AddSchedule 按自定义的调度计划注册任务
+func (s *Scheduler) AddSchedule(name string, schedule Schedule, fn Job, opts ...JobOption) error {
+	return s.add(name, "", schedule, fn, opts...)
+}
+
+func (s *Scheduler) add(name, spec string, schedule Schedule, fn Job, opts ...JobOption) error {
+	if name == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("invalid job name %q", name)
+	}
+	if fn == nil {
+		return fmt.Errorf("job %s: nil function", name)
+	}
+	j := &job{
+		name:     name,
+		spec:     spec,
+		schedule: schedule,
+		fn:       fn,
+		opts:     newJobOptions(opts...),
+		wake:     make(chan struct{}, 1),
+		stats:    JobStats{Name: name, Spec: spec},
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("job %s already exists", name)
+	}
+	s.jobs[name] = j
+	if s.ctx != nil {
+		s.startJob(j)
+	}
+	return nil
+}
+
+// Start 开始调度全部任务，阻塞直至 Stop 被调用或 ctx 结束
+func (s *Scheduler) Start(ctx context.Context) error {
+	s.mu.Lock()
+	if s.ctx != nil {
+		s.mu.Unlock()
+		return errors.New("scheduler already started")
+	}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	runCtx := s.ctx
+	for _, j := range s.jobs {
+		s.startJob(j)
+	}
+	s.mu.Unlock()
+
+	if s.opts.elector != nil {
+		s.elected.Do(func() { s.opts.elector.OnElected(s.onElected) })
+	}
+	<-runCtx.Done()
+	s.wg.Wait()
+	return nil
+}
+
+// Stop 停止调度，取消正在执行的任务的 ctx，并等待其返回或 ctx 结束
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats 返回全部任务在当前实例上的执行统计，按任务名排序
+func (s *Scheduler) Stats() []JobStats {
+	s.mu.Lock()
+	jobs := make([]*job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	s.mu.Unlock()
+
+	stats := make([]JobStats, 0, len(jobs))
+	for _, j := range jobs {
+		j.mu.Lock()
+		stats = append(stats, j.stats)
+		j.mu.Unlock()
+	}
+	slices.SortFunc(stats, func(a, b JobStats) int { return strings.Compare(a.Name, b.Name) })
+	return stats
+}
+
+// State 返回 Store 中记录的任务状态，包括全部实例上最近一次执行及下一次计划执行的时间
+func (s *Scheduler) State(ctx context.Context, name string) (State, error) {
+	s.mu.Lock()
+	_, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return State{}, fmt.Errorf("job %s not found", name)
+	}
+	state, _, err := s.opts.store.Load(ctx, name)
+	return state, err
+}
+
+// startJob 启动任务的调度循环，需持有 mu
+func (s *Scheduler) startJob(j *job) {
+	j.since = s.now()
+	s.wg.Add(1)
+	go s.loop(s.ctx, j)
+}
+
+// onElected 成为 leader 时记录任期 ctx 并唤醒全部任务，检查切换期间错过的执行
+func (s *Scheduler) onElected(term context.Context) {
+	s.mu.Lock()
+	s.term = term
+	jobs := make([]*job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	s.mu.Unlock()
+	for _, j := range jobs {
+		select {
+		case j.wake <- struct{}{}:
+		default:
+		}
+	}
+
+	<-term.Done()
+	s.mu.Lock()
+	if s.term == term {
+		s.term = nil
+	}
+	s.mu.Unlock()
+}
+
+// leaderTerm 当前为 leader 时返回任期 ctx，否则返回 nil
+func (s *Scheduler) leaderTerm() context.Context {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.term == nil || s.term.Err() != nil || !s.opts.elector.IsLeader() {
+		return nil
+	}
+	return s.term
+}
+
+func (s *Scheduler) now() time.Time {
+	return time.Now().In(s.opts.location)
+}
+
+// loop 任务的调度循环，每次到达计划执行时间或被唤醒时检查并执行已到期的执行
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	defer s.wg.Done()
+	for {
+		s.fire(ctx, j)
+
+		next := j.schedule.Next(s.now())
+		j.mu.Lock()
+		j.stats.NextRun = next
+		j.mu.Unlock()
+		if !s.wait(ctx, j, next) {
+			return
+		}
+	}
+}
+
+// wait 等待至 next 或任务被唤醒，next 为零值时仅等待唤醒，ctx 结束时返回 false
+func (s *Scheduler) wait(ctx context.Context, j *job, next time.Time) bool {
+	var timer <-chan time.Time
+	if !next.IsZero() {
+		t := time.NewTimer(time.Until(next))
+		defer t.Stop()
+		timer = t.C
+	}
+	select {
+	case <-timer:
+		return true
+	case <-j.wake:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// fire 获取执行权后，认领并按误触发策略执行已到期的计划执行
+func (s *Scheduler) fire(ctx context.Context, j *job) {
+	runCtx, release, ok := s.acquire(ctx, j)
+	if !ok {
+		return
+	}
+	defer release()
+
+	logger := log.GetDefaultSlog().With(slog.String("job", j.name))
+	state, version, err := s.opts.store.Load(runCtx, j.name)
+	if err != nil {
+		logger.Error("load job state failed", slog.Any("error", err))
+		return
+	}
+	now := s.now()
+	runs, last, misfires := j.plan(state, now, s.opts.location)
+	if last.IsZero() {
+		return
+	}
+	if misfires > 0 {
+		j.mu.Lock()
+		j.stats.Misfires += int64(misfires)
+		j.mu.Unlock()
+		s.recordRuns(runCtx, j.name, resultMisfire, int64(misfires))
+		logger.Warn("job misfired", slog.Int("misfires", misfires), slog.Time("last_scheduled", last))
+	}
+
+	state.NextRun = j.schedule.Next(now)
+	if len(runs) == 0 {
+		// 跳过错过的执行，推进最近一次执行时间，避免其他实例重复判断
+		state.LastRun = last
+		if _, err = s.opts.store.Save(runCtx, j.name, state, version); err != nil && !errors.Is(err, ErrStateConflict) {
+			logger.Error("save job state failed", slog.Any("error", err))
+		}
+		return
+	}
+	for _, scheduled := range runs {
+		// 先认领再执行，认领失败说明该计划执行时间已被其他实例执行
+		state.LastRun = scheduled
+		state.LastStart = s.now()
+		state.Runner = s.opts.id
+		if version, err = s.opts.store.Save(runCtx, j.name, state, version); err != nil {
+			if !errors.Is(err, ErrStateConflict) {
+				logger.Error("claim job failed", slog.Any("error", err))
+			}
+			return
+		}
+
+		duration, runErr := s.execute(runCtx, j, scheduled)
+		state.LastDuration = duration
+		state.LastError = ""
+		if runErr != nil {
+			state.LastError = runErr.Error()
+		}
+		if version, err = s.opts.store.Save(context.WithoutCancel(runCtx), j.name, state, version); err != nil {
+			if !errors.Is(err, ErrStateConflict) {
+				logger.Error("save job state failed", slog.Any("error", err))
+			}
+			return
+		}
+		if runCtx.Err() != nil {
+			return
+		}
+	}
+}
+
+// acquire 获取任务的执行权，返回执行期间有效的 ctx，失去执行权（失去领导权或任务锁丢失）时 ctx 将被取消
+func (s *Scheduler) acquire(ctx context.Context, j *job) (context.Context, func(), bool) {
+	switch {
+	case j.opts.locker != nil:
+		ok, err := j.opts.locker.TryLock(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.GetDefaultSlog().Error("acquire job lock failed", slog.String("job", j.name), slog.Any("error", err))
+			}
+			return nil, nil, false
+		}
+		if !ok {
+			return nil, nil, false
+		}
+		runCtx, cancel := context.WithCancel(ctx)
+		lost := j.opts.locker.Done()
+		go func() {
+			select {
+			case <-lost:
+				cancel()
+			case <-runCtx.Done():
+			}
+		}()
+		return runCtx, func() {
+			cancel()
+			if err := j.opts.locker.Unlock(context.WithoutCancel(ctx)); err != nil {
+				log.GetDefaultSlog().Warn("release job lock failed", slog.String("job", j.name), slog.Any("error", err))
+			}
+		}, true
+	case s.opts.elector != nil:
+		term := s.leaderTerm()
+		if term == nil {
+			return nil, nil, false
+		}
+		runCtx, cancel := context.WithCancel(ctx)
+		stop := context.AfterFunc(term, cancel)
+		return runCtx, func() {
+			stop()
+			cancel()
+		}, true
+	default:
+		runCtx, cancel := context.WithCancel(ctx)
+		return runCtx, cancel, true
+	}
+}
+
+// plan 根据任务状态计算 now 之前已到期的计划执行时间，返回按误触发策略需执行的时间、最近一次到期的时间及未执行的次数，
+// 不存在到期的执行时 last 为零值
+func (j *job) plan(state State, now time.Time, loc *time.Location) (runs []time.Time, last time.Time, misfires int) {
+	base := state.LastRun
+	if base.IsZero() {
+		base = j.since
+	}
+	var (
+		due   []time.Time
+		total int
+	)
+	for t := j.schedule.Next(base.In(loc)); !t.IsZero() && !t.After(now); t = j.schedule.Next(t) {
+		total++
+		due = append(due, t)
+		if len(due) > j.opts.maxCatchUp {
+			due = due[1:]
+		}
+	}
+	if total == 0 {
+		return nil, time.Time{}, 0
+	}
+	last = due[len(due)-1]
+	switch j.opts.misfire {
+	case MisfireSkip:
+		if now.Sub(last) <= j.opts.misfireThreshold {
+			runs = due[len(due)-1:]
+		}
+	case MisfireRunAll:
+		runs = due
+	default:
+		runs = due[len(due)-1:]
+	}
+	return runs, last, total - len(runs)
+}
+
+// execute 执行任务并记录统计及指标，返回执行耗时及错误，任务 panic 时视为执行失败
+func (s *Scheduler) execute(ctx context.Context, j *job, scheduled time.Time) (duration time.Duration, err error) {
+	if j.opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.opts.timeout)
+		defer cancel()
+	}
+	j.mu.Lock()
+	j.stats.Running = true
+	j.mu.Unlock()
+
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panic: %v", r)
+		}
+		duration = time.Since(start)
+
+		result := resultSuccess
+		j.mu.Lock()
+		j.stats.Running = false
+		j.stats.Runs++
+		j.stats.LastRun = scheduled
+		j.stats.LastDuration = duration
+		j.stats.LastError = ""
+		if err != nil {
+			result = resultFailure
+			j.stats.Failures++
+			j.stats.LastError = err.Error()
+		}
+		j.mu.Unlock()
+
+		s.recordRuns(ctx, j.name, result, 1)
+		if s.opts.seconds != nil {
+			s.opts.seconds.Record(context.WithoutCancel(ctx), duration.Seconds(), metric.WithAttributes(
+				attribute.String(metricLabelJob, j.name),
+				attribute.String(metricLabelResult, result),
+			))
+		}
+		if err != nil {
+			log.GetDefaultSlog().Error("job failed", slog.String("job", j.name), slog.Time("scheduled", scheduled),
+				slog.Duration("duration", duration), slog.Any("error", err))
+		}
+	}()
+	return 0, j.fn(ctx)
+}
+
+// recordRuns 记录任务的执行次数
+func (s *Scheduler) recordRuns(ctx context.Context, name, result string, n int64) {
+	if s.opts.runs == nil {
+		return
+	}
+	s.opts.runs.Add(context.WithoutCancel(ctx), n, metric.WithAttributes(
+		attribute.String(metricLabelJob, name),
+		attribute.String(metricLabelResult, result),
+	))
+}
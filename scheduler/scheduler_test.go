@@ -0,0 +1,278 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/haysons/gokit/distributed/memory"
+	"github.com/haysons/gokit/internal/etcdtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// recorder 记录任务执行的计划时间
+type recorder struct {
+	mu   sync.Mutex
+	runs map[time.Time]int
+}
+
+func newRecorder() *recorder {
+	return &recorder{runs: make(map[time.Time]int)}
+}
+
+func (r *recorder) job(s **Scheduler, name string) Job {
+	return func(ctx context.Context) error {
+		state, err := (*s).State(ctx, name)
+		if err != nil {
+			return err
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.runs[state.LastRun]++
+		return nil
+	}
+}
+
+func (r *recorder) snapshot() map[time.Time]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	runs := make(map[time.Time]int, len(r.runs))
+	for k, v := range r.runs {
+		runs[k] = v
+	}
+	return runs
+}
+
+// start 在后台运行调度器，测试结束时停止
+func start(t *testing.T, s *Scheduler) {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- s.Start(context.Background()) }()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		assert.NoError(t, s.Stop(ctx))
+		assert.NoError(t, <-done)
+	})
+}
+
+func TestScheduler_Local(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("scheduler")
+	runs, err := DefaultRunsCounter(meter, DefaultRunsCounterName)
+	require.NoError(t, err)
+	seconds, err := DefaultSecondsHistogram(meter, DefaultSecondsHistogramName)
+	require.NoError(t, err)
+
+	s := New(WithRuns(runs), WithSeconds(seconds))
+	rec := newRecorder()
+	require.NoError(t, s.Add("tick", "* * * * * *", rec.job(&s, "tick")))
+	require.Error(t, s.Add("tick", "* * * * * *", rec.job(&s, "tick")))
+	require.Error(t, s.Add("bad", "* * *", rec.job(&s, "bad")))
+	start(t, s)
+
+	require.Eventually(t, func() bool { return len(rec.snapshot()) >= 2 }, 5*time.Second, 50*time.Millisecond)
+	for scheduled, n := range rec.snapshot() {
+		assert.Equal(t, 1, n, scheduled)
+		assert.Zero(t, scheduled.Nanosecond())
+	}
+	stats := s.Stats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, "tick", stats[0].Name)
+	assert.GreaterOrEqual(t, stats[0].Runs, int64(2))
+	assert.Zero(t, stats[0].Failures)
+	assert.True(t, stats[0].NextRun.After(stats[0].LastRun))
+
+	state, err := s.State(context.Background(), "tick")
+	require.NoError(t, err)
+	assert.Equal(t, state.NextRun, state.LastRun.Add(time.Second))
+	assert.NotEmpty(t, state.Runner)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	names := make(map[string]bool)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			names[m.Name] = true
+		}
+	}
+	assert.True(t, names[DefaultRunsCounterName])
+	assert.True(t, names[DefaultSecondsHistogramName])
+}
+
+func TestScheduler_LeaderOnly(t *testing.T) {
+	backend := memory.NewBackend()
+	store := NewMemoryStore()
+	rec := newRecorder()
+	ctx := context.Background()
+
+	electors := make([]*memory.Election, 2)
+	schedulers := make([]*Scheduler, 2)
+	for i := range schedulers {
+		electors[i] = backend.NewElection("scheduler")
+		require.NoError(t, electors[i].Start(ctx))
+		schedulers[i] = New(WithElector(electors[i]), WithStore(store))
+		require.NoError(t, schedulers[i].Add("tick", "* * * * * *", rec.job(&schedulers[i], "tick")))
+		start(t, schedulers[i])
+	}
+	leader := 0
+	if electors[1].IsLeader() {
+		leader = 1
+	}
+
+	require.Eventually(t, func() bool { return len(rec.snapshot()) >= 2 }, 5*time.Second, 50*time.Millisecond)
+	assert.Positive(t, schedulers[leader].Stats()[0].Runs)
+	assert.Zero(t, schedulers[1-leader].Stats()[0].Runs)
+
+	// leader 切换后由新的 leader 继续执行，同一计划执行时间不会重复执行
+	require.NoError(t, electors[leader].Close())
+	require.Eventually(t, func() bool { return schedulers[1-leader].Stats()[0].Runs >= 2 }, 5*time.Second, 50*time.Millisecond)
+	for scheduled, n := range rec.snapshot() {
+		assert.Equal(t, 1, n, scheduled)
+	}
+	require.NoError(t, electors[1-leader].Close())
+}
+
+func TestScheduler_Locker(t *testing.T) {
+	backend := memory.NewBackend()
+	store := NewMemoryStore()
+	rec := newRecorder()
+
+	schedulers := make([]*Scheduler, 3)
+	for i := range schedulers {
+		lock, err := backend.NewLock("tick")
+		require.NoError(t, err)
+		schedulers[i] = New(WithStore(store))
+		require.NoError(t, schedulers[i].Add("tick", "* * * * * *", rec.job(&schedulers[i], "tick"), WithLocker(lock)))
+		start(t, schedulers[i])
+	}
+
+	require.Eventually(t, func() bool { return len(rec.snapshot()) >= 3 }, 5*time.Second, 50*time.Millisecond)
+	var total int64
+	for _, s := range schedulers {
+		total += s.Stats()[0].Runs
+	}
+	runs := rec.snapshot()
+	for scheduled, n := range runs {
+		assert.Equal(t, 1, n, scheduled)
+	}
+	assert.InDelta(t, len(runs), total, 1)
+}
+
+func TestScheduler_Misfire(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		name     string
+		opts     []JobOption
+		runs     int64
+		misfires int64
+	}{
+		{"RunOnce", nil, 1, 4},
+		{"Skip", []JobOption{WithMisfirePolicy(MisfireSkip)}, 0, 5},
+		{"RunAll", []JobOption{WithMisfirePolicy(MisfireRunAll), WithMaxCatchUp(3)}, 3, 2},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// 上一次执行于5分30秒前，此后错过了5次执行，最近一次错过的执行在30秒前
+			store := NewMemoryStore()
+			lastRun := time.Now().Truncate(time.Second).Add(-5*time.Minute - 30*time.Second)
+			_, err := store.Save(ctx, "report", State{LastRun: lastRun}, 0)
+			require.NoError(t, err)
+
+			s := New(WithStore(store))
+			var (
+				mu        sync.Mutex
+				scheduled []time.Time
+			)
+			require.NoError(t, s.Add("report", "@every 1m", func(ctx context.Context) error {
+				state, err := s.State(ctx, "report")
+				if err != nil {
+					return err
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				scheduled = append(scheduled, state.LastRun)
+				return nil
+			}, c.opts...))
+			start(t, s)
+
+			require.Eventually(t, func() bool {
+				st := s.Stats()[0]
+				return st.Misfires == c.misfires && st.Runs == c.runs && !st.Running
+			}, 5*time.Second, 50*time.Millisecond)
+			state, err := s.State(ctx, "report")
+			require.NoError(t, err)
+			// 无论是否补执行，最近一次执行时间均推进至最近一次错过的执行
+			assert.Equal(t, lastRun.Add(5*time.Minute), state.LastRun)
+
+			mu.Lock()
+			defer mu.Unlock()
+			require.Len(t, scheduled, int(c.runs))
+			for i, at := range scheduled {
+				assert.Equal(t, lastRun.Add(time.Duration(5-len(scheduled)+i+1)*time.Minute), at)
+			}
+		})
+	}
+}
+
+func TestScheduler_Failure(t *testing.T) {
+	s := New()
+	require.NoError(t, s.Add("fail", "* * * * * *", func(context.Context) error {
+		return errors.New("boom")
+	}))
+	require.NoError(t, s.Add("panic", "* * * * * *", func(context.Context) error {
+		panic("oops")
+	}))
+	start(t, s)
+
+	require.Eventually(t, func() bool {
+		stats := s.Stats()
+		return stats[0].Failures > 0 && stats[1].Failures > 0
+	}, 5*time.Second, 50*time.Millisecond)
+	stats := s.Stats()
+	assert.Equal(t, "boom", stats[0].LastError)
+	assert.Contains(t, stats[1].LastError, "oops")
+	state, err := s.State(context.Background(), "fail")
+	require.NoError(t, err)
+	assert.Equal(t, "boom", state.LastError)
+}
+
+func TestScheduler_Timeout(t *testing.T) {
+	s := New()
+	require.NoError(t, s.Add("slow", "* * * * * *", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithTimeout(100*time.Millisecond)))
+	start(t, s)
+
+	require.Eventually(t, func() bool { return s.Stats()[0].Failures > 0 }, 5*time.Second, 50*time.Millisecond)
+	assert.Equal(t, context.DeadlineExceeded.Error(), s.Stats()[0].LastError)
+}
+
+func TestEtcdStore(t *testing.T) {
+	client := etcdtest.Start(t)
+	store := NewEtcdStore(client, "/scheduler")
+	ctx := context.Background()
+
+	state, version, err := store.Load(ctx, "job")
+	require.NoError(t, err)
+	assert.Zero(t, version)
+	assert.True(t, state.LastRun.IsZero())
+
+	lastRun := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	version, err = store.Save(ctx, "job", State{LastRun: lastRun, Runner: "a"}, 0)
+	require.NoError(t, err)
+	_, err = store.Save(ctx, "job", State{LastRun: lastRun, Runner: "b"}, 0)
+	assert.ErrorIs(t, err, ErrStateConflict)
+
+	state, loaded, err := store.Load(ctx, "job")
+	require.NoError(t, err)
+	assert.Equal(t, version, loaded)
+	assert.True(t, lastRun.Equal(state.LastRun))
+	assert.Equal(t, "a", state.Runner)
+}
@@ -0,0 +1,121 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/client/v3"
+)
+
+// ErrStateConflict 任务状态已被其他实例修改，通常意味着本次执行已被其他实例认领
+var ErrStateConflict = errors.New("job state modified concurrently")
+
+// State 任务的执行状态，持久化至 Store，新的 leader 据此补执行错过的执行且不会重复执行
+type State struct {
+	LastRun      time.Time     `json:"last_run"`             // 最近一次执行对应的计划执行时间
+	NextRun      time.Time     `json:"next_run"`             // 下一次计划执行时间
+	LastStart    time.Time     `json:"last_start"`           // 最近一次执行的实际开始时间
+	LastDuration time.Duration `json:"last_duration"`        // 最近一次执行的耗时
+	LastError    string        `json:"last_error,omitempty"` // 最近一次执行的错误，成功时为空
+	Runner       string        `json:"runner,omitempty"`     // 最近一次执行任务的实例 id
+}
+
+// Store 任务状态的存储，Save 以版本号实现乐观锁，保证同一计划执行时间仅被一个实例认领
+type Store interface {
+	// Load 读取任务状态及其版本号，不存在时返回零值及版本号0
+	Load(ctx context.Context, job string) (State, int64, error)
+	// Save 版本号仍为 version 时写入任务状态，返回新的版本号，版本号已变化时返回 ErrStateConflict
+	Save(ctx context.Context, job string, state State, version int64) (int64, error)
+}
+
+// EtcdStore 基于 etcd 的任务状态存储，任务状态以 json 编码存储于 {prefix}/{job}，版本号为 key 的修改版本号
+type EtcdStore struct {
+	client *clientv3.Client
+	prefix string
+}
+
+// NewEtcdStore 创建基于 etcd 的任务状态存储
+func NewEtcdStore(client *clientv3.Client, prefix string) *EtcdStore {
+	return &EtcdStore{client: client, prefix: prefix}
+}
+
+func (s *EtcdStore) key(job string) string {
+	return s.prefix + "/" + job
+}
+
+// Load 读取任务状态及其版本号，不存在时返回零值及版本号0
+func (s *EtcdStore) Load(ctx context.Context, job string) (State, int64, error) {
+	resp, err := s.client.Get(ctx, s.key(job))
+	if err != nil {
+		return State{}, 0, fmt.Errorf("get job state failed: %w", err)
+	}
+	if len(resp.Kvs) == 0 {
+		return State{}, 0, nil
+	}
+	var state State
+	if err = json.Unmarshal(resp.Kvs[0].Value, &state); err != nil {
+		return State{}, 0, fmt.Errorf("unmarshal job state failed: %w", err)
+	}
+	return state, resp.Kvs[0].ModRevision, nil
+}
+
+// Save 版本号仍为 version 时写入任务状态，返回新的版本号，版本号已变化时返回 ErrStateConflict
+func (s *EtcdStore) Save(ctx context.Context, job string, state State, version int64) (int64, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return 0, fmt.Errorf("marshal job state failed: %w", err)
+	}
+	key := s.key(job)
+	resp, err := s.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(key), "=", version)).
+		Then(clientv3.OpPut(key, string(data))).
+		Commit()
+	if err != nil {
+		return 0, fmt.Errorf("save job state failed: %w", err)
+	}
+	if !resp.Succeeded {
+		return 0, ErrStateConflict
+	}
+	return resp.Header.Revision, nil
+}
+
+// MemoryStore 进程内的任务状态存储，状态不会持久化，适用于单实例部署及测试
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string]memoryState
+}
+
+type memoryState struct {
+	state   State
+	version int64
+}
+
+// NewMemoryStore 创建进程内的任务状态存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string]memoryState)}
+}
+
+// Load 读取任务状态及其版本号，不存在时返回零值及版本号0
+func (s *MemoryStore) Load(_ context.Context, job string) (State, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.states[job]
+	return st.state, st.version, nil
+}
+
+// Save 版本号仍为 version 时写入任务状态，返回新的版本号，版本号已变化时返回 ErrStateConflict
+func (s *MemoryStore) Save(_ context.Context, job string, state State, version int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.states[job]
+	if st.version != version {
+		return 0, ErrStateConflict
+	}
+	st = memoryState{state: state, version: version + 1}
+	s.states[job] = st
+	return st.version, nil
+}
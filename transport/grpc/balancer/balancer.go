@@ -0,0 +1,54 @@
+package balancer
+
+import (
+	"strconv"
+
+	"google.golang.org/grpc/attributes"
+	"google.golang.org/grpc/balancer/roundrobin"
+	"google.golang.org/grpc/resolver"
+)
+
+const (
+	// RoundRobin 轮询，grpc 内置的负载均衡策略
+	RoundRobin = roundrobin.Name
+	// Weighted 平滑加权轮询，权重取自服务实例元数据中的 weight
+	Weighted = "gokit_weighted_round_robin"
+	// P2C 基于 EWMA 延迟的 P2C（Power of Two Choices）负载均衡，随机选取两个节点，选择负载较低者
+	P2C = "gokit_p2c_ewma"
+)
+
+const (
+	// WeightKey 服务实例元数据中表示权重的 key
+	WeightKey = "weight"
+	// DefaultWeight 未配置权重或权重非法时使用的默认权重
+	DefaultWeight = 10
+)
+
+type weightKey struct{}
+
+// SetWeight 为地址设置权重，权重存放于 BalancerAttributes 中，不影响地址的去重
+func SetWeight(addr resolver.Address, weight int) resolver.Address {
+	if addr.BalancerAttributes == nil {
+		addr.BalancerAttributes = attributes.New(weightKey{}, weight)
+	} else {
+		addr.BalancerAttributes = addr.BalancerAttributes.WithValue(weightKey{}, weight)
+	}
+	return addr
+}
+
+// GetWeight 获取地址的权重，未设置时返回 DefaultWeight
+func GetWeight(addr resolver.Address) int {
+	if w, ok := addr.BalancerAttributes.Value(weightKey{}).(int); ok && w > 0 {
+		return w
+	}
+	return DefaultWeight
+}
+
+// ParseWeight 解析服务实例元数据中的权重，未配置或非法时返回 DefaultWeight
+func ParseWeight(metadata map[string]string) int {
+	w, err := strconv.Atoi(metadata[WeightKey])
+	if err != nil || w <= 0 {
+		return DefaultWeight
+	}
+	return w
+}
@@ -0,0 +1,133 @@
+package balancer
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+type fakeSubConn struct {
+	balancer.SubConn
+	name string
+}
+
+func buildInfo(weights map[*fakeSubConn]int) base.PickerBuildInfo {
+	info := base.PickerBuildInfo{ReadySCs: make(map[balancer.SubConn]base.SubConnInfo)}
+	for sc, w := range weights {
+		addr := resolver.Address{Addr: sc.name}
+		if w > 0 {
+			addr = SetWeight(addr, w)
+		}
+		info.ReadySCs[sc] = base.SubConnInfo{Address: addr}
+	}
+	return info
+}
+
+func pick(t *testing.T, p balancer.Picker) *fakeSubConn {
+	res, err := p.Pick(balancer.PickInfo{})
+	require.NoError(t, err)
+	if res.Done != nil {
+		res.Done(balancer.DoneInfo{})
+	}
+	return res.SubConn.(*fakeSubConn)
+}
+
+// record 模拟一次经过 latency 完成的请求，距上次统计已过去 decayTime，使样本占较大权重
+func record(n *p2cNode, latency time.Duration) {
+	n.inflight.Add(1)
+	n.stamp.Store(time.Now().Add(-decayTime).UnixNano())
+	n.done(time.Now().Add(-latency), nil)
+}
+
+func TestParseWeight(t *testing.T) {
+	assert.Equal(t, 5, ParseWeight(map[string]string{WeightKey: "5"}))
+	assert.Equal(t, DefaultWeight, ParseWeight(map[string]string{WeightKey: "-1"}))
+	assert.Equal(t, DefaultWeight, ParseWeight(map[string]string{WeightKey: "abc"}))
+	assert.Equal(t, DefaultWeight, ParseWeight(nil))
+
+	addr := SetWeight(resolver.Address{Addr: "a"}, 3)
+	assert.Equal(t, 3, GetWeight(addr))
+	assert.Equal(t, DefaultWeight, GetWeight(resolver.Address{Addr: "b"}))
+}
+
+func TestWeighted(t *testing.T) {
+	a, b, c := &fakeSubConn{name: "a"}, &fakeSubConn{name: "b"}, &fakeSubConn{name: "c"}
+	p := (&weightedPickerBuilder{}).Build(buildInfo(map[*fakeSubConn]int{a: 1, b: 2, c: 3}))
+
+	// 每轮（权重之和）内各节点被选中的次数与权重一致
+	counts := make(map[string]int)
+	for range 600 {
+		counts[pick(t, p).name]++
+	}
+	assert.Equal(t, map[string]int{"a": 100, "b": 200, "c": 300}, counts)
+
+	// 平滑加权：高权重节点的请求不会集中连续出现
+	p = (&weightedPickerBuilder{}).Build(buildInfo(map[*fakeSubConn]int{a: 5, b: 1, c: 1}))
+	var seq string
+	for range 7 {
+		seq += pick(t, p).name
+	}
+	assert.Equal(t, 5, strings.Count(seq, "a"))
+	assert.NotContains(t, seq, "aaa")
+
+	_, err := (&weightedPickerBuilder{}).Build(base.PickerBuildInfo{}).Pick(balancer.PickInfo{})
+	assert.ErrorIs(t, err, balancer.ErrNoSubConnAvailable)
+}
+
+func TestP2C(t *testing.T) {
+	fast, slow := &fakeSubConn{name: "fast"}, &fakeSubConn{name: "slow"}
+	b := &p2cPickerBuilder{nodes: make(map[balancer.SubConn]*p2cNode)}
+	p := b.Build(buildInfo(map[*fakeSubConn]int{fast: 0, slow: 0}))
+
+	// 慢节点的延迟样本远高于快节点
+	for range 10 {
+		record(b.nodes[slow], 50*time.Millisecond)
+		record(b.nodes[fast], time.Millisecond)
+	}
+
+	counts := make(map[string]int)
+	for range 100 {
+		counts[pick(t, p).name]++
+	}
+	assert.Equal(t, 100, counts["fast"])
+}
+
+func TestP2C_KeepStatsAcrossBuilds(t *testing.T) {
+	a, b, c := &fakeSubConn{name: "a"}, &fakeSubConn{name: "b"}, &fakeSubConn{name: "c"}
+	pb := &p2cPickerBuilder{nodes: make(map[balancer.SubConn]*p2cNode)}
+	pb.Build(buildInfo(map[*fakeSubConn]int{a: 0, b: 0}))
+	record(pb.nodes[a], 50*time.Millisecond)
+	lag := math.Float64frombits(pb.nodes[a].lag.Load())
+	require.Greater(t, lag, initLatency)
+
+	// 新增节点后重新构建，已有节点的统计保留
+	nodeA := pb.nodes[a]
+	pb.Build(buildInfo(map[*fakeSubConn]int{a: 0, b: 0, c: 0}))
+	assert.Same(t, nodeA, pb.nodes[a])
+	assert.Equal(t, lag, math.Float64frombits(pb.nodes[a].lag.Load()))
+	assert.Len(t, pb.nodes, 3)
+
+	// 不再就绪的节点统计被移除
+	pb.Build(buildInfo(map[*fakeSubConn]int{b: 0}))
+	assert.Len(t, pb.nodes, 1)
+	assert.Contains(t, pb.nodes, balancer.SubConn(b))
+
+	_, err := pb.Build(base.PickerBuildInfo{}).Pick(balancer.PickInfo{})
+	assert.ErrorIs(t, err, balancer.ErrNoSubConnAvailable)
+	assert.Empty(t, pb.nodes)
+}
+
+func TestRegistered(t *testing.T) {
+	for _, name := range []string{Weighted, P2C} {
+		b := balancer.Get(name)
+		require.NotNil(t, b, name)
+		assert.Equal(t, name, b.Name())
+	}
+}
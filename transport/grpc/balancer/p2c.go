@@ -0,0 +1,154 @@
+package balancer
+
+import (
+	"math"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+)
+
+const (
+	// decayTime EWMA 的衰减时间常数，越大越平滑
+	decayTime = 10 * time.Second
+	// forcePick 节点超过此时间未被选中时将被强制选中一次，避免节点延迟样本过旧而长期得不到流量
+	forcePick = 3 * time.Second
+	// initLatency 节点的初始延迟，使新节点优先获得流量以尽快收集样本
+	initLatency = float64(time.Millisecond)
+)
+
+func init() {
+	balancer.Register(p2cBalancerBuilder{})
+}
+
+// p2cBalancerBuilder 为每个 ClientConn 创建独立的 p2cPickerBuilder，使节点统计仅在同一 ClientConn 内复用
+type p2cBalancerBuilder struct{}
+
+func (p2cBalancerBuilder) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	pb := &p2cPickerBuilder{nodes: make(map[balancer.SubConn]*p2cNode)}
+	return base.NewBalancerBuilder(P2C, pb, base.Config{HealthCheck: true}).Build(cc, opts)
+}
+
+func (p2cBalancerBuilder) Name() string {
+	return P2C
+}
+
+// p2cPickerBuilder 保存各 SubConn 的统计，任一 SubConn 状态变化都会重新构建 picker，
+// 复用已有节点的统计可避免延迟样本被重置，不再就绪的 SubConn 统计将被移除
+type p2cPickerBuilder struct {
+	nodes map[balancer.SubConn]*p2cNode // 仅在 balancer 的串行回调中访问
+}
+
+func (b *p2cPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	for sc := range b.nodes {
+		if _, ok := info.ReadySCs[sc]; !ok {
+			delete(b.nodes, sc)
+		}
+	}
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	nodes := make([]*p2cNode, 0, len(info.ReadySCs))
+	now := time.Now().UnixNano()
+	for sc := range info.ReadySCs {
+		n, ok := b.nodes[sc]
+		if !ok {
+			n = &p2cNode{subConn: sc}
+			n.lag.Store(math.Float64bits(initLatency))
+			n.stamp.Store(now)
+			n.picked.Store(now)
+			b.nodes[sc] = n
+		}
+		nodes = append(nodes, n)
+	}
+	return &p2cPicker{nodes: nodes}
+}
+
+// p2cNode 节点的负载统计
+type p2cNode struct {
+	subConn  balancer.SubConn
+	lag      atomic.Uint64 // EWMA 延迟（纳秒），以 float64 bits 存储
+	success  atomic.Uint64 // EWMA 成功率，以 float64 bits 存储
+	inflight atomic.Int64  // 处理中的请求数
+	stamp    atomic.Int64  // 最近一次更新统计的时间
+	picked   atomic.Int64  // 最近一次被选中的时间
+	mu       sync.Mutex    // 保护统计的更新
+}
+
+// load 节点负载，延迟越高、处理中的请求越多、成功率越低，负载越高
+func (n *p2cNode) load() float64 {
+	lag := math.Float64frombits(n.lag.Load())
+	success := math.Float64frombits(n.success.Load())
+	if success <= 0 {
+		success = 1
+	}
+	return lag * float64(n.inflight.Load()+1) / success
+}
+
+// done 请求完成后更新节点统计
+func (n *p2cNode) done(start time.Time, err error) {
+	n.inflight.Add(-1)
+	now := time.Now()
+	latency := float64(now.Sub(start))
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	td := now.UnixNano() - n.stamp.Swap(now.UnixNano())
+	if td < 0 {
+		td = 0
+	}
+	w := math.Exp(-float64(td) / float64(decayTime))
+	lag := math.Float64frombits(n.lag.Load())
+	n.lag.Store(math.Float64bits(lag*w + latency*(1-w)))
+
+	result := 1.0
+	if err != nil {
+		result = 0
+	}
+	success := math.Float64frombits(n.success.Load())
+	if success == 0 {
+		success = 1
+	}
+	n.success.Store(math.Float64bits(success*w + result*(1-w)))
+}
+
+type p2cPicker struct {
+	nodes []*p2cNode
+}
+
+func (p *p2cPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	var picked *p2cNode
+	switch len(p.nodes) {
+	case 1:
+		picked = p.nodes[0]
+	default:
+		i := rand.IntN(len(p.nodes))
+		j := rand.IntN(len(p.nodes) - 1)
+		if j >= i {
+			j++
+		}
+		a, b := p.nodes[i], p.nodes[j]
+		if a.load() > b.load() {
+			a, b = b, a
+		}
+		picked = a
+		// 负载较高的节点长时间未被选中时强制选中，以更新其延迟统计
+		now := time.Now().UnixNano()
+		if now-b.picked.Load() > int64(forcePick) {
+			picked = b
+		}
+	}
+
+	start := time.Now()
+	picked.picked.Store(start.UnixNano())
+	picked.inflight.Add(1)
+	return balancer.PickResult{
+		SubConn: picked.subConn,
+		Done: func(info balancer.DoneInfo) {
+			picked.done(start, info.Err)
+		},
+	}, nil
+}
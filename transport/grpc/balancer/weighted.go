@@ -0,0 +1,55 @@
+package balancer
+
+import (
+	"sync"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+)
+
+func init() {
+	balancer.Register(base.NewBalancerBuilder(Weighted, &weightedPickerBuilder{}, base.Config{HealthCheck: true}))
+}
+
+type weightedPickerBuilder struct{}
+
+func (*weightedPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	nodes := make([]*weightedNode, 0, len(info.ReadySCs))
+	for sc, sci := range info.ReadySCs {
+		nodes = append(nodes, &weightedNode{subConn: sc, weight: GetWeight(sci.Address)})
+	}
+	return &weightedPicker{nodes: nodes}
+}
+
+type weightedNode struct {
+	subConn       balancer.SubConn
+	weight        int
+	currentWeight int
+}
+
+// weightedPicker 平滑加权轮询（与 nginx 一致），在保证权重比例的同时避免请求集中打到高权重节点
+type weightedPicker struct {
+	mu    sync.Mutex
+	nodes []*weightedNode
+}
+
+func (p *weightedPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var (
+		total int
+		best  *weightedNode
+	)
+	for _, n := range p.nodes {
+		n.currentWeight += n.weight
+		total += n.weight
+		if best == nil || n.currentWeight > best.currentWeight {
+			best = n
+		}
+	}
+	best.currentWeight -= total
+	return balancer.PickResult{SubConn: best.subConn}, nil
+}
@@ -0,0 +1,223 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/haysons/gokit/middleware"
+	"github.com/haysons/gokit/registry"
+	"github.com/haysons/gokit/transport"
+	"github.com/haysons/gokit/transport/grpc/balancer"
+	"github.com/haysons/gokit/transport/grpc/resolver/discovery"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	grpcmd "google.golang.org/grpc/metadata"
+)
+
+// ClientConfig grpc 客户端配置项
+type ClientConfig struct {
+	Endpoint string        `mapstructure:"endpoint"` // 服务地址，直连时为 host:port，基于注册中心时为 discovery:///service-name
+	Timeout  time.Duration `mapstructure:"timeout"`  // 单次请求的超时时间，默认不超时
+	Balancer string        `mapstructure:"balancer"` // 负载均衡策略，如 round_robin、gokit_weighted_round_robin、gokit_p2c_ewma，默认为 round_robin
+
+	discovery    registry.Discovery             // 服务发现
+	filters      []registry.Filter              // 服务实例过滤器
+	subsetKey    string                         // 子集哈希 key
+	subsetSize   int                            // 子集大小
+	tlsConf      *tls.Config                    // tls 配置
+	middleware   []middleware.Middleware        // 通用中间件
+	unaryInts    []grpc.UnaryClientInterceptor  // grpc 请求响应式拦截器
+	streamInts   []grpc.StreamClientInterceptor // grpc 流式拦截器
+	grpcDialOpts []grpc.DialOption              // grpc 原生配置
+}
+
+// ClientOption 函数式配置项
+type ClientOption func(*ClientConfig)
+
+// WithClientConfig 整体替换配置
+func WithClientConfig(cfg ClientConfig) ClientOption {
+	return func(c *ClientConfig) {
+		*c = cfg
+	}
+}
+
+// WithEndpoint 配置服务地址，直连时为 host:port，基于注册中心时为 discovery:///service-name
+func WithEndpoint(endpoint string) ClientOption {
+	return func(c *ClientConfig) {
+		c.Endpoint = endpoint
+	}
+}
+
+// WithTimeout 配置单次请求的超时时间
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *ClientConfig) {
+		c.Timeout = timeout
+	}
+}
+
+// WithBalancer 配置负载均衡策略，见 balancer 包
+func WithBalancer(name string) ClientOption {
+	return func(c *ClientConfig) {
+		c.Balancer = name
+	}
+}
+
+// WithDiscovery 配置服务发现，Endpoint 为 discovery:///service-name 时将通过注册中心获取服务实例
+func WithDiscovery(d registry.Discovery) ClientOption {
+	return func(c *ClientConfig) {
+		c.discovery = d
+	}
+}
+
+// WithNodeFilter 配置服务实例过滤器，如 registry.Version、registry.Metadata
+func WithNodeFilter(filters ...registry.Filter) ClientOption {
+	return func(c *ClientConfig) {
+		c.filters = append(c.filters, filters...)
+	}
+}
+
+// WithSubset 配置仅连接 size 个服务实例，子集由 key 通过一致性哈希确定
+func WithSubset(key string, size int) ClientOption {
+	return func(c *ClientConfig) {
+		c.subsetKey = key
+		c.subsetSize = size
+	}
+}
+
+// WithClientTLSConfig 配置 tls 加密相关，默认不加密
+func WithClientTLSConfig(cfg *tls.Config) ClientOption {
+	return func(c *ClientConfig) {
+		c.tlsConf = cfg
+	}
+}
+
+// WithClientMiddleware 配置请求响应式接口的通用中间件
+func WithClientMiddleware(m ...middleware.Middleware) ClientOption {
+	return func(c *ClientConfig) {
+		c.middleware = append(c.middleware, m...)
+	}
+}
+
+// WithClientUnaryInterceptor 配置 grpc 请求响应式拦截器
+func WithClientUnaryInterceptor(in ...grpc.UnaryClientInterceptor) ClientOption {
+	return func(c *ClientConfig) {
+		c.unaryInts = in
+	}
+}
+
+// WithClientStreamInterceptor 配置 grpc 流式拦截器
+func WithClientStreamInterceptor(in ...grpc.StreamClientInterceptor) ClientOption {
+	return func(c *ClientConfig) {
+		c.streamInts = in
+	}
+}
+
+// WithDialOptions 增加原生 grpc 配置
+func WithDialOptions(opts ...grpc.DialOption) ClientOption {
+	return func(c *ClientConfig) {
+		c.grpcDialOpts = opts
+	}
+}
+
+// NewClient 创建 grpc 客户端连接，连接为惰性建立，不会阻塞
+func NewClient(opts ...ClientOption) (*grpc.ClientConn, error) {
+	cfg := new(ClientConfig)
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.Endpoint == "" {
+		return nil, errors.New("grpc client: endpoint is required")
+	}
+	if cfg.Balancer == "" {
+		cfg.Balancer = balancer.RoundRobin
+	}
+
+	unaryInts := []grpc.UnaryClientInterceptor{
+		unaryClientInterceptor(cfg.middleware, cfg.Timeout),
+	}
+	if len(cfg.unaryInts) > 0 {
+		unaryInts = append(unaryInts, cfg.unaryInts...)
+	}
+	dialOpts := []grpc.DialOption{
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig": [{%q:{}}]}`, cfg.Balancer)),
+		grpc.WithChainUnaryInterceptor(unaryInts...),
+	}
+	if len(cfg.streamInts) > 0 {
+		dialOpts = append(dialOpts, grpc.WithChainStreamInterceptor(cfg.streamInts...))
+	}
+
+	// tls 配置
+	if cfg.tlsConf != nil {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(cfg.tlsConf)))
+	} else {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	// 服务发现
+	if strings.HasPrefix(cfg.Endpoint, discovery.Scheme+"://") {
+		if cfg.discovery == nil {
+			return nil, errors.New("grpc client: discovery is required for discovery endpoint")
+		}
+		dialOpts = append(dialOpts, grpc.WithResolvers(discovery.NewBuilder(cfg.discovery,
+			discovery.WithFilter(cfg.filters...),
+			discovery.WithSubset(cfg.subsetKey, cfg.subsetSize),
+		)))
+	}
+
+	// grpc 原生配置
+	if len(cfg.grpcDialOpts) > 0 {
+		dialOpts = append(dialOpts, cfg.grpcDialOpts...)
+	}
+	return grpc.NewClient(cfg.Endpoint, dialOpts...)
+}
+
+// unaryClientInterceptor 通用中间件转化为 grpc 客户端拦截器
+func unaryClientInterceptor(ms []middleware.Middleware, timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		// 构建传输层信息，并注入 context
+		tr := &Transport{
+			endpoint:    cc.Target(),
+			operation:   method,
+			reqHeader:   headerCarrier{},
+			replyHeader: headerCarrier{},
+		}
+		ctx = transport.InjectClientContext(ctx, tr)
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		h := func(ctx context.Context, req any) (any, error) {
+			// 中间件写入的请求 header 作为 metadata 发送
+			for k, v := range tr.reqHeader {
+				ctx = grpcmd.AppendToOutgoingContext(ctx, flatten(k, v)...)
+			}
+			var header grpcmd.MD
+			err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header))...)
+			for k, v := range header {
+				tr.replyHeader[k] = v
+			}
+			return reply, err
+		}
+		if len(ms) > 0 {
+			h = middleware.Combine(ms...)(h)
+		}
+		_, err := h(ctx, req)
+		return err
+	}
+}
+
+// flatten 将 header 的键值转换为 AppendToOutgoingContext 所需的键值对
+func flatten(key string, values []string) []string {
+	kv := make([]string, 0, len(values)*2)
+	for _, v := range values {
+		kv = append(kv, key, v)
+	}
+	return kv
+}
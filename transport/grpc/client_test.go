@@ -0,0 +1,127 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/haysons/gokit/middleware"
+	"github.com/haysons/gokit/registry"
+	"github.com/haysons/gokit/transport"
+	"github.com/haysons/gokit/transport/grpc/balancer"
+	"github.com/haysons/gokit/transport/testdata/helloworld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	grpcmd "google.golang.org/grpc/metadata"
+)
+
+// namedGreeter 返回服务名称及收到的 x-md-test header，便于判断请求落到了哪个实例
+type namedGreeter struct {
+	helloworld.UnimplementedGreeterServer
+	name string
+}
+
+func (g namedGreeter) SayHello(ctx context.Context, _ *helloworld.HelloRequest) (*helloworld.HelloReply, error) {
+	md, _ := grpcmd.FromIncomingContext(ctx)
+	return &helloworld.HelloReply{Message: g.name + ":" + md.Get("x-md-test")[0]}, nil
+}
+
+// staticDiscovery 返回固定实例列表的服务发现
+type staticDiscovery struct {
+	instances []*registry.ServiceInstance
+}
+
+func (d *staticDiscovery) GetService(context.Context, string) ([]*registry.ServiceInstance, error) {
+	return d.instances, nil
+}
+
+func (d *staticDiscovery) Watch(ctx context.Context, _ string) (registry.Watcher, error) {
+	return &staticWatcher{ctx: ctx, instances: d.instances, first: true}, nil
+}
+
+type staticWatcher struct {
+	ctx       context.Context
+	instances []*registry.ServiceInstance
+	first     bool
+}
+
+func (w *staticWatcher) Next() ([]*registry.ServiceInstance, error) {
+	if w.first {
+		w.first = false
+		return w.instances, nil
+	}
+	<-w.ctx.Done()
+	return nil, w.ctx.Err()
+}
+
+func (w *staticWatcher) Stop() error { return nil }
+
+func startGreeter(t *testing.T, name, version, weight string) *registry.ServiceInstance {
+	t.Helper()
+	server := NewServer(WithAddr("127.0.0.1:0"))
+	helloworld.RegisterGreeterServer(server.GetServiceRegistrar(), namedGreeter{name: name})
+	go func() {
+		_ = server.Start(context.Background())
+	}()
+	<-server.Ready()
+	t.Cleanup(func() { _ = server.Stop(context.Background()) })
+	u, err := server.Endpoint()
+	require.NoError(t, err)
+	return &registry.ServiceInstance{
+		ID:        name,
+		Name:      "greeter",
+		Version:   version,
+		Metadata:  map[string]string{balancer.WeightKey: weight},
+		Endpoints: []string{"grpc://" + u.Host},
+	}
+}
+
+func TestClientDiscovery(t *testing.T) {
+	d := &staticDiscovery{instances: []*registry.ServiceInstance{
+		startGreeter(t, "a", "v1", "3"),
+		startGreeter(t, "b", "v1", "1"),
+		startGreeter(t, "c", "v2", "1"),
+	}}
+	headerMiddleware := func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			if tr, ok := transport.FromClientContext(ctx); ok {
+				tr.RequestHeader().Set("x-md-test", "ok")
+			}
+			return next(ctx, req)
+		}
+	}
+
+	for _, b := range []string{balancer.RoundRobin, balancer.Weighted, balancer.P2C} {
+		t.Run(b, func(t *testing.T) {
+			conn, err := NewClient(
+				WithEndpoint("discovery:///greeter"),
+				WithDiscovery(d),
+				WithNodeFilter(registry.Version("v1")),
+				WithBalancer(b),
+				WithClientMiddleware(headerMiddleware),
+			)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			client := helloworld.NewGreeterClient(conn)
+			hits := make(map[string]int)
+			for range 40 {
+				resp, err := client.SayHello(context.Background(), &helloworld.HelloRequest{})
+				require.NoError(t, err)
+				hits[resp.Message]++
+			}
+			assert.Zero(t, hits["c:ok"], "filtered instance must not receive requests")
+			assert.Equal(t, 40, hits["a:ok"]+hits["b:ok"])
+			if b == balancer.Weighted {
+				// 连接建立期间可能仅有部分实例就绪，故不严格校验比例
+				assert.Greater(t, hits["a:ok"], hits["b:ok"])
+			}
+		})
+	}
+}
+
+func TestClientRequiresDiscovery(t *testing.T) {
+	_, err := NewClient(WithEndpoint("discovery:///greeter"))
+	assert.Error(t, err)
+	_, err = NewClient()
+	assert.Error(t, err)
+}
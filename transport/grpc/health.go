@@ -0,0 +1,43 @@
+package grpc
+
+import (
+	"context"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// healthProxy 注册至 grpc 服务器的健康检查服务，将请求转发至当前的健康检查服务，
+// grpc 服务器不允许重复注册服务，借此支持在服务器创建后替换健康检查服务
+type healthProxy struct {
+	healthpb.UnimplementedHealthServer
+
+	target atomic.Pointer[healthpb.HealthServer]
+}
+
+func newHealthProxy(srv healthpb.HealthServer) *healthProxy {
+	p := &healthProxy{}
+	p.set(srv)
+	return p
+}
+
+func (p *healthProxy) set(srv healthpb.HealthServer) {
+	p.target.Store(&srv)
+}
+
+func (p *healthProxy) get() healthpb.HealthServer {
+	return *p.target.Load()
+}
+
+func (p *healthProxy) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	return p.get().Check(ctx, req)
+}
+
+func (p *healthProxy) List(ctx context.Context, req *healthpb.HealthListRequest) (*healthpb.HealthListResponse, error) {
+	return p.get().List(ctx, req)
+}
+
+func (p *healthProxy) Watch(req *healthpb.HealthCheckRequest, stream grpc.ServerStreamingServer[healthpb.HealthCheckResponse]) error {
+	return p.get().Watch(req, stream)
+}
@@ -0,0 +1,75 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/haysons/gokit/registry"
+	"google.golang.org/grpc/resolver"
+)
+
+// Scheme 基于注册中心的服务发现 scheme，target 格式为 discovery:///service-name
+const Scheme = "discovery"
+
+// defaultRetryInterval 监听服务实例失败后的重试间隔
+const defaultRetryInterval = time.Second
+
+// Option 函数式配置项
+type Option func(*builder)
+
+// WithFilter 配置服务实例过滤器，仅满足全部过滤器的实例参与负载均衡，如按版本、机房过滤
+func WithFilter(filters ...registry.Filter) Option {
+	return func(b *builder) {
+		b.filters = append(b.filters, filters...)
+	}
+}
+
+// WithSubset 配置子集大小，客户端仅连接其中 size 个实例，以减少大规模集群下的连接数，
+// 子集由 key（一般为客户端实例 id）通过一致性哈希确定，实例变化时子集尽可能保持稳定
+func WithSubset(key string, size int) Option {
+	return func(b *builder) {
+		b.subsetKey = key
+		b.subsetSize = size
+	}
+}
+
+type builder struct {
+	discovery  registry.Discovery
+	filters    []registry.Filter
+	subsetKey  string
+	subsetSize int
+}
+
+// NewBuilder 创建基于注册中心的 grpc resolver.Builder，可通过 grpc.WithResolvers 配置至客户端
+func NewBuilder(d registry.Discovery, opts ...Option) resolver.Builder {
+	b := &builder{discovery: d}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Build 创建 resolver，并在后台持续监听服务实例的变化
+func (b *builder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	name := target.Endpoint()
+	if name == "" {
+		return nil, errors.New("discovery: service name is required")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &discoveryResolver{
+		builder: b,
+		name:    name,
+		cc:      cc,
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	go r.watch()
+	return r, nil
+}
+
+// Scheme 返回 discovery
+func (*builder) Scheme() string {
+	return Scheme
+}
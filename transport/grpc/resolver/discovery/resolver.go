@@ -0,0 +1,125 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/haysons/gokit/log"
+	"github.com/haysons/gokit/registry"
+	"github.com/haysons/gokit/transport/grpc/balancer"
+	"google.golang.org/grpc/attributes"
+	"google.golang.org/grpc/resolver"
+)
+
+// instanceKey 地址属性中存放服务实例 id 的 key
+type instanceKey struct{}
+
+// discoveryResolver 监听注册中心中服务实例的变化并更新 grpc 的地址列表
+type discoveryResolver struct {
+	*builder
+	name   string
+	cc     resolver.ClientConn
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	updated bool // 是否已成功更新过地址列表，仅由监听的 goroutine 访问
+}
+
+// watch 持续监听服务实例，监听失败时按间隔重试
+func (r *discoveryResolver) watch() {
+	defer close(r.done)
+	for {
+		w, err := r.discovery.Watch(r.ctx, r.name)
+		if err == nil {
+			err = r.next(w)
+			_ = w.Stop()
+		}
+		if r.ctx.Err() != nil {
+			return
+		}
+		log.GetDefaultSlog().Warn("discovery watch failed", slog.String("service", r.name), slog.Any("error", err))
+		r.cc.ReportError(fmt.Errorf("discovery: watch service %s: %w", r.name, err))
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-time.After(defaultRetryInterval):
+		}
+	}
+}
+
+// next 阻塞并处理监听器返回的实例，直至监听器出错
+func (r *discoveryResolver) next(w registry.Watcher) error {
+	for {
+		instances, err := w.Next()
+		if err != nil {
+			return err
+		}
+		r.update(instances)
+	}
+}
+
+// update 过滤服务实例并更新地址列表，过滤后无可用实例时保留原有地址列表，避免注册中心短暂异常导致全部连接断开；
+// 尚未成功更新过地址列表时向 grpc 报告错误，使等待地址的请求尽快失败
+func (r *discoveryResolver) update(instances []*registry.ServiceInstance) {
+	instances = registry.ApplyFilters(instances, r.filters...)
+	instances = subset(instances, r.subsetKey, r.subsetSize)
+	addrs := make([]resolver.Address, 0, len(instances))
+	for _, si := range instances {
+		endpoint := si.Endpoint("grpc")
+		if endpoint == "" {
+			continue
+		}
+		addr := resolver.Address{
+			Addr:       endpoint,
+			Attributes: attributes.New(instanceKey{}, si.ID),
+		}
+		addrs = append(addrs, balancer.SetWeight(addr, balancer.ParseWeight(si.Metadata)))
+	}
+	if len(addrs) == 0 {
+		log.GetDefaultSlog().Warn("discovery found no available instance", slog.String("service", r.name))
+		if !r.updated {
+			r.cc.ReportError(fmt.Errorf("discovery: no available instance of service %s", r.name))
+		}
+		return
+	}
+	if err := r.cc.UpdateState(resolver.State{Addresses: addrs}); err != nil {
+		log.GetDefaultSlog().Warn("discovery update state failed", slog.String("service", r.name), slog.Any("error", err))
+		return
+	}
+	r.updated = true
+}
+
+// ResolveNow 服务实例变化由监听驱动，无需主动解析
+func (*discoveryResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+// Close 停止监听
+func (r *discoveryResolver) Close() {
+	r.cancel()
+	<-r.done
+}
+
+// subset 基于 rendezvous hash 选取稳定的实例子集，size 不大于0或不小于实例数时返回全部实例
+func subset(instances []*registry.ServiceInstance, key string, size int) []*registry.ServiceInstance {
+	if size <= 0 || len(instances) <= size {
+		return instances
+	}
+	type scored struct {
+		si    *registry.ServiceInstance
+		score uint64
+	}
+	items := make([]scored, len(instances))
+	for i, si := range instances {
+		items[i] = scored{si: si, score: xxhash.Sum64String(key + "/" + si.ID)}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].score > items[j].score })
+	out := make([]*registry.ServiceInstance, size)
+	for i := range out {
+		out[i] = items[i].si
+	}
+	return out
+}
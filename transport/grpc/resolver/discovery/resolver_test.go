@@ -0,0 +1,188 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/haysons/gokit/registry"
+	"github.com/haysons/gokit/transport/grpc/balancer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/resolver"
+)
+
+// fakeDiscovery 通过管道推送服务实例的注册中心
+type fakeDiscovery struct {
+	updates chan []*registry.ServiceInstance
+}
+
+func (d *fakeDiscovery) GetService(context.Context, string) ([]*registry.ServiceInstance, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (d *fakeDiscovery) Watch(ctx context.Context, _ string) (registry.Watcher, error) {
+	return &fakeWatcher{ctx: ctx, updates: d.updates}, nil
+}
+
+type fakeWatcher struct {
+	ctx     context.Context
+	updates chan []*registry.ServiceInstance
+}
+
+func (w *fakeWatcher) Next() ([]*registry.ServiceInstance, error) {
+	select {
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case instances := <-w.updates:
+		return instances, nil
+	}
+}
+
+func (w *fakeWatcher) Stop() error {
+	return nil
+}
+
+// fakeClientConn 记录 resolver 更新的地址列表及报告的错误
+type fakeClientConn struct {
+	resolver.ClientConn
+	mu     sync.Mutex
+	states []resolver.State
+	errs   []error
+}
+
+func (cc *fakeClientConn) UpdateState(state resolver.State) error {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.states = append(cc.states, state)
+	return nil
+}
+
+func (cc *fakeClientConn) ReportError(err error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.errs = append(cc.errs, err)
+}
+
+func (cc *fakeClientConn) errCount() int {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return len(cc.errs)
+}
+
+func (cc *fakeClientConn) count() int {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return len(cc.states)
+}
+
+func (cc *fakeClientConn) last() resolver.State {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	return cc.states[len(cc.states)-1]
+}
+
+func instance(id, version, zone, weight string) *registry.ServiceInstance {
+	return &registry.ServiceInstance{
+		ID:        id,
+		Name:      "helloworld",
+		Version:   version,
+		Metadata:  map[string]string{"zone": zone, balancer.WeightKey: weight},
+		Endpoints: []string{"grpc://10.0.0." + id + ":9000"},
+	}
+}
+
+func startResolver(t *testing.T, opts ...Option) (*fakeDiscovery, *fakeClientConn) {
+	d := &fakeDiscovery{updates: make(chan []*registry.ServiceInstance)}
+	cc := &fakeClientConn{}
+	target := resolver.Target{URL: url.URL{Scheme: Scheme, Path: "/helloworld"}}
+	r, err := NewBuilder(d, opts...).Build(target, cc, resolver.BuildOptions{})
+	require.NoError(t, err)
+	t.Cleanup(r.Close)
+	return d, cc
+}
+
+// push 推送实例并等待地址列表更新，wantUpdate 为 false 时确认地址列表未更新
+func push(t *testing.T, d *fakeDiscovery, cc *fakeClientConn, wantUpdate bool, instances ...*registry.ServiceInstance) {
+	n := cc.count()
+	d.updates <- instances
+	if wantUpdate {
+		require.Eventually(t, func() bool { return cc.count() == n+1 }, time.Second, 5*time.Millisecond)
+		return
+	}
+	// 再推送一次空列表，确保上一次推送已处理完成
+	d.updates <- nil
+	assert.Equal(t, n, cc.count())
+}
+
+func addrs(state resolver.State) map[string]int {
+	out := make(map[string]int, len(state.Addresses))
+	for _, addr := range state.Addresses {
+		out[addr.Addr] = balancer.GetWeight(addr)
+	}
+	return out
+}
+
+func TestResolver(t *testing.T) {
+	d, cc := startResolver(t)
+	push(t, d, cc, true, instance("1", "v1", "a", "5"), instance("2", "v2", "b", ""))
+	// 权重取自实例元数据，未配置时使用默认权重
+	assert.Equal(t, map[string]int{"10.0.0.1:9000": 5, "10.0.0.2:9000": balancer.DefaultWeight}, addrs(cc.last()))
+
+	// 无可用实例时保留原有地址列表
+	push(t, d, cc, false)
+	noGRPC := instance("3", "v1", "a", "")
+	noGRPC.Endpoints = []string{"http://10.0.0.3:8000"}
+	push(t, d, cc, false, noGRPC)
+}
+
+func TestResolver_NoInstance(t *testing.T) {
+	d, cc := startResolver(t)
+	// 尚未更新过地址列表时无可用实例，报告错误
+	d.updates <- nil
+	require.Eventually(t, func() bool { return cc.errCount() == 1 }, time.Second, 5*time.Millisecond)
+	assert.Zero(t, cc.count())
+
+	// 成功更新后无可用实例时仅保留原有地址列表，不再报告错误
+	push(t, d, cc, true, instance("1", "v1", "a", ""))
+	push(t, d, cc, false)
+	assert.Equal(t, 1, cc.errCount())
+}
+
+func TestResolver_Filter(t *testing.T) {
+	d, cc := startResolver(t, WithFilter(registry.Version("v1"), registry.Metadata("zone", "a")))
+	push(t, d, cc, true,
+		instance("1", "v1", "a", ""),
+		instance("2", "v2", "a", ""),
+		instance("3", "v1", "b", ""),
+		instance("4", "v1", "a", ""),
+	)
+	assert.Equal(t, map[string]int{"10.0.0.1:9000": balancer.DefaultWeight, "10.0.0.4:9000": balancer.DefaultWeight}, addrs(cc.last()))
+
+	// 过滤后无可用实例时保留原有地址列表
+	push(t, d, cc, false, instance("2", "v2", "a", ""))
+}
+
+func TestSubset(t *testing.T) {
+	var instances []*registry.ServiceInstance
+	for _, id := range []string{"1", "2", "3", "4", "5", "6"} {
+		instances = append(instances, instance(id, "v1", "a", ""))
+	}
+	assert.Len(t, subset(instances, "client", 0), 6)
+	assert.Len(t, subset(instances, "client", 10), 6)
+
+	picked := subset(instances, "client", 3)
+	require.Len(t, picked, 3)
+	// 同一 key 的子集稳定，移除未选中的实例不影响子集
+	var rest []*registry.ServiceInstance
+	for _, si := range instances {
+		if si != picked[0] && si != picked[1] && si != picked[2] {
+			rest = append(rest, si)
+		}
+	}
+	remaining := append(append([]*registry.ServiceInstance(nil), picked...), rest[1:]...)
+	assert.ElementsMatch(t, picked, subset(remaining, "client", 3))
+}
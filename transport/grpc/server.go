@@ -3,12 +3,18 @@ package grpc
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"net"
+	"net/url"
+	"sync"
 
 	"github.com/haysons/gokit/middleware"
 	"github.com/haysons/gokit/transport"
+	utilnet "github.com/haysons/gokit/util/net"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	grpcmd "google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/reflection"
 )
@@ -22,6 +28,8 @@ type ServerConfig struct {
 	streamInts        []grpc.StreamServerInterceptor // grpc 流式拦截器
 	grpcOpts          []grpc.ServerOption            // grpc 原生配置
 	disableReflection bool                           // 关闭服务端反射（服务端反射可以为客户端提供服务器有哪些服务及方法）
+	healthServer      healthpb.HealthServer          // grpc.health.v1 健康检查服务
+	disableHealth     bool                           // 关闭健康检查服务
 }
 
 // ServerOption 函数式配置项
@@ -69,6 +77,20 @@ func WithDisableReflection() ServerOption {
 	}
 }
 
+// WithHealthServer 配置 grpc.health.v1 健康检查服务，如 health.NewGRPCServer，默认使用 grpc 自带的健康检查服务
+func WithHealthServer(srv healthpb.HealthServer) ServerOption {
+	return func(c *ServerConfig) {
+		c.healthServer = srv
+	}
+}
+
+// WithDisableHealth 禁用健康检查服务
+func WithDisableHealth() ServerOption {
+	return func(c *ServerConfig) {
+		c.disableHealth = true
+	}
+}
+
 // WithGRPCOptions 增加原生 grpc 配置
 func WithGRPCOptions(opts ...grpc.ServerOption) ServerOption {
 	return func(c *ServerConfig) {
@@ -76,11 +98,22 @@ func WithGRPCOptions(opts ...grpc.ServerOption) ServerOption {
 	}
 }
 
+var (
+	_ transport.Server     = (*Server)(nil)
+	_ transport.Readier    = (*Server)(nil)
+	_ transport.Endpointer = (*Server)(nil)
+)
+
 type Server struct {
 	grpcServer       *grpc.Server
 	cfg              *ServerConfig
 	middleware       []middleware.Middleware
 	streamMiddleware []middleware.Middleware
+	ready            chan struct{} // 端口监听完成后关闭
+	endpoint         *url.URL      // 服务对外的入口点，端口监听完成后确定
+	readyOnce        sync.Once
+	defaultHealth    *health.Server // 未指定健康检查服务时使用的 grpc 自带健康检查服务
+	health           *healthProxy   // 已注册的健康检查服务，禁用健康检查时为 nil
 }
 
 // NewServer 创建 grpc 服务器
@@ -91,7 +124,8 @@ func NewServer(opts ...ServerOption) *Server {
 		opt(cfg)
 	}
 	srv := &Server{
-		cfg: cfg,
+		cfg:   cfg,
+		ready: make(chan struct{}),
 	}
 
 	// 拦截器
@@ -127,10 +161,29 @@ func NewServer(opts ...ServerOption) *Server {
 		reflection.Register(grpcServer)
 	}
 
+	// 默认注册健康检查服务
+	if !cfg.disableHealth {
+		healthServer := cfg.healthServer
+		if healthServer == nil {
+			srv.defaultHealth = health.NewServer()
+			healthServer = srv.defaultHealth
+		}
+		srv.health = newHealthProxy(healthServer)
+		healthpb.RegisterHealthServer(grpcServer, srv.health)
+	}
+
 	srv.grpcServer = grpcServer
 	return srv
 }
 
+// SetHealthServer 替换 grpc.health.v1 健康检查服务，服务启动后亦可调用，禁用健康检查服务时无效。
+// health.Module 借此将基于检查项的健康检查服务自动注册至应用管理的 grpc 服务器
+func (s *Server) SetHealthServer(srv healthpb.HealthServer) {
+	if s.health != nil && srv != nil {
+		s.health.set(srv)
+	}
+}
+
 // Use 请求响应式接口添加中间件
 func (s *Server) Use(m ...middleware.Middleware) {
 	s.middleware = append(s.middleware, m...)
@@ -146,17 +199,57 @@ func (s *Server) GetServiceRegistrar() grpc.ServiceRegistrar {
 	return s.grpcServer
 }
 
-// Start 启动 grpc 服务器
+// Start 启动 grpc 服务器，此方法将阻塞直至服务器停止
 func (s *Server) Start(ctx context.Context) error {
 	lis, err := net.Listen("tcp", s.cfg.Addr)
 	if err != nil {
 		return err
 	}
-	return s.grpcServer.Serve(lis)
+	s.readyOnce.Do(func() {
+		s.endpoint = &url.URL{Scheme: "grpc", Host: endpointHost(lis.Addr())}
+		close(s.ready)
+	})
+	if err = s.grpcServer.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+	return nil
+}
+
+// Ready 返回的管道将在端口监听完成后关闭
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Endpoint 返回服务对外的入口点，如 grpc://10.0.0.1:9000，服务需完成端口监听
+func (s *Server) Endpoint() (*url.URL, error) {
+	select {
+	case <-s.ready:
+		return s.endpoint, nil
+	default:
+		return nil, errors.New("grpc server is not listening")
+	}
+}
+
+// endpointHost 监听地址为未指定地址（如 :9000、0.0.0.0:9000）时，使用本机私有ip作为入口点地址
+func endpointHost(addr net.Addr) string {
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		if localIP := utilnet.GetLocalIP(); localIP != "" {
+			host = localIP
+		}
+	}
+	return net.JoinHostPort(host, port)
 }
 
 // Stop 停止 grpc 服务器
 func (s *Server) Stop(ctx context.Context) error {
+	if s.defaultHealth != nil {
+		// 停止前将健康状态置为 NOT_SERVING
+		s.defaultHealth.Shutdown()
+	}
 	done := make(chan struct{})
 	go func() {
 		// 优雅关闭
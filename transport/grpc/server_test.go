@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/resolver"
 )
 
@@ -42,3 +43,75 @@ func TestServerStart(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "Hello hayson", resp.Message)
 }
+
+func TestServerDefaultHealth(t *testing.T) {
+	ctx := context.Background()
+	server := NewServer(WithAddr("127.0.0.1:8089"))
+	go func() {
+		_ = server.Start(ctx)
+	}()
+	<-server.Ready()
+
+	conn, err := grpc.NewClient("127.0.0.1:8089", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+	require.NoError(t, server.Stop(ctx))
+}
+
+func TestServerEndpoint(t *testing.T) {
+	ctx := context.Background()
+	server := NewServer(WithAddr("127.0.0.1:0"))
+	_, err := server.Endpoint()
+	require.Error(t, err)
+
+	go func() {
+		_ = server.Start(ctx)
+	}()
+	<-server.Ready()
+	defer server.Stop(ctx)
+
+	u, err := server.Endpoint()
+	require.NoError(t, err)
+	require.Equal(t, "grpc", u.Scheme)
+	require.Equal(t, "127.0.0.1", u.Hostname())
+	require.NotEqual(t, "0", u.Port())
+}
+
+type notServingHealth struct {
+	healthpb.UnimplementedHealthServer
+}
+
+func (notServingHealth) Check(context.Context, *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}, nil
+}
+
+func TestServerSetHealthServer(t *testing.T) {
+	ctx := context.Background()
+	server := NewServer(WithAddr("127.0.0.1:0"))
+	go func() {
+		_ = server.Start(ctx)
+	}()
+	<-server.Ready()
+	defer server.Stop(ctx)
+
+	u, err := server.Endpoint()
+	require.NoError(t, err)
+	conn, err := grpc.NewClient(u.Host, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	// 服务启动后替换健康检查服务
+	server.SetHealthServer(notServingHealth{})
+	resp, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+}
@@ -0,0 +1,134 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: error_reason.proto
+
+package helloworld
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// @gokit:errors http=400 grpc=INVALID_ARGUMENT module=helloworld
+type ErrorReason int32
+
+const (
+	ErrorReason_ERROR_REASON_UNSPECIFIED ErrorReason = 0
+	// 名称为空
+	ErrorReason_NAME_EMPTY ErrorReason = 20001
+	// 用户不存在
+	// @gokit:error http=404 grpc=NOT_FOUND hint="用户不存在" hint_id=helloworld.user_not_found
+	ErrorReason_USER_NOT_FOUND ErrorReason = 20002
+)
+
+// Enum value maps for ErrorReason.
+var (
+	ErrorReason_name = map[int32]string{
+		0:     "ERROR_REASON_UNSPECIFIED",
+		20001: "NAME_EMPTY",
+		20002: "USER_NOT_FOUND",
+	}
+	ErrorReason_value = map[string]int32{
+		"ERROR_REASON_UNSPECIFIED": 0,
+		"NAME_EMPTY":               20001,
+		"USER_NOT_FOUND":           20002,
+	}
+)
+
+func (x ErrorReason) Enum() *ErrorReason {
+	p := new(ErrorReason)
+	*p = x
+	return p
+}
+
+func (x ErrorReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ErrorReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_error_reason_proto_enumTypes[0].Descriptor()
+}
+
+func (ErrorReason) Type() protoreflect.EnumType {
+	return &file_error_reason_proto_enumTypes[0]
+}
+
+func (x ErrorReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ErrorReason.Descriptor instead.
+func (ErrorReason) EnumDescriptor() ([]byte, []int) {
+	return file_error_reason_proto_rawDescGZIP(), []int{0}
+}
+
+var File_error_reason_proto protoreflect.FileDescriptor
+
+const file_error_reason_proto_rawDesc = "" +
+	"\n" +
+	"\x12error_reason.proto\x12\rhelloworld.v1*S\n" +
+	"\vErrorReason\x12\x1c\n" +
+	"\x18ERROR_REASON_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\n" +
+	"NAME_EMPTY\x10\xa1\x9c\x01\x12\x14\n" +
+	"\x0eUSER_NOT_FOUND\x10\xa2\x9c\x01B8Z6github.com/haysons/gokit/transport/testdata/helloworldb\x06proto3"
+
+var (
+	file_error_reason_proto_rawDescOnce sync.Once
+	file_error_reason_proto_rawDescData []byte
+)
+
+func file_error_reason_proto_rawDescGZIP() []byte {
+	file_error_reason_proto_rawDescOnce.Do(func() {
+		file_error_reason_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_error_reason_proto_rawDesc), len(file_error_reason_proto_rawDesc)))
+	})
+	return file_error_reason_proto_rawDescData
+}
+
+var file_error_reason_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_error_reason_proto_goTypes = []any{
+	(ErrorReason)(0), // 0: helloworld.v1.ErrorReason
+}
+var file_error_reason_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_error_reason_proto_init() }
+func file_error_reason_proto_init() {
+	if File_error_reason_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_error_reason_proto_rawDesc), len(file_error_reason_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   0,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_error_reason_proto_goTypes,
+		DependencyIndexes: file_error_reason_proto_depIdxs,
+		EnumInfos:         file_error_reason_proto_enumTypes,
+	}.Build()
+	File_error_reason_proto = out.File
+	file_error_reason_proto_goTypes = nil
+	file_error_reason_proto_depIdxs = nil
+}
@@ -0,0 +1,51 @@
+// Code generated by protoc-gen-gokit-errors. DO NOT EDIT.
+// versions:
+// - protoc-gen-gokit-errors v0.1.0
+// - protoc                  (unknown)
+// source: error_reason.proto
+
+package helloworld
+
+import (
+	errors "github.com/haysons/gokit/errors"
+	codes "google.golang.org/grpc/codes"
+)
+
+var (
+	def_ErrorReason_NAME_EMPTY = errors.Register(errors.Definition{
+		Code:     int(ErrorReason_NAME_EMPTY),
+		Message:  "NAME_EMPTY",
+		HttpCode: 400,
+		GrpcCode: codes.InvalidArgument,
+		Module:   "helloworld",
+	})
+	def_ErrorReason_USER_NOT_FOUND = errors.Register(errors.Definition{
+		Code:     int(ErrorReason_USER_NOT_FOUND),
+		Message:  "USER_NOT_FOUND",
+		Hint:     "用户不存在",
+		HintID:   "helloworld.user_not_found",
+		HttpCode: 404,
+		GrpcCode: codes.NotFound,
+		Module:   "helloworld",
+	})
+)
+
+// IsNameEmpty 判断错误是否为 ErrorReason_NAME_EMPTY
+func IsNameEmpty(err error) bool {
+	return def_ErrorReason_NAME_EMPTY.Is(err)
+}
+
+// ErrorNameEmpty 名称为空
+func ErrorNameEmpty(format string, args ...any) error {
+	return def_ErrorReason_NAME_EMPTY.Newf(format, args...)
+}
+
+// IsUserNotFound 判断错误是否为 ErrorReason_USER_NOT_FOUND
+func IsUserNotFound(err error) bool {
+	return def_ErrorReason_USER_NOT_FOUND.Is(err)
+}
+
+// ErrorUserNotFound 用户不存在
+func ErrorUserNotFound(format string, args ...any) error {
+	return def_ErrorReason_USER_NOT_FOUND.Newf(format, args...)
+}
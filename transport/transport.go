@@ -1,6 +1,9 @@
 package transport
 
-import "context"
+import (
+	"context"
+	"net/url"
+)
 
 // Server 传输层 server 部分
 type Server interface {
@@ -8,6 +11,18 @@ type Server interface {
 	Stop(context.Context) error
 }
 
+// Readier 传输层 server 可选实现的接口，服务完成端口监听、可以接收请求后关闭 Ready 返回的管道，
+// 由于 Start 一般会阻塞直至服务停止，应用需借此判断服务是否启动完成
+type Readier interface {
+	Ready() <-chan struct{}
+}
+
+// Endpointer 传输层 server 可选实现的接口，返回服务对外的入口点，如 grpc://10.0.0.1:9000，
+// 应用将其作为服务实例的入口点注册至注册中心
+type Endpointer interface {
+	Endpoint() (*url.URL, error)
+}
+
 // Header 传输层 header 部分，主要用于存取元数据
 type Header interface {
 	Get(key string) string
@@ -0,0 +1,360 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	metricLabelCache  = "cache"
+	metricLabelResult = "result"
+	metricLabelReason = "reason"
+
+	defaultSketchWidth = 4096
+)
+
+// ErrNotFound 加载的数据不存在，Loader 返回该错误时可通过 WithNegativeTTL 缓存未找到的结果
+var ErrNotFound = errors.New("cache: not found")
+
+// Loader 缓存未命中时加载 key 对应的值
+type Loader[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+// Stats 缓存的统计信息
+type Stats struct {
+	Hits         int64 // 命中次数
+	Misses       int64 // 未命中次数
+	Loads        int64 // 加载次数，并发的加载合并为一次
+	LoadFailures int64 // 加载失败次数，包含未找到
+	Evictions    int64 // 因容量或过期淘汰的次数
+	Size         int   // 当前缓存项数量
+	Cost         int64 // 当前总成本
+}
+
+// call 进行中的加载，相同 key 的并发加载合并为一次
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+	stale bool // 加载期间 key 被删除，加载结果不再写入缓存
+}
+
+// Cache 泛型进程内缓存，支持数量及成本上限、过期时间、LRU 及 W-TinyLFU 淘汰策略、
+// 合并并发加载、缓存未找到的结果以及基于发布订阅的分布式失效通知
+type Cache[K comparable, V any] struct {
+	opts   options
+	costFn func(V) int64 // 计算缓存项的成本，未配置时为 nil
+	seed   maphash.Seed
+	mu     sync.Mutex
+	items  map[K]*entry[K, V]
+	policy policy[K, V]
+	calls  map[K]*call[V]
+	cost   int64
+	stats  Stats
+
+	attrs  map[string]metric.MeasurementOption // 预先构造的指标标签
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New 创建缓存，配置 WithInvalidation 时在后台订阅失效通知，不再使用时需调用 Close
+func New[K comparable, V any](opts ...Option) *Cache[K, V] {
+	return NewWithCost[K, V](nil, opts...)
+}
+
+// NewWithCost 创建以 cost 计算缓存项成本的缓存，如缓存值占用的字节数，配合 WithMaxCost 限制总成本，
+// cost 为 nil 时每个缓存项的成本为1，其余同 New
+func NewWithCost[K comparable, V any](cost func(value V) int64, opts ...Option) *Cache[K, V] {
+	o := newOptions(opts...)
+	capacity := int64(o.maxSize)
+	if o.maxCost > 0 {
+		capacity = o.maxCost
+	}
+	sketchWidth := defaultSketchWidth
+	if o.maxSize > 0 {
+		sketchWidth = o.maxSize
+	}
+	c := &Cache[K, V]{
+		opts:   o,
+		costFn: cost,
+		seed:   maphash.MakeSeed(),
+		items:  make(map[K]*entry[K, V]),
+		policy: newPolicy[K, V](o.policy, capacity, sketchWidth),
+		calls:  make(map[K]*call[V]),
+		attrs:  make(map[string]metric.MeasurementOption),
+	}
+	for _, label := range []struct{ key, value string }{
+		{metricLabelResult, "hit"}, {metricLabelResult, "miss"},
+		{metricLabelReason, "capacity"}, {metricLabelReason, "expired"},
+		{metricLabelResult, "success"}, {metricLabelResult, "failure"}, {metricLabelResult, "not_found"},
+	} {
+		c.attrs[label.value] = metric.WithAttributes(
+			attribute.String(metricLabelCache, o.name),
+			attribute.String(label.key, label.value),
+		)
+	}
+	if o.pubsub != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		c.cancel, c.done = cancel, make(chan struct{})
+		go c.listen(ctx)
+	}
+	return c
+}
+
+// Get 获取缓存值，不存在、已过期或缓存的是未找到的结果时返回 false
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	e := c.lookup(key)
+	hit := e != nil && e.err == nil
+	result := c.count(hit)
+	c.mu.Unlock()
+	c.recordRequest(result)
+	if !hit {
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+// Set 写入缓存值，使用默认的过期时间
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.opts.ttl)
+}
+
+// SetWithTTL 写入缓存值并指定过期时间，ttl 为0时不过期
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.markStale(key)
+	c.set(key, value, nil, ttl)
+}
+
+// GetOrLoad 获取缓存值，未命中时调用 loader 加载并写入缓存，相同 key 的并发加载合并为一次。
+// 加载不随调用方的 ctx 取消，各调用方在自身 ctx 结束时停止等待，加载的最长时间可通过 WithLoadTimeout 限制。
+// loader 返回未找到的错误且配置了 WithNegativeTTL 时缓存该错误，期间直接返回该错误；其他错误不会被缓存
+func (c *Cache[K, V]) GetOrLoad(ctx context.Context, key K, loader Loader[K, V]) (V, error) {
+	c.mu.Lock()
+	if e := c.lookup(key); e != nil {
+		result := c.count(true)
+		c.mu.Unlock()
+		c.recordRequest(result)
+		return e.value, e.err
+	}
+	missed := c.count(false)
+	cl, ok := c.calls[key]
+	if !ok {
+		cl = &call[V]{done: make(chan struct{})}
+		c.calls[key] = cl
+		c.stats.Loads++
+		go c.do(context.WithoutCancel(ctx), key, cl, loader)
+	}
+	c.mu.Unlock()
+	c.recordRequest(missed)
+
+	select {
+	case <-cl.done:
+		return cl.value, cl.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// do 执行加载并写入缓存，完成后通知等待该加载的全部调用方
+func (c *Cache[K, V]) do(ctx context.Context, key K, cl *call[V], loader Loader[K, V]) {
+	if c.opts.loadTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.loadTimeout)
+		defer cancel()
+	}
+	start := time.Now()
+	cl.value, cl.err = c.load(ctx, key, loader)
+	result := "success"
+
+	c.mu.Lock()
+	delete(c.calls, key)
+	switch {
+	case cl.err == nil:
+		if !cl.stale {
+			c.set(key, cl.value, nil, c.opts.ttl)
+		}
+	case c.opts.notFound(cl.err):
+		result = "not_found"
+		c.stats.LoadFailures++
+		if !cl.stale && c.opts.negativeTTL > 0 {
+			var zero V
+			c.set(key, zero, cl.err, c.opts.negativeTTL)
+		}
+	default:
+		result = "failure"
+		c.stats.LoadFailures++
+	}
+	c.mu.Unlock()
+
+	if c.opts.loadSeconds != nil {
+		c.opts.loadSeconds.Record(context.Background(), time.Since(start).Seconds(), c.attrs[result])
+	}
+	close(cl.done)
+}
+
+// load 调用 loader，loader 发生 panic 时转换为错误，避免等待同一加载的调用方永久阻塞
+func (c *Cache[K, V]) load(ctx context.Context, key K, loader Loader[K, V]) (value V, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("cache: loader panic: %v", r)
+		}
+	}()
+	return loader(ctx, key)
+}
+
+// Delete 删除本地缓存项，进行中的加载结果不会写入缓存
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.markStale(key)
+	if e, ok := c.items[key]; ok {
+		c.remove(e)
+	}
+}
+
+// Clear 删除全部本地缓存项，进行中的加载结果不会写入缓存
+func (c *Cache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, cl := range c.calls {
+		cl.stale = true
+	}
+	clear(c.items)
+	c.policy.clear()
+	c.cost = 0
+}
+
+// Len 当前缓存项数量，包含已过期但尚未淘汰的缓存项
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.items)
+}
+
+// Stats 缓存的统计信息
+func (c *Cache[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Size = len(c.items)
+	stats.Cost = c.cost
+	return stats
+}
+
+// Close 停止订阅失效通知，未配置 WithInvalidation 时不做任何操作
+func (c *Cache[K, V]) Close() {
+	if c.cancel == nil {
+		return
+	}
+	c.cancel()
+	<-c.done
+}
+
+// lookup 查找有效的缓存项，过期的缓存项将被淘汰，需持有 c.mu
+func (c *Cache[K, V]) lookup(key K) *entry[K, V] {
+	e, ok := c.items[key]
+	if !ok {
+		return nil
+	}
+	if e.expired(time.Now()) {
+		c.remove(e)
+		c.evicted("expired")
+		return nil
+	}
+	c.policy.access(e)
+	return e
+}
+
+// set 写入缓存项并淘汰超出容量的缓存项，需持有 c.mu
+func (c *Cache[K, V]) set(key K, value V, err error, ttl time.Duration) {
+	if old, ok := c.items[key]; ok {
+		c.remove(old)
+	}
+	e := &entry[K, V]{
+		key:    key,
+		value:  value,
+		err:    err,
+		cost:   1,
+		weight: 1,
+		hash:   maphash.Comparable(c.seed, key),
+	}
+	if err == nil && c.costFn != nil {
+		e.cost = max(c.costFn(value), 0)
+	}
+	if c.opts.maxCost > 0 {
+		e.weight = e.cost
+	}
+	if ttl > 0 {
+		if c.opts.jitter > 0 {
+			ttl += rand.N(c.opts.jitter)
+		}
+		e.expireAt = time.Now().Add(ttl)
+	}
+	c.items[key] = e
+	c.cost += e.cost
+	c.policy.add(e)
+
+	for c.overflow() {
+		victim := c.policy.victim()
+		if victim == nil {
+			break
+		}
+		c.remove(victim)
+		c.evicted("capacity")
+	}
+}
+
+func (c *Cache[K, V]) overflow() bool {
+	return (c.opts.maxSize > 0 && len(c.items) > c.opts.maxSize) ||
+		(c.opts.maxCost > 0 && c.cost > c.opts.maxCost)
+}
+
+// remove 移除缓存项，需持有 c.mu
+func (c *Cache[K, V]) remove(e *entry[K, V]) {
+	c.policy.remove(e)
+	delete(c.items, e.key)
+	c.cost -= e.cost
+}
+
+// markStale 标记 key 进行中的加载已过时，需持有 c.mu
+func (c *Cache[K, V]) markStale(key K) {
+	if cl, ok := c.calls[key]; ok {
+		cl.stale = true
+	}
+}
+
+// count 统计命中或未命中并返回对应的指标标签，需持有 c.mu
+func (c *Cache[K, V]) count(hit bool) string {
+	if hit {
+		c.stats.Hits++
+		return "hit"
+	}
+	c.stats.Misses++
+	return "miss"
+}
+
+func (c *Cache[K, V]) recordRequest(result string) {
+	if c.opts.requests != nil {
+		c.opts.requests.Add(context.Background(), 1, c.attrs[result])
+	}
+}
+
+// evicted 记录淘汰，需持有 c.mu
+func (c *Cache[K, V]) evicted(reason string) {
+	c.stats.Evictions++
+	if c.opts.evictions != nil {
+		c.opts.evictions.Add(context.Background(), 1, c.attrs[reason])
+	}
+}
@@ -0,0 +1,302 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/haysons/gokit/distributed/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestCache_GetSet(t *testing.T) {
+	c := New[string, int]()
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+
+	c.Set("a", 1)
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	c.Set("a", 2)
+	v, _ = c.Get("a")
+	assert.Equal(t, 2, v)
+	assert.Equal(t, 1, c.Len())
+
+	c.Delete("a")
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+
+	stats := c.Stats()
+	assert.EqualValues(t, 2, stats.Hits)
+	assert.EqualValues(t, 2, stats.Misses)
+}
+
+func TestCache_TTL(t *testing.T) {
+	c := New[string, int](WithTTL(50*time.Millisecond), WithJitter(10*time.Millisecond))
+	c.Set("a", 1)
+	c.SetWithTTL("b", 2, 0)
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	time.Sleep(100 * time.Millisecond)
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+	_, ok = c.Get("b")
+	assert.True(t, ok)
+	assert.EqualValues(t, 1, c.Stats().Evictions)
+}
+
+func TestCache_MaxSize(t *testing.T) {
+	c := New[int, int](WithMaxSize(3))
+	for i := range 3 {
+		c.Set(i, i)
+	}
+	// 访问0后1成为最久未访问的缓存项
+	c.Get(0)
+	c.Set(3, 3)
+	assert.Equal(t, 3, c.Len())
+	_, ok := c.Get(1)
+	assert.False(t, ok)
+	for _, k := range []int{0, 2, 3} {
+		_, ok = c.Get(k)
+		assert.True(t, ok, k)
+	}
+}
+
+func TestCache_MaxCost(t *testing.T) {
+	c := NewWithCost[string](func(v string) int64 {
+		return int64(len(v))
+	}, WithMaxSize(0), WithMaxCost(10))
+	c.Set("a", "12345")
+	c.Set("b", "1234")
+	assert.EqualValues(t, 9, c.Stats().Cost)
+	c.Set("c", "123")
+	assert.EqualValues(t, 7, c.Stats().Cost)
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+
+	// 成本超出上限的缓存项不会被保留
+	c.Set("d", "12345678901")
+	_, ok = c.Get("d")
+	assert.False(t, ok)
+	assert.LessOrEqual(t, c.Stats().Cost, int64(10))
+}
+
+func TestCache_GetOrLoad(t *testing.T) {
+	c := New[string, string]()
+	var loads atomic.Int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context, key string) (string, error) {
+		loads.Add(1)
+		<-release
+		return "v-" + key, nil
+	}
+
+	// 并发的加载合并为一次
+	const n = 10
+	var wg sync.WaitGroup
+	results := make([]string, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.GetOrLoad(context.Background(), "k", loader)
+			assert.NoError(t, err)
+			results[i] = v
+		}()
+	}
+	require.Eventually(t, func() bool { return loads.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.EqualValues(t, 1, loads.Load())
+	for _, v := range results {
+		assert.Equal(t, "v-k", v)
+	}
+
+	v, err := c.GetOrLoad(context.Background(), "k", loader)
+	require.NoError(t, err)
+	assert.Equal(t, "v-k", v)
+	assert.EqualValues(t, 1, loads.Load())
+	assert.EqualValues(t, 1, c.Stats().Loads)
+}
+
+func TestCache_GetOrLoadCancel(t *testing.T) {
+	c := New[string, string](WithLoadTimeout(time.Second))
+	started, release := make(chan struct{}), make(chan struct{})
+	loader := func(ctx context.Context, key string) (string, error) {
+		close(started)
+		// 加载不随发起加载的调用方取消，但受 WithLoadTimeout 限制
+		_, ok := ctx.Deadline()
+		assert.True(t, ok)
+		select {
+		case <-release:
+			return "v-" + key, ctx.Err()
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := make(chan error, 1)
+	go func() {
+		_, err := c.GetOrLoad(ctx, "k", loader)
+		leader <- err
+	}()
+	<-started
+	waiter := make(chan string, 1)
+	go func() {
+		v, err := c.GetOrLoad(context.Background(), "k", loader)
+		assert.NoError(t, err)
+		waiter <- v
+	}()
+
+	// 发起加载的调用方取消后立即返回，其他调用方仍等待加载完成
+	cancel()
+	assert.ErrorIs(t, <-leader, context.Canceled)
+	close(release)
+	assert.Equal(t, "v-k", <-waiter)
+	v, ok := c.Get("k")
+	assert.True(t, ok)
+	assert.Equal(t, "v-k", v)
+}
+
+func TestCache_GetOrLoadTimeout(t *testing.T) {
+	c := New[string, string](WithLoadTimeout(20 * time.Millisecond))
+	_, err := c.GetOrLoad(context.Background(), "k", func(ctx context.Context, key string) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestCache_GetOrLoadError(t *testing.T) {
+	c := New[int, int](WithNegativeTTL(time.Minute))
+	var loads atomic.Int32
+	boom := errors.New("boom")
+	loader := func(ctx context.Context, key int) (int, error) {
+		loads.Add(1)
+		switch key {
+		case 1:
+			return 0, ErrNotFound
+		case 2:
+			return 0, boom
+		default:
+			panic("oops")
+		}
+	}
+
+	// 未找到的结果被缓存
+	for range 2 {
+		_, err := c.GetOrLoad(context.Background(), 1, loader)
+		assert.ErrorIs(t, err, ErrNotFound)
+	}
+	assert.EqualValues(t, 1, loads.Load())
+	_, ok := c.Get(1)
+	assert.False(t, ok)
+
+	// 其他错误不会被缓存
+	for range 2 {
+		_, err := c.GetOrLoad(context.Background(), 2, loader)
+		assert.ErrorIs(t, err, boom)
+	}
+	assert.EqualValues(t, 3, loads.Load())
+
+	_, err := c.GetOrLoad(context.Background(), 3, loader)
+	assert.ErrorContains(t, err, "oops")
+	assert.EqualValues(t, 4, c.Stats().LoadFailures)
+}
+
+func TestCache_DeleteDuringLoad(t *testing.T) {
+	c := New[string, int]()
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		v, err := c.GetOrLoad(context.Background(), "k", func(ctx context.Context, key string) (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, v)
+	}()
+	<-started
+	// 加载期间 key 被删除，加载结果可能已过时，不再写入缓存
+	c.Delete("k")
+	close(release)
+	<-done
+	_, ok := c.Get("k")
+	assert.False(t, ok)
+}
+
+func TestCache_Metrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("cache")
+	requests, err := DefaultRequestsCounter(meter, DefaultRequestsCounterName)
+	require.NoError(t, err)
+	evictions, err := DefaultEvictionsCounter(meter, DefaultEvictionsCounterName)
+	require.NoError(t, err)
+	loadSeconds, err := DefaultLoadSecondsHistogram(meter, DefaultLoadSecondsHistogramName)
+	require.NoError(t, err)
+
+	c := New[int, int](WithName("test"), WithMaxSize(1),
+		WithRequests(requests), WithEvictions(evictions), WithLoadSeconds(loadSeconds))
+	for i := range 2 {
+		_, err = c.GetOrLoad(context.Background(), i, func(ctx context.Context, key int) (int, error) {
+			return key, nil
+		})
+		require.NoError(t, err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	names := make(map[string]bool)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			names[m.Name] = true
+		}
+	}
+	assert.True(t, names[DefaultRequestsCounterName])
+	assert.True(t, names[DefaultEvictionsCounterName])
+	assert.True(t, names[DefaultLoadSecondsHistogramName])
+}
+
+func TestCache_Invalidation(t *testing.T) {
+	backend := memory.NewBackend()
+	caches := make([]*Cache[string, int], 2)
+	for i := range caches {
+		caches[i] = New[string, int](WithInvalidation(backend.NewPubSub("/cache"), "users"))
+		defer caches[i].Close()
+	}
+	// 等待订阅建立
+	require.Eventually(t, func() bool {
+		require.NoError(t, caches[0].Invalidate(context.Background(), "probe"))
+		caches[1].Set("probe", 1)
+		time.Sleep(10 * time.Millisecond)
+		_, ok := caches[1].Get("probe")
+		return !ok
+	}, 5*time.Second, 50*time.Millisecond)
+
+	for i := range 3 {
+		for _, c := range caches {
+			c.Set(strconv.Itoa(i), i)
+		}
+	}
+	require.NoError(t, caches[0].Invalidate(context.Background(), "0", "1"))
+	require.Eventually(t, func() bool { return caches[1].Len() == 1 }, time.Second, 10*time.Millisecond)
+	_, ok := caches[1].Get("2")
+	assert.True(t, ok)
+
+	require.NoError(t, caches[1].InvalidateAll(context.Background()))
+	require.Eventually(t, func() bool { return caches[0].Len() == 0 }, time.Second, 10*time.Millisecond)
+}
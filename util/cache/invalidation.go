@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/haysons/gokit/log"
+)
+
+const resubscribeInterval = time.Second
+
+// invalidation 失效通知
+type invalidation[K comparable] struct {
+	Keys []K  `json:"keys,omitempty"`
+	All  bool `json:"all,omitempty"` // 删除全部缓存项
+}
+
+// Invalidate 删除本地缓存项，配置 WithInvalidation 时同时发布失效通知，通知其他实例删除对应的缓存项
+func (c *Cache[K, V]) Invalidate(ctx context.Context, keys ...K) error {
+	for _, key := range keys {
+		c.Delete(key)
+	}
+	return c.publish(ctx, invalidation[K]{Keys: keys})
+}
+
+// InvalidateAll 删除全部本地缓存项，配置 WithInvalidation 时同时发布失效通知，通知其他实例删除全部缓存项
+func (c *Cache[K, V]) InvalidateAll(ctx context.Context) error {
+	c.Clear()
+	return c.publish(ctx, invalidation[K]{All: true})
+}
+
+func (c *Cache[K, V]) publish(ctx context.Context, msg invalidation[K]) error {
+	if c.opts.pubsub == nil {
+		return nil
+	}
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("cache: marshal invalidation failed: %w", err)
+	}
+	if err = c.opts.pubsub.Publish(ctx, c.opts.topic, payload); err != nil {
+		return fmt.Errorf("cache: publish invalidation failed: %w", err)
+	}
+	return nil
+}
+
+// listen 订阅失效通知并删除对应的缓存项，直至 ctx 结束；订阅失败时定期重试，
+// 重试成功后删除全部缓存项，避免保留订阅建立前已失效的缓存项
+func (c *Cache[K, V]) listen(ctx context.Context) {
+	defer close(c.done)
+	logger := log.GetDefaultSlog().With(slog.String("cache", c.opts.name), slog.String("topic", c.opts.topic))
+	retried := false
+	for {
+		ch, err := c.opts.pubsub.Subscribe(ctx, c.opts.topic)
+		if err == nil {
+			if retried {
+				c.Clear()
+			}
+			for msg := range ch {
+				c.apply(logger, msg.Payload)
+			}
+		} else {
+			logger.Error("subscribe cache invalidation failed", slog.Any("error", err))
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		retried = true
+		select {
+		case <-time.After(resubscribeInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *Cache[K, V]) apply(logger *slog.Logger, payload []byte) {
+	var msg invalidation[K]
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		logger.Error("unmarshal cache invalidation failed", slog.Any("error", err))
+		return
+	}
+	if msg.All {
+		c.Clear()
+		return
+	}
+	for _, key := range msg.Keys {
+		c.Delete(key)
+	}
+}
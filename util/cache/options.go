@@ -0,0 +1,176 @@
+package cache
+
+import (
+	"errors"
+	"time"
+
+	"github.com/haysons/gokit/distributed"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const defaultMaxSize = 10000
+
+const (
+	DefaultRequestsCounterName      = "cache_requests_total"
+	DefaultEvictionsCounterName     = "cache_evictions_total"
+	DefaultLoadSecondsHistogramName = "cache_load_seconds_bucket"
+)
+
+// Policy 淘汰策略
+type Policy int
+
+const (
+	// PolicyLRU 淘汰最久未访问的缓存项
+	PolicyLRU Policy = iota
+	// PolicyTinyLFU W-TinyLFU 策略，新的缓存项先进入窗口 LRU，被挤出窗口时与主区的淘汰候选比较访问频率，
+	// 频率更高者保留，能够抵御批量扫描对热点数据的冲刷，命中率一般优于 LRU
+	PolicyTinyLFU
+)
+
+// Option 缓存配置项
+type Option func(*options)
+
+type options struct {
+	name        string                  // 缓存名称，作为指标的 cache 标签
+	maxSize     int                     // 最大缓存项数量，为0时不限制
+	maxCost     int64                   // 最大总成本，为0时不限制
+	loadTimeout time.Duration           // 单次加载的超时时间，为0时不限制
+	policy      Policy                  // 淘汰策略
+	ttl         time.Duration           // 缓存项的默认过期时间，为0时不过期
+	jitter      time.Duration           // 过期时间的随机抖动上限
+	negativeTTL time.Duration           // 未找到结果的缓存时间，为0时不缓存
+	notFound    func(err error) bool    // 判断加载错误是否表示未找到
+	pubsub      distributed.PubSub      // 失效通知的发布订阅
+	topic       string                  // 失效通知的主题
+	requests    metric.Int64Counter     // 请求计数器
+	evictions   metric.Int64Counter     // 淘汰计数器
+	loadSeconds metric.Float64Histogram // 加载耗时直方图
+}
+
+func newOptions(opts ...Option) options {
+	o := options{
+		maxSize:  defaultMaxSize,
+		policy:   PolicyLRU,
+		notFound: func(err error) bool { return errors.Is(err, ErrNotFound) },
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.maxSize = max(o.maxSize, 0)
+	o.maxCost = max(o.maxCost, 0)
+	return o
+}
+
+// WithName 配置缓存名称，作为指标的 cache 标签以区分不同的缓存
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithMaxSize 配置最大缓存项数量，默认为10000，为0时不限制，超过后按淘汰策略淘汰缓存项
+func WithMaxSize(n int) Option {
+	return func(o *options) {
+		o.maxSize = n
+	}
+}
+
+// WithMaxCost 配置最大总成本，默认不限制，超过后按淘汰策略淘汰缓存项，需通过 NewWithCost 创建缓存以指定成本的计算方式
+func WithMaxCost(cost int64) Option {
+	return func(o *options) {
+		o.maxCost = cost
+	}
+}
+
+// WithPolicy 配置淘汰策略，默认为 PolicyLRU
+func WithPolicy(policy Policy) Option {
+	return func(o *options) {
+		o.policy = policy
+	}
+}
+
+// WithTTL 配置缓存项的默认过期时间，默认不过期
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithJitter 配置过期时间的随机抖动，过期时间将随机增加 [0, jitter)，避免同时写入的缓存项同时过期
+func WithJitter(jitter time.Duration) Option {
+	return func(o *options) {
+		o.jitter = jitter
+	}
+}
+
+// WithLoadTimeout 配置 GetOrLoad 单次加载的超时时间，默认不限制。加载不随发起加载的调用方 ctx 取消，
+// 以免某个调用方取消导致合并等待同一加载的其他调用方失败，需通过该配置限制加载的最长时间
+func WithLoadTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.loadTimeout = timeout
+	}
+}
+
+// WithNegativeTTL 配置未找到结果的缓存时间，GetOrLoad 加载返回未找到的错误时缓存该错误，
+// 期间相同 key 的请求直接返回该错误，避免缓存穿透，默认不缓存
+func WithNegativeTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.negativeTTL = ttl
+	}
+}
+
+// WithNotFound 配置判断加载错误是否表示未找到的函数，默认判断错误是否为 ErrNotFound
+func WithNotFound(fn func(err error) bool) Option {
+	return func(o *options) {
+		o.notFound = fn
+	}
+}
+
+// WithInvalidation 配置分布式失效通知，Invalidate 在删除本地缓存项的同时向 topic 发布失效通知，
+// 订阅 topic 的其他实例收到通知后删除本地缓存项，key 以 json 编码传输
+func WithInvalidation(pubsub distributed.PubSub, topic string) Option {
+	return func(o *options) {
+		o.pubsub = pubsub
+		o.topic = topic
+	}
+}
+
+// WithRequests 统计不同结果的累计请求次数，结果包括 hit 及 miss
+func WithRequests(c metric.Int64Counter) Option {
+	return func(o *options) {
+		o.requests = c
+	}
+}
+
+// WithEvictions 统计不同原因的累计淘汰次数，原因包括 capacity 及 expired
+func WithEvictions(c metric.Int64Counter) Option {
+	return func(o *options) {
+		o.evictions = c
+	}
+}
+
+// WithLoadSeconds 统计不同结果的加载耗时分布，结果包括 success、failure 及 not_found
+func WithLoadSeconds(histogram metric.Float64Histogram) Option {
+	return func(o *options) {
+		o.loadSeconds = histogram
+	}
+}
+
+// DefaultRequestsCounter 请求计数器，构造完成后可通过 WithRequests 统计累计请求次数
+func DefaultRequestsCounter(meter metric.Meter, name string) (metric.Int64Counter, error) {
+	return meter.Int64Counter(name, metric.WithUnit("{request}"))
+}
+
+// DefaultEvictionsCounter 淘汰计数器，构造完成后可通过 WithEvictions 统计累计淘汰次数
+func DefaultEvictionsCounter(meter metric.Meter, name string) (metric.Int64Counter, error) {
+	return meter.Int64Counter(name, metric.WithUnit("{eviction}"))
+}
+
+// DefaultLoadSecondsHistogram 加载耗时直方图，构造完成后可通过 WithLoadSeconds 统计加载耗时的分布情况
+func DefaultLoadSecondsHistogram(meter metric.Meter, name string) (metric.Float64Histogram, error) {
+	return meter.Float64Histogram(
+		name,
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1),
+	)
+}
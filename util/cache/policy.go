@@ -0,0 +1,263 @@
+package cache
+
+import (
+	"container/list"
+	"time"
+)
+
+// segment 缓存项所在的 W-TinyLFU 分区
+type segment uint8
+
+const (
+	segmentWindow segment = iota
+	segmentProbation
+	segmentProtected
+)
+
+// entry 缓存项
+type entry[K comparable, V any] struct {
+	key      K
+	value    V
+	err      error     // 未找到结果的缓存项保存加载错误
+	cost     int64     // 缓存项的成本
+	weight   int64     // 缓存项在淘汰策略中占用的容量，限制总成本时为成本，否则为1
+	hash     uint64    // key 的哈希值，用于估计访问频率
+	expireAt time.Time // 过期时间，为零值时不过期
+	elem     *list.Element
+	segment  segment
+}
+
+func (e *entry[K, V]) expired(now time.Time) bool {
+	return !e.expireAt.IsZero() && !now.Before(e.expireAt)
+}
+
+// policy 淘汰策略，仅维护缓存项的淘汰顺序，调用方需持有缓存的锁
+type policy[K comparable, V any] interface {
+	// add 添加缓存项
+	add(e *entry[K, V])
+	// access 访问缓存项
+	access(e *entry[K, V])
+	// remove 移除缓存项
+	remove(e *entry[K, V])
+	// victim 返回下一个应被淘汰的缓存项，不会将其移除，不存在时返回 nil
+	victim() *entry[K, V]
+	// clear 移除全部缓存项
+	clear()
+}
+
+func newPolicy[K comparable, V any](p Policy, capacity int64, sketchWidth int) policy[K, V] {
+	if p == PolicyTinyLFU {
+		return newTinyLFU[K, V](capacity, sketchWidth)
+	}
+	return &lru[K, V]{ll: list.New()}
+}
+
+// lru 淘汰最久未访问的缓存项
+type lru[K comparable, V any] struct {
+	ll *list.List
+}
+
+func (p *lru[K, V]) add(e *entry[K, V]) {
+	e.elem = p.ll.PushFront(e)
+}
+
+func (p *lru[K, V]) access(e *entry[K, V]) {
+	p.ll.MoveToFront(e.elem)
+}
+
+func (p *lru[K, V]) remove(e *entry[K, V]) {
+	p.ll.Remove(e.elem)
+}
+
+func (p *lru[K, V]) victim() *entry[K, V] {
+	if back := p.ll.Back(); back != nil {
+		return back.Value.(*entry[K, V])
+	}
+	return nil
+}
+
+func (p *lru[K, V]) clear() {
+	p.ll.Init()
+}
+
+// weightedList 记录总容量的 LRU 链表
+type weightedList struct {
+	ll     *list.List
+	weight int64
+}
+
+// tinyLFU W-TinyLFU 策略，容量划分为窗口区（1%）及主区，主区为分段 LRU，由试用区及保护区（主区的80%）组成。
+// 新的缓存项进入窗口区，被挤出窗口区时与试用区的淘汰候选比较访问频率，频率更高者进入主区；
+// 试用区的缓存项再次被访问时晋升至保护区，保护区超出容量时最久未访问的缓存项降级至试用区
+type tinyLFU[K comparable, V any] struct {
+	window, probation, protected weightedList
+
+	windowCap    int64
+	mainCap      int64
+	protectedCap int64
+	sketch       *sketch
+}
+
+func newTinyLFU[K comparable, V any](capacity int64, sketchWidth int) *tinyLFU[K, V] {
+	windowCap := max(capacity/100, 1)
+	mainCap := max(capacity-windowCap, 0)
+	return &tinyLFU[K, V]{
+		window:       weightedList{ll: list.New()},
+		probation:    weightedList{ll: list.New()},
+		protected:    weightedList{ll: list.New()},
+		windowCap:    windowCap,
+		mainCap:      mainCap,
+		protectedCap: mainCap * 8 / 10,
+		sketch:       newSketch(sketchWidth),
+	}
+}
+
+func (p *tinyLFU[K, V]) list(s segment) *weightedList {
+	switch s {
+	case segmentProbation:
+		return &p.probation
+	case segmentProtected:
+		return &p.protected
+	default:
+		return &p.window
+	}
+}
+
+// move 将缓存项移至分区 s 的头部
+func (p *tinyLFU[K, V]) move(e *entry[K, V], s segment) {
+	from, to := p.list(e.segment), p.list(s)
+	from.ll.Remove(e.elem)
+	from.weight -= e.weight
+	e.elem = to.ll.PushFront(e)
+	to.weight += e.weight
+	e.segment = s
+}
+
+func (p *tinyLFU[K, V]) add(e *entry[K, V]) {
+	p.sketch.increment(e.hash)
+	e.segment = segmentWindow
+	e.elem = p.window.ll.PushFront(e)
+	p.window.weight += e.weight
+	// 窗口区超出容量且主区仍有空闲容量时，窗口区最久未访问的缓存项直接进入主区
+	for p.window.weight > p.windowCap {
+		candidate := p.window.ll.Back().Value.(*entry[K, V])
+		if p.probation.weight+p.protected.weight+candidate.weight > p.mainCap {
+			break
+		}
+		p.move(candidate, segmentProbation)
+	}
+}
+
+func (p *tinyLFU[K, V]) access(e *entry[K, V]) {
+	p.sketch.increment(e.hash)
+	switch e.segment {
+	case segmentProbation:
+		p.move(e, segmentProtected)
+		// 保护区超出容量时，最久未访问的缓存项降级至试用区
+		for p.protected.weight > p.protectedCap {
+			p.move(p.protected.ll.Back().Value.(*entry[K, V]), segmentProbation)
+		}
+	default:
+		p.list(e.segment).ll.MoveToFront(e.elem)
+	}
+}
+
+func (p *tinyLFU[K, V]) remove(e *entry[K, V]) {
+	l := p.list(e.segment)
+	l.ll.Remove(e.elem)
+	l.weight -= e.weight
+}
+
+// mainVictim 主区的淘汰候选，优先选择试用区最久未访问的缓存项
+func (p *tinyLFU[K, V]) mainVictim() *entry[K, V] {
+	if back := p.probation.ll.Back(); back != nil {
+		return back.Value.(*entry[K, V])
+	}
+	if back := p.protected.ll.Back(); back != nil {
+		return back.Value.(*entry[K, V])
+	}
+	return nil
+}
+
+func (p *tinyLFU[K, V]) victim() *entry[K, V] {
+	for p.window.weight > p.windowCap {
+		candidate := p.window.ll.Back().Value.(*entry[K, V])
+		victim := p.mainVictim()
+		if victim == nil {
+			return candidate
+		}
+		// 访问频率更高者进入或留在主区
+		if p.sketch.estimate(candidate.hash) > p.sketch.estimate(victim.hash) {
+			p.move(candidate, segmentProbation)
+			return victim
+		}
+		return candidate
+	}
+	if victim := p.mainVictim(); victim != nil {
+		return victim
+	}
+	if back := p.window.ll.Back(); back != nil {
+		return back.Value.(*entry[K, V])
+	}
+	return nil
+}
+
+func (p *tinyLFU[K, V]) clear() {
+	for _, l := range []*weightedList{&p.window, &p.probation, &p.protected} {
+		l.ll.Init()
+		l.weight = 0
+	}
+}
+
+// sketch 记录访问频率的 Count-Min Sketch，每个计数器最大为15，
+// 累计记录次数达到计数器数量的10倍时全部计数器减半，使频率估计偏向近期的访问
+type sketch struct {
+	rows      [4][]uint8
+	mask      uint64
+	additions int
+	resetAt   int
+}
+
+var sketchSeeds = [4]uint64{0xc3a5c85c97cb3127, 0xb492b66fbe98f273, 0x9ae16a3b2f90404f, 0xcbf29ce484222325}
+
+func newSketch(width int) *sketch {
+	n := 16
+	for n < width {
+		n <<= 1
+	}
+	s := &sketch{mask: uint64(n - 1), resetAt: 10 * n}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, n)
+	}
+	return s
+}
+
+func (s *sketch) index(i int, hash uint64) uint64 {
+	h := (hash ^ sketchSeeds[i]) * 0x9e3779b97f4a7c15
+	return (h >> 32) & s.mask
+}
+
+func (s *sketch) increment(hash uint64) {
+	for i := range s.rows {
+		if c := &s.rows[i][s.index(i, hash)]; *c < 15 {
+			*c++
+		}
+	}
+	s.additions++
+	if s.additions >= s.resetAt {
+		for i := range s.rows {
+			for j := range s.rows[i] {
+				s.rows[i][j] >>= 1
+			}
+		}
+		s.additions /= 2
+	}
+}
+
+func (s *sketch) estimate(hash uint64) uint8 {
+	m := uint8(15)
+	for i := range s.rows {
+		m = min(m, s.rows[i][s.index(i, hash)])
+	}
+	return m
+}
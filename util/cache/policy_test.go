@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSketch(t *testing.T) {
+	s := newSketch(64)
+	for range 5 {
+		s.increment(1)
+	}
+	s.increment(2)
+	assert.EqualValues(t, 5, s.estimate(1))
+	assert.EqualValues(t, 1, s.estimate(2))
+	assert.Zero(t, s.estimate(3))
+
+	// 计数器最大为15
+	for range 20 {
+		s.increment(1)
+	}
+	assert.EqualValues(t, 15, s.estimate(1))
+
+	// 累计记录次数达到上限后计数器减半
+	for i := range s.resetAt {
+		s.increment(uint64(i) + 100)
+	}
+	assert.Less(t, s.estimate(1), uint8(15))
+}
+
+func TestTinyLFU_ScanResistance(t *testing.T) {
+	const size = 100
+	c := New[int, int](WithMaxSize(size), WithPolicy(PolicyTinyLFU))
+	// 热点数据被多次访问
+	for range 5 {
+		for k := range size / 2 {
+			if _, ok := c.Get(k); !ok {
+				c.Set(k, k)
+			}
+		}
+	}
+	// 一次性扫描大量冷数据
+	for k := 1000; k < 1000+10*size; k++ {
+		c.Set(k, k)
+	}
+	assert.Equal(t, size, c.Len())
+	hits := 0
+	for k := range size / 2 {
+		if _, ok := c.Get(k); ok {
+			hits++
+		}
+	}
+	assert.Greater(t, hits, size*2/5)
+
+	// LRU 下扫描会冲刷全部热点数据
+	lru := New[int, int](WithMaxSize(size))
+	for k := range size / 2 {
+		lru.Set(k, k)
+	}
+	for k := 1000; k < 1000+10*size; k++ {
+		lru.Set(k, k)
+	}
+	for k := range size / 2 {
+		_, ok := lru.Get(k)
+		assert.False(t, ok)
+	}
+}
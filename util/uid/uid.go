@@ -1,7 +1,9 @@
 package uid
 
 import (
+	"context"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/bwmarrin/snowflake"
@@ -38,25 +40,49 @@ func XID() string {
 	return xid.New().String()
 }
 
-var snowflakeNode *snowflake.Node
+// snowflakeGenerator SnowflakeID 使用的生成器，ready 不为 nil 时生成已暂停，并在恢复生成时关闭
+type snowflakeGenerator struct {
+	node  *snowflake.Node
+	num   int64 // 节点编号
+	ready chan struct{}
+}
+
+var snowflakeGen atomic.Pointer[snowflakeGenerator]
 
 func init() {
 	// 自2020年1月1日计算，可使用至2090年
 	snowflake.Epoch = 1577808000000
-	var err error
-	node := SnowflakeNode()
-	snowflakeNode, err = snowflake.NewNode(node)
-	if err != nil {
+	if err := SetSnowflakeNode(SnowflakeNode()); err != nil {
 		panic(fmt.Sprintf("generate snowflake node failed: %v", err))
 	}
 }
 
-// SnowflakeID 生成雪花id
+// SnowflakeID 生成雪花id，生成已被 SuspendSnowflakeNode 暂停时返回0，需等待恢复生成的调用方应使用 SnowflakeIDContext
 func SnowflakeID() snowflake.ID {
-	return snowflakeNode.Generate()
+	g := snowflakeGen.Load()
+	if g.ready != nil {
+		return 0
+	}
+	return g.node.Generate()
 }
 
-// SnowflakeNode 返回当前机器的节点编号，由于默认情况下雪花算法只有10bit节点号，使用这种默认生成的节点编号，有比较大的概率会重复
+// SnowflakeIDContext 生成雪花id，生成已被 SuspendSnowflakeNode 暂停时阻塞直至恢复生成或 ctx 结束
+func SnowflakeIDContext(ctx context.Context) (snowflake.ID, error) {
+	for {
+		g := snowflakeGen.Load()
+		if g.ready == nil {
+			return g.node.Generate(), nil
+		}
+		select {
+		case <-g.ready:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// SnowflakeNode 返回当前机器的节点编号，由于默认情况下雪花算法只有10bit节点号，使用这种默认生成的节点编号，有比较大的概率会重复，
+// 多实例部署时建议通过 distributed.InitSnowflakeNode 在 etcd 中分配唯一的节点编号
 func SnowflakeNode() int64 {
 	bits := snowflake.NodeBits
 	length := int(bits)/8 + 1
@@ -65,6 +91,52 @@ func SnowflakeNode() int64 {
 	return int64(node)
 }
 
+// SetSnowflakeNode 以指定的节点编号初始化 SnowflakeID 使用的生成器并恢复已暂停的生成，节点编号需位于 [0, 2^NodeBits) 内。
+// 节点编号未变化时沿用原生成器，避免重置序列号后同一毫秒内生成重复的 id
+func SetSnowflakeNode(node int64) error {
+	for {
+		prev := snowflakeGen.Load()
+		next := &snowflakeGenerator{num: node}
+		switch {
+		case prev != nil && prev.num == node && prev.ready == nil:
+			return nil
+		case prev != nil && prev.num == node:
+			next.node = prev.node
+		default:
+			n, err := snowflake.NewNode(node)
+			if err != nil {
+				return err
+			}
+			next.node = n
+		}
+		if snowflakeGen.CompareAndSwap(prev, next) {
+			if prev != nil && prev.ready != nil {
+				close(prev.ready)
+			}
+			return nil
+		}
+	}
+}
+
+// SuspendSnowflakeNode 暂停 SnowflakeID 的生成直至 SetSnowflakeNode 设置节点编号，期间 SnowflakeID 返回0，SnowflakeIDContext 阻塞，
+// 用于节点编号的租约丢失后、重新申请成功前，避免与占用了该节点编号的其他实例生成重复的 id
+func SuspendSnowflakeNode() {
+	for {
+		g := snowflakeGen.Load()
+		if g.ready != nil {
+			return
+		}
+		if snowflakeGen.CompareAndSwap(g, &snowflakeGenerator{node: g.node, num: g.num, ready: make(chan struct{})}) {
+			return
+		}
+	}
+}
+
+// CurrentSnowflakeNode 返回 SnowflakeID 当前使用的节点编号
+func CurrentSnowflakeNode() int64 {
+	return snowflakeGen.Load().num
+}
+
 var numericUIDGenerator *NumericUIDGenerator
 
 func init() {
@@ -1,11 +1,13 @@
 package uid
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/bwmarrin/snowflake"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestUUID(t *testing.T) {
@@ -53,6 +55,16 @@ func TestSnowflakeIDGeneration(t *testing.T) {
 	assert.True(t, id3 < id4, "SnowflakeIDs should be generated in increasing order given a time gap")
 }
 
+func TestSetSnowflakeNode(t *testing.T) {
+	defer SetSnowflakeNode(SnowflakeNode())
+
+	assert.NoError(t, SetSnowflakeNode(7))
+	assert.EqualValues(t, 7, CurrentSnowflakeNode())
+	assert.EqualValues(t, 7, SnowflakeID().Node())
+	assert.Error(t, SetSnowflakeNode(1<<snowflake.NodeBits))
+	assert.EqualValues(t, 7, CurrentSnowflakeNode())
+}
+
 func TestNumericUID(t *testing.T) {
 	id := uint64(123456)
 	uid := NumericUID(id)
@@ -63,3 +75,57 @@ func TestNumericUIDNano(t *testing.T) {
 	uid := NumericUIDNano()
 	assert.True(t, uid >= 100000000 && uid <= 999999999, "NumericUIDNano should be within the valid range")
 }
+
+func TestSuspendSnowflakeNode(t *testing.T) {
+	defer SetSnowflakeNode(SnowflakeNode())
+
+	SuspendSnowflakeNode()
+	SuspendSnowflakeNode()
+	// 暂停期间 SnowflakeID 不阻塞，返回0
+	assert.Zero(t, SnowflakeID())
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := SnowflakeIDContext(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	generated := make(chan snowflake.ID, 1)
+	go func() {
+		id, err := SnowflakeIDContext(context.Background())
+		assert.NoError(t, err)
+		generated <- id
+	}()
+	select {
+	case <-generated:
+		t.Fatal("SnowflakeIDContext should block while suspended")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	assert.NoError(t, SetSnowflakeNode(9))
+	select {
+	case id := <-generated:
+		assert.EqualValues(t, 9, id.Node())
+	case <-time.After(time.Second):
+		t.Fatal("SnowflakeIDContext should resume after SetSnowflakeNode")
+	}
+	assert.EqualValues(t, 9, SnowflakeID().Node())
+}
+
+func TestSetSnowflakeNode_Unchanged(t *testing.T) {
+	defer SetSnowflakeNode(SnowflakeNode())
+
+	// 节点编号未变化时沿用原生成器，同一毫秒内重复设置不会生成重复的 id
+	require.NoError(t, SetSnowflakeNode(5))
+	ids := make(map[snowflake.ID]bool)
+	for range 1000 {
+		require.NoError(t, SetSnowflakeNode(5))
+		id := SnowflakeID()
+		require.False(t, ids[id], id)
+		ids[id] = true
+
+		SuspendSnowflakeNode()
+		require.NoError(t, SetSnowflakeNode(5))
+		id = SnowflakeID()
+		require.False(t, ids[id], id)
+		ids[id] = true
+	}
+}